@@ -0,0 +1,309 @@
+// This package holds the format-independent parts of the binary codecs (omap/msgpack and
+// omap/cbor): walking Go values (including ordered maps) to be encoded, and converting the
+// generic decoded values back into the typed keys/values of a map.
+package codecutil
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+// Writer is implemented by each format to write the basic items, Encode drives it.
+type Writer interface {
+	Nil()
+	Bool(b bool)
+	Int(i int64)
+	Uint(u uint64)
+	Float32(f float32)
+	Float64(f float64)
+	String(s string)
+	Bytes(b []byte)
+	ArrayHeader(n int)
+	MapHeader(n int)
+	// Append already encoded data.
+	Raw(b []byte)
+	// Create a new empty writer of the same format.
+	New() Writer
+	// Return the data encoded so far.
+	Encoded() []byte
+}
+
+type pair struct {
+	key   reflect.Value
+	value reflect.Value
+}
+
+// Encode the given value v into w. Ordered maps (any type with an Iterator method returning an
+// omap.OMapIterator) are encoded in iteration order, builtin maps are encoded with keys sorted by
+// their encoded form, so the output is always deterministic.
+func Encode(w Writer, v any) error {
+	return encodeValue(w, reflect.ValueOf(v))
+}
+
+// Encode the content of the given iterator as a map into w, in iteration order.
+func EncodeIterator[K comparable, V any](w Writer, it omap.OMapIterator[K, V]) error {
+	var pairs []pair
+	for it.Next() {
+		pairs = append(pairs, pair{reflect.ValueOf(it.Key()), reflect.ValueOf(it.Value())})
+	}
+	return encodePairs(w, pairs)
+}
+
+func encodePairs(w Writer, pairs []pair) error {
+	w.MapHeader(len(pairs))
+	for _, p := range pairs {
+		if err := encodeValue(w, p.key); err != nil {
+			return err
+		}
+		if err := encodeValue(w, p.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Return the key/values of v if it is an ordered map, using reflection to call its Iterator method.
+func orderedPairs(v reflect.Value) ([]pair, bool) {
+	method := v.MethodByName("Iterator")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return nil, false
+	}
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, false
+	}
+	it := method.Call(nil)[0]
+	next, key, value := it.MethodByName("Next"), it.MethodByName("Key"), it.MethodByName("Value")
+	if !next.IsValid() || !key.IsValid() || !value.IsValid() {
+		return nil, false
+	}
+	pairs := make([]pair, 0)
+	for next.Call(nil)[0].Bool() {
+		pairs = append(pairs, pair{key.Call(nil)[0], value.Call(nil)[0]})
+	}
+	return pairs, true
+}
+
+func encodeValue(w Writer, v reflect.Value) error {
+	if !v.IsValid() {
+		w.Nil()
+		return nil
+	}
+	if pairs, ok := orderedPairs(v); ok {
+		return encodePairs(w, pairs)
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			w.Nil()
+			return nil
+		}
+		return encodeValue(w, v.Elem())
+	case reflect.Bool:
+		w.Bool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		w.Int(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		w.Uint(v.Uint())
+	case reflect.Float32:
+		w.Float32(float32(v.Float()))
+	case reflect.Float64:
+		w.Float64(v.Float())
+	case reflect.String:
+		w.String(v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			w.Nil()
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			w.Bytes(b)
+			return nil
+		}
+		w.ArrayHeader(v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeValue(w, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			w.Nil()
+			return nil
+		}
+		type encodedPair struct {
+			key   []byte
+			value reflect.Value
+		}
+		pairs := make([]encodedPair, 0, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			kw := w.New()
+			if err := encodeValue(kw, iter.Key()); err != nil {
+				return err
+			}
+			pairs = append(pairs, encodedPair{kw.Encoded(), iter.Value()})
+		}
+		sort.Slice(pairs, func(i, j int) bool { return bytes.Compare(pairs[i].key, pairs[j].key) < 0 })
+		w.MapHeader(len(pairs))
+		for _, p := range pairs {
+			w.Raw(p.key)
+			if err := encodeValue(w, p.value); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return nil
+}
+
+// Maximum nesting depth of arrays and maps (and CBOR tags) accepted by the decoders, as each level
+// takes only one byte of input, deeper data could overflow the stack (same limit of encoding/json).
+const MaxDepth = 10000
+
+// Create a new map to hold a nested map found while decoding. Nested maps are always decoded as
+// omap.OMap[string, any] to keep the order, keys that are not strings are formatted with fmt.Sprint.
+func NewNestedMap() omap.OMap[string, any] {
+	return omap.New[string, any]()
+}
+
+// Put the given decoded key/value into a nested map created by NewNestedMap.
+func PutNested(m omap.OMap[string, any], key any, value any) {
+	if s, ok := key.(string); ok {
+		m.Put(s, value)
+	} else {
+		m.Put(fmt.Sprint(key), value)
+	}
+}
+
+// Convert a decoded value into type T, see Assign.
+func Convert[T any](src any) (T, error) {
+	var ret T
+	err := Assign(reflect.ValueOf(&ret).Elem(), src)
+	return ret, err
+}
+
+// Assign the generic decoded value src (nil, bool, int64, uint64, float64, string, []byte, []any or
+// omap.OMap[string, any]) into dst, converting it to dst type if needed and possible.
+func Assign(dst reflect.Value, src any) error {
+	if src == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	sv := reflect.ValueOf(src)
+	if sv.Type().AssignableTo(dst.Type()) {
+		dst.Set(sv)
+		return nil
+	}
+	if _, ok := src.(omap.OMap[string, any]); ok {
+		if _, ordered := dst.Type().MethodByName("Iterator"); ordered {
+			return fmt.Errorf("cannot assign nested map to %v, ordered maps can only be decoded as omap.OMap[string, any]", dst.Type())
+		}
+	}
+	switch dst.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch s := src.(type) {
+		case int64:
+			if !dst.OverflowInt(s) {
+				dst.SetInt(s)
+				return nil
+			}
+		case uint64:
+			if s <= math.MaxInt64 && !dst.OverflowInt(int64(s)) {
+				dst.SetInt(int64(s))
+				return nil
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch s := src.(type) {
+		case uint64:
+			if !dst.OverflowUint(s) {
+				dst.SetUint(s)
+				return nil
+			}
+		case int64:
+			if s >= 0 && !dst.OverflowUint(uint64(s)) {
+				dst.SetUint(uint64(s))
+				return nil
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		switch s := src.(type) {
+		case float64:
+			dst.SetFloat(s)
+			return nil
+		case int64:
+			dst.SetFloat(float64(s))
+			return nil
+		case uint64:
+			dst.SetFloat(float64(s))
+			return nil
+		}
+	case reflect.Bool:
+		if s, ok := src.(bool); ok {
+			dst.SetBool(s)
+			return nil
+		}
+	case reflect.String:
+		switch s := src.(type) {
+		case string:
+			dst.SetString(s)
+			return nil
+		case []byte:
+			dst.SetString(string(s))
+			return nil
+		}
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() == reflect.Uint8 {
+			switch s := src.(type) {
+			case []byte:
+				dst.SetBytes(append([]byte(nil), s...))
+				return nil
+			case string:
+				dst.SetBytes([]byte(s))
+				return nil
+			}
+		}
+		if s, ok := src.([]any); ok {
+			slice := reflect.MakeSlice(dst.Type(), len(s), len(s))
+			for i := range s {
+				if err := Assign(slice.Index(i), s[i]); err != nil {
+					return fmt.Errorf("at index %d: %w", i, err)
+				}
+			}
+			dst.Set(slice)
+			return nil
+		}
+	case reflect.Map:
+		if s, ok := src.(omap.OMap[string, any]); ok {
+			m := reflect.MakeMapWithSize(dst.Type(), s.Len())
+			for it := s.Iterator(); it.Next(); {
+				key := reflect.New(dst.Type().Key()).Elem()
+				if err := Assign(key, it.Key()); err != nil {
+					return fmt.Errorf("at key %q: %w", it.Key(), err)
+				}
+				value := reflect.New(dst.Type().Elem()).Elem()
+				if err := Assign(value, it.Value()); err != nil {
+					return fmt.Errorf("at key %q: %w", it.Key(), err)
+				}
+				m.SetMapIndex(key, value)
+			}
+			dst.Set(m)
+			return nil
+		}
+	case reflect.Ptr:
+		ptr := reflect.New(dst.Type().Elem())
+		if err := Assign(ptr.Elem(), src); err != nil {
+			return err
+		}
+		dst.Set(ptr)
+		return nil
+	}
+	return fmt.Errorf("cannot assign value of type %T to %v", src, dst.Type())
+}
@@ -0,0 +1,30 @@
+// This package formats Go types as they are written in Go source, to build the "%#v" output of the
+// maps (see omap.IteratorToGoString).
+package gotype
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// a quoted string (struct tags, kept as is) or the import path of a package, up to its last "/"
+var importPath = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|[\w.\-~+]+/`)
+
+// Return the Go source form of the type t, as reflect.Type.String does, but qualifying the types
+// used as type arguments of generic types by their package name instead of their import path (e.g.
+// "omap.OMap[string, url.URL]" instead of "omap.OMap[string,net/url.URL]").
+// The package name is taken as the last element of the import path, so types of packages whose
+// name differs from it (e.g. "gopkg.in/yaml.v3") are not valid Go source.
+func String(t reflect.Type) string {
+	s := t.String()
+	if !strings.Contains(s, "[") {
+		return s
+	}
+	return importPath.ReplaceAllStringFunc(s, func(match string) string {
+		if strings.HasPrefix(match, `"`) {
+			return match
+		}
+		return ""
+	})
+}
@@ -0,0 +1,31 @@
+package gotype_test
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/matheusoliveira/go-ordered-map/internal/gotype"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+func TestString(t *testing.T) {
+	for _, tc := range []struct {
+		value    any
+		expected string
+	}{
+		{0, "int"},
+		{[]*url.URL{}, "[]*url.URL"},
+		{map[string]interface{ String() string }{}, "map[string]interface { String() string }"},
+		{omap.OMapLinked[string, int]{}, "omap.OMapLinked[string,int]"},
+		{map[string]*omap.OMapLinked[string, []url.URL]{}, "map[string]*omap.OMapLinked[string,[]url.URL]"},
+		{omap.OMapLinked[url.URL, omap.OMapLinked[string, *url.Userinfo]]{}, "omap.OMapLinked[url.URL,omap.OMapLinked[string,*url.Userinfo]]"},
+		{omap.OMapLinked[string, struct {
+			A url.URL `json:"a/b"`
+		}]{}, `omap.OMapLinked[string,struct { A url.URL "json:\"a/b\"" }]`},
+	} {
+		if s := gotype.String(reflect.TypeOf(tc.value)); s != tc.expected {
+			t.Errorf("expected %s, found %s", tc.expected, s)
+		}
+	}
+}
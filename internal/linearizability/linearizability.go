@@ -0,0 +1,216 @@
+// This package offers a small linearizability checker, to validate histories of operations
+// executed concurrently against an object (e.g. omap.OMapSync) against a sequential model of it.
+//
+// Operations are recorded with History.Record, which takes a timestamp (from the monotonic clock)
+// when the operation is invoked and when it returns, and then Check searches for a valid
+// linearization using the algorithm from Wing & Gong, with the improvements from Lowe (caching of
+// visited states), see: "Testing for linearizability", Gavin Lowe, 2017.
+package linearizability
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Returned, wrapped, by Check when a history is not linearizable.
+var ErrNotLinearizable = errors.New("history is not linearizable")
+
+// Operation is a call to the object under test, with the times it was invoked and returned. An
+// operation A happened before B if A.Return < B.Call, otherwise they are concurrent.
+type Operation[I, O any] struct {
+	Client int
+	Input  I
+	Output O
+	Call   int64
+	Return int64
+}
+
+// Model is a sequential specification of the object under test.
+type Model[S, I, O any] struct {
+	// Returns the initial state.
+	Init func() S
+	// Applies input to state, returning true if output is a valid result for it along with the new
+	// state. Must not modify the given state, as it is kept for backtracking.
+	Step func(state S, input I, output O) (bool, S)
+	// Returns true if both states are the same.
+	Equal func(s1, s2 S) bool
+	// Describes an operation in error messages, optional.
+	Describe func(input I, output O) string
+}
+
+// History of operations executed by a fixed number of clients, each one expected to run on its
+// own goroutine. Clients don't synchronize with each other when recording, so the race detector
+// is still able to find unsynchronized accesses in the object under test.
+type History[I, O any] struct {
+	start time.Time
+	ops   [][]Operation[I, O]
+}
+
+// Create a new History for the given number of clients, identified from 0 to nClients-1.
+func NewHistory[I, O any](nClients int) *History[I, O] {
+	return &History[I, O]{start: time.Now(), ops: make([][]Operation[I, O], nClients)}
+}
+
+// Run fct, recording it as an operation of client with the given input and the returned output.
+// Must not be called concurrently for the same client.
+func (h *History[I, O]) Record(client int, input I, fct func() O) O {
+	call := int64(time.Since(h.start))
+	output := fct()
+	ret := int64(time.Since(h.start))
+	h.ops[client] = append(h.ops[client], Operation[I, O]{Client: client, Input: input, Output: output, Call: call, Return: ret})
+	return output
+}
+
+// Return the operations of all clients, must be called after all clients have finished.
+func (h *History[I, O]) Operations() []Operation[I, O] {
+	var ret []Operation[I, O]
+	for _, ops := range h.ops {
+		ret = append(ret, ops...)
+	}
+	return ret
+}
+
+// call or return event of an operation, in a doubly linked list ordered by time
+type event struct {
+	id     int
+	isCall bool
+	time   int64
+	match  *event // return event of a call
+	prev   *event
+	next   *event
+}
+
+// remove call event e and its return from the list
+func (e *event) lift() {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	r := e.match
+	r.prev.next = r.next
+	if r.next != nil {
+		r.next.prev = r.prev
+	}
+}
+
+// undo lift
+func (e *event) unlift() {
+	r := e.match
+	r.prev.next = r
+	if r.next != nil {
+		r.next.prev = r
+	}
+	e.prev.next = e
+	e.next.prev = e
+}
+
+type bitset []uint64
+
+func (b bitset) set(i int) bitset {
+	b[i/64] |= 1 << (uint(i) % 64)
+	return b
+}
+
+func (b bitset) clear(i int) bitset {
+	b[i/64] &^= 1 << (uint(i) % 64)
+	return b
+}
+
+func (b bitset) key() string {
+	var sb strings.Builder
+	for _, w := range b {
+		fmt.Fprintf(&sb, "%016x", w)
+	}
+	return sb.String()
+}
+
+// Check if the history of operations is linearizable according to model, returning nil if so,
+// or an error wrapping ErrNotLinearizable describing the longest valid linearization found.
+func Check[S, I, O any](model Model[S, I, O], ops []Operation[I, O]) error {
+	// build the list of events
+	events := make([]*event, 0, len(ops)*2)
+	for i, op := range ops {
+		ret := &event{id: i, time: op.Return}
+		events = append(events, &event{id: i, isCall: true, time: op.Call, match: ret}, ret)
+	}
+	// on ties, calls go first, so the operations are considered concurrent
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].time < events[j].time || (events[i].time == events[j].time && events[i].isCall && !events[j].isCall)
+	})
+	head := &event{}
+	prev := head
+	for _, e := range events {
+		e.prev = prev
+		prev.next = e
+		prev = e
+	}
+	type frame struct {
+		e     *event
+		state S
+	}
+	var stack []frame
+	var longest []int
+	linearized := make(bitset, len(ops)/64+1)
+	cache := make(map[string][]S)
+	state := model.Init()
+	for e := head.next; head.next != nil; {
+		if e.isCall {
+			op := ops[e.id]
+			if ok, newState := model.Step(state, op.Input, op.Output); ok {
+				linearized.set(e.id)
+				key := linearized.key()
+				seen := false
+				for _, s := range cache[key] {
+					if model.Equal(s, newState) {
+						seen = true
+						break
+					}
+				}
+				if !seen {
+					cache[key] = append(cache[key], newState)
+					stack = append(stack, frame{e, state})
+					state = newState
+					e.lift()
+					if len(stack) > len(longest) {
+						longest = longest[:0]
+						for _, f := range stack {
+							longest = append(longest, f.e.id)
+						}
+					}
+					e = head.next
+					continue
+				}
+				linearized.clear(e.id)
+			}
+			e = e.next
+		} else {
+			// a return was reached without linearizing its call, so backtrack
+			if len(stack) == 0 {
+				return notLinearizable(model, ops, longest)
+			}
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			state = top.state
+			linearized.clear(top.e.id)
+			top.e.unlift()
+			e = top.e.next
+		}
+	}
+	return nil
+}
+
+func notLinearizable[S, I, O any](model Model[S, I, O], ops []Operation[I, O], longest []int) error {
+	describe := model.Describe
+	if describe == nil {
+		describe = func(input I, output O) string {
+			return fmt.Sprintf("%v -> %v", input, output)
+		}
+	}
+	var sb strings.Builder
+	for _, id := range longest {
+		op := ops[id]
+		fmt.Fprintf(&sb, "\n  client %d [%d, %d]: %s", op.Client, op.Call, op.Return, describe(op.Input, op.Output))
+	}
+	return fmt.Errorf("%w: longest linearization found has %d of %d operations:%s", ErrNotLinearizable, len(longest), len(ops), sb.String())
+}
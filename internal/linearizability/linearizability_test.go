@@ -0,0 +1,132 @@
+package linearizability
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// a register, input is the value to write or -1 to read
+var registerModel = Model[int, int, int]{
+	Init: func() int { return 0 },
+	Step: func(state int, input int, output int) (bool, int) {
+		if input < 0 {
+			return output == state, state
+		}
+		return true, input
+	},
+	Equal: func(s1, s2 int) bool { return s1 == s2 },
+}
+
+func op(client, input, output int, call, ret int64) Operation[int, int] {
+	return Operation[int, int]{Client: client, Input: input, Output: output, Call: call, Return: ret}
+}
+
+func TestCheck(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		ops          []Operation[int, int]
+		linearizable bool
+	}{
+		{"empty", nil, true},
+		{"sequential", []Operation[int, int]{op(0, 1, 0, 1, 2), op(0, -1, 1, 3, 4)}, true},
+		{"sequential stale read", []Operation[int, int]{op(0, 1, 0, 1, 2), op(1, -1, 0, 3, 4)}, false},
+		// read concurrent with write may return old or new value
+		{"concurrent old", []Operation[int, int]{op(0, 1, 0, 1, 4), op(1, -1, 0, 2, 3)}, true},
+		{"concurrent new", []Operation[int, int]{op(0, 1, 0, 1, 4), op(1, -1, 1, 2, 3)}, true},
+		{"concurrent invalid", []Operation[int, int]{op(0, 1, 0, 1, 4), op(1, -1, 2, 2, 3)}, false},
+		// once a read sees the new value, a later read can't see the old one
+		{"new then old", []Operation[int, int]{op(0, 1, 0, 1, 10), op(1, -1, 1, 2, 3), op(2, -1, 0, 4, 5)}, false},
+		{"old then new", []Operation[int, int]{op(0, 1, 0, 1, 10), op(1, -1, 0, 2, 3), op(2, -1, 1, 4, 5)}, true},
+		// needs backtracking: write 2 must be linearized before write 1
+		{"backtracking", []Operation[int, int]{op(0, 1, 0, 1, 6), op(1, 2, 0, 2, 7), op(2, -1, 1, 8, 9)}, true},
+		// same state reached by different orders is not explored twice
+		{"cached state", []Operation[int, int]{op(0, 1, 0, 1, 6), op(1, 1, 0, 2, 7), op(2, -1, 5, 8, 9)}, false},
+		// same time is considered concurrent
+		{"tie", []Operation[int, int]{op(0, 1, 0, 1, 2), op(1, -1, 0, 2, 3)}, true},
+		{"two writes", []Operation[int, int]{op(0, 1, 0, 1, 6), op(1, 2, 0, 2, 7), op(2, -1, 1, 8, 9), op(2, -1, 2, 10, 11)}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Check(registerModel, tc.ops)
+			if tc.linearizable && err != nil {
+				t.Errorf("expected history to be linearizable, error: %v", err)
+			} else if !tc.linearizable && !errors.Is(err, ErrNotLinearizable) {
+				t.Errorf("expected ErrNotLinearizable, found %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckErrorMessage(t *testing.T) {
+	model := registerModel
+	ops := []Operation[int, int]{op(0, 1, 0, 1, 2), op(1, -1, 0, 3, 4)}
+	err := Check(model, ops)
+	if exp := "longest linearization found has 1 of 2 operations:\n  client 0 [1, 2]: 1 -> 0"; err == nil || !strings.HasSuffix(err.Error(), exp) {
+		t.Errorf("expected error ending with %q, found %v", exp, err)
+	}
+	model.Describe = func(input, output int) string { return fmt.Sprintf("write(%d)", input) }
+	if exp := "client 0 [1, 2]: write(1)"; err == nil || !strings.HasSuffix(Check(model, ops).Error(), exp) {
+		t.Errorf("expected error ending with %q, found %v", exp, err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	h := NewHistory[int, int](4)
+	var mx sync.Mutex
+	register := 0
+	var wg sync.WaitGroup
+	for c := 0; c < 4; c++ {
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				if i%2 == 0 {
+					h.Record(c, c*100+i, func() int {
+						mx.Lock()
+						defer mx.Unlock()
+						register = c*100 + i
+						return 0
+					})
+				} else {
+					h.Record(c, -1, func() int {
+						mx.Lock()
+						defer mx.Unlock()
+						return register
+					})
+				}
+			}
+		}(c)
+	}
+	wg.Wait()
+	ops := h.Operations()
+	if len(ops) != 80 {
+		t.Fatalf("expected 80 operations, found %d", len(ops))
+	}
+	for _, op := range ops {
+		if op.Call > op.Return {
+			t.Errorf("expected call before return, found %d > %d", op.Call, op.Return)
+		}
+	}
+	if err := Check(registerModel, ops); err != nil {
+		t.Error(err)
+	}
+	// a lost write must be detected
+	last := ops[len(ops)-1].Return
+	ops = append(ops, op(9, -1, 12345, last+1, last+2))
+	if err := Check(registerModel, ops); !errors.Is(err, ErrNotLinearizable) {
+		t.Errorf("expected ErrNotLinearizable, found %v", err)
+	}
+}
+
+func TestBitset(t *testing.T) {
+	b := make(bitset, 2)
+	b.set(0).set(70)
+	if k := b.key(); k != "00000000000000010000000000000040" {
+		t.Errorf("unexpected key %q", k)
+	}
+	if k := b.clear(70).key(); k != "00000000000000010000000000000000" {
+		t.Errorf("unexpected key %q", k)
+	}
+}
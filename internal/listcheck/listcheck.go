@@ -0,0 +1,60 @@
+// This package holds the validation of the doubly linked lists used by the linked maps (omap and
+// omultimap), so both check their lists in exactly the same way, see omap.Validator.
+package listcheck
+
+// Walk the doubly linked list from head to tail and then backwards, checking that both ends and
+// the prev/next links agree and that the list has exactly n entries, calling visit for each entry
+// in order. Each walk stops after n entries, so a cycle is reported instead of looping forever.
+// Each violation found is reported by calling addf with its description, as in fmt.Sprintf.
+func Validate[E any](addf func(format string, args ...any), head, tail *E, n int, next, prev func(*E) *E, visit func(i int, e *E)) {
+	if (head == nil) != (tail == nil) {
+		addf("only one of head and tail is nil")
+	}
+	if head != nil && prev(head) != nil {
+		addf("head has a prev entry")
+	}
+	if tail != nil && next(tail) != nil {
+		addf("tail has a next entry")
+	}
+	i, tooLong := 0, false
+	var last *E
+	for e := head; e != nil; e = next(e) {
+		if i == n {
+			addf("walking forward found more than the %d expected entries (cycle?)", n)
+			tooLong = true
+			break
+		}
+		if prev(e) != last {
+			addf("entry %d does not point back to entry %d", i, i-1)
+		}
+		visit(i, e)
+		last = e
+		i++
+	}
+	if i < n {
+		addf("walking forward found %d entries, expected %d", i, n)
+	}
+	if !tooLong && last != tail {
+		addf("walking forward ended at entry %d, which is not the tail", i-1)
+	}
+	i, tooLong = 0, false
+	var first *E
+	for e := tail; e != nil; e = prev(e) {
+		if i == n {
+			addf("walking backward found more than the %d expected entries (cycle?)", n)
+			tooLong = true
+			break
+		}
+		if next(e) != first {
+			addf("entry %d from the tail does not point forward to entry %d from the tail", i, i-1)
+		}
+		first = e
+		i++
+	}
+	if i < n {
+		addf("walking backward found %d entries, expected %d", i, n)
+	}
+	if !tooLong && first != head {
+		addf("walking backward ended at entry %d from the tail, which is not the head", i-1)
+	}
+}
@@ -0,0 +1,92 @@
+package listcheck_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/matheusoliveira/go-ordered-map/internal/listcheck"
+)
+
+type node struct {
+	id         int
+	next, prev *node
+}
+
+// return head and tail of a list with n nodes
+func newList(n int) (*node, *node) {
+	var head, tail *node
+	for i := 0; i < n; i++ {
+		e := &node{id: i, prev: tail}
+		if tail == nil {
+			head = e
+		} else {
+			tail.next = e
+		}
+		tail = e
+	}
+	return head, tail
+}
+
+func validate(head, tail *node, n int) ([]string, []int) {
+	var violations []string
+	var visited []int
+	listcheck.Validate(func(format string, args ...any) {
+		violations = append(violations, fmt.Sprintf(format, args...))
+	}, head, tail, n, func(e *node) *node { return e.next }, func(e *node) *node { return e.prev }, func(i int, e *node) {
+		visited = append(visited, e.id)
+	})
+	return violations, visited
+}
+
+func TestValidate(t *testing.T) {
+	if violations, visited := validate(nil, nil, 0); len(violations) != 0 || len(visited) != 0 {
+		t.Errorf("unexpected violations %v on empty list", violations)
+	}
+	head, tail := newList(3)
+	if violations, visited := validate(head, tail, 3); len(violations) != 0 || !reflect.DeepEqual(visited, []int{0, 1, 2}) {
+		t.Errorf("unexpected violations %v, visited %v", violations, visited)
+	}
+	for _, tc := range []struct {
+		name     string
+		corrupt  func(head, tail *node) (*node, *node, int)
+		expected []string
+	}{
+		{"len", func(head, tail *node) (*node, *node, int) { return head, tail, 4 }, []string{
+			"walking forward found 3 entries, expected 4",
+			"walking backward found 3 entries, expected 4",
+		}},
+		{"nil tail", func(head, tail *node) (*node, *node, int) { return head, nil, 3 }, []string{
+			"only one of head and tail is nil",
+			"walking forward ended at entry 2, which is not the tail",
+			"walking backward found 0 entries, expected 3",
+			"walking backward ended at entry -1 from the tail, which is not the head",
+		}},
+		{"cycle", func(head, tail *node) (*node, *node, int) {
+			head.prev, tail.next = tail, head
+			return head, tail, 3
+		}, []string{
+			"head has a prev entry",
+			"tail has a next entry",
+			"entry 0 does not point back to entry -1",
+			"walking forward found more than the 3 expected entries (cycle?)",
+			"entry 0 from the tail does not point forward to entry -1 from the tail",
+			"walking backward found more than the 3 expected entries (cycle?)",
+		}},
+		{"links", func(head, tail *node) (*node, *node, int) {
+			tail.prev = head
+			return head, tail, 3
+		}, []string{
+			"entry 2 does not point back to entry 1",
+			"entry 1 from the tail does not point forward to entry 0 from the tail",
+			"walking backward found 2 entries, expected 3",
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			violations, _ := validate(tc.corrupt(newList(3)))
+			if !reflect.DeepEqual(violations, tc.expected) {
+				t.Errorf("expected violations:\n%q\nfound:\n%q", tc.expected, violations)
+			}
+		})
+	}
+}
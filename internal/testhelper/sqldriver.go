@@ -0,0 +1,151 @@
+package testhelper
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// FakeTable is an in-memory database/sql/driver implementation holding a single table with the
+// given columns, to test sql.Scanner/driver.Valuer implementations without a real database.
+//
+// Any statement starting with "INSERT" appends its arguments as a new row (so it must have one
+// argument per column), any statement starting with "SELECT" returns all rows inserted so far, in
+// order. Values are stored as given by database/sql (after driver.Valuer conversion), unless
+// Convert is set, in which case it is applied to every value on insert (e.g. to mimic a driver
+// that returns strings as []byte). If RowsErr is set, it is returned by the driver after the last
+// row, instead of the end of rows (to simulate a failure in the middle of a query).
+type FakeTable struct {
+	Columns []string
+	Convert func(driver.Value) driver.Value
+	RowsErr error
+	mx      sync.Mutex
+	rows    [][]driver.Value
+}
+
+var ErrFakeTable = errors.New("fake table error")
+
+// Create a new *sql.DB backed by a FakeTable with the given columns.
+func NewFakeDB(columns ...string) (*sql.DB, *FakeTable) {
+	table := &FakeTable{Columns: columns}
+	return sql.OpenDB(table), table
+}
+
+// Implement driver.Connector interface.
+func (t *FakeTable) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{t}, nil
+}
+
+// Implement driver.Connector interface.
+func (t *FakeTable) Driver() driver.Driver {
+	return fakeDriver{t}
+}
+
+// Return a copy of the rows inserted so far.
+func (t *FakeTable) Rows() [][]driver.Value {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	ret := make([][]driver.Value, len(t.rows))
+	for i := range t.rows {
+		ret[i] = append([]driver.Value(nil), t.rows[i]...)
+	}
+	return ret
+}
+
+type fakeDriver struct {
+	t *FakeTable
+}
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{d.t}, nil
+}
+
+type fakeConn struct {
+	t *FakeTable
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	query = strings.ToUpper(strings.TrimSpace(query))
+	if !strings.HasPrefix(query, "INSERT") && !strings.HasPrefix(query, "SELECT") {
+		return nil, ErrFakeTable
+	}
+	return &fakeStmt{c.t, query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, ErrFakeTable
+}
+
+type fakeStmt struct {
+	t     *FakeTable
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	if strings.HasPrefix(s.query, "INSERT") {
+		return len(s.t.Columns)
+	}
+	return 0
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if !strings.HasPrefix(s.query, "INSERT") {
+		return nil, ErrFakeTable
+	}
+	row := make([]driver.Value, len(args))
+	for i := range args {
+		row[i] = args[i]
+		if s.t.Convert != nil {
+			row[i] = s.t.Convert(args[i])
+		}
+	}
+	s.t.mx.Lock()
+	defer s.t.mx.Unlock()
+	s.t.rows = append(s.t.rows, row)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if !strings.HasPrefix(s.query, "SELECT") {
+		return nil, ErrFakeTable
+	}
+	return &fakeRows{columns: s.t.Columns, rows: s.t.Rows(), err: s.t.RowsErr}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		if r.err != nil {
+			return r.err
+		}
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
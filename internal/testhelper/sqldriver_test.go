@@ -0,0 +1,60 @@
+package testhelper_test
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+)
+
+func TestFakeDB(t *testing.T) {
+	db, table := th.NewFakeDB("a", "b")
+	defer db.Close()
+	table.Convert = func(v driver.Value) driver.Value {
+		if s, ok := v.(string); ok {
+			return []byte(s)
+		}
+		return v
+	}
+	_, err := db.Exec("INSERT INTO t VALUES (?, ?)", "x", 1)
+	th.AssertErrNil(t, err, "unexpected error on insert")
+	_, err = db.Exec("INSERT INTO t VALUES (?, ?)", nil, 2)
+	th.AssertErrNil(t, err, "unexpected error on insert")
+	_, err = db.Exec("INSERT INTO t VALUES (?)", "x")
+	th.AssertErrNotNil(t, err, "expected error with wrong number of arguments")
+	_, err = db.Exec("SELECT a, b FROM t")
+	th.AssertErrNotNil(t, err, "expected error with exec of select")
+	_, err = db.Query("INSERT INTO t VALUES (?, ?)", "x", 1)
+	th.AssertErrNotNil(t, err, "expected error with query of insert")
+	_, err = db.Exec("DELETE FROM t")
+	th.AssertErrIs(t, err, th.ErrFakeTable, "expected error with unsupported statement")
+	_, err = db.Begin()
+	th.AssertErrIs(t, err, th.ErrFakeTable, "expected error with transaction")
+	if _, err := table.Driver().Open(""); err != nil {
+		t.Errorf("unexpected error on Open: %v", err)
+	}
+	rows, err := db.Query("SELECT a, b FROM t")
+	th.AssertErrNil(t, err, "unexpected error on select")
+	defer rows.Close()
+	var a []byte
+	var b int
+	var found []string
+	for rows.Next() {
+		th.AssertErrNil(t, rows.Scan(&a, &b), "unexpected error on scan")
+		found = append(found, string(a))
+	}
+	th.AssertErrNil(t, rows.Err(), "unexpected error on rows")
+	if len(found) != 2 || found[0] != "x" || found[1] != "" || b != 2 {
+		t.Errorf("unexpected rows: %q (b=%d)", found, b)
+	}
+	if len(table.Rows()) != 2 {
+		t.Errorf("expected 2 rows, found %d", len(table.Rows()))
+	}
+	table.RowsErr = th.ErrFakeTable
+	rows2, err := db.Query("SELECT a, b FROM t")
+	th.AssertErrNil(t, err, "unexpected error on select")
+	defer rows2.Close()
+	for rows2.Next() {
+	}
+	th.AssertErrIs(t, rows2.Err(), th.ErrFakeTable, "expected error on rows")
+}
@@ -0,0 +1,255 @@
+package omap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// Version of the binary format produced by MarshalBinary, it is always the first byte of the
+// encoded data.
+const BinaryFormatVersion byte = 1
+
+// Codec is used by MarshalBinary and UnmarshalBinary to encode each key and value of a map.
+// Unmarshal always receives a pointer to the destination key or value.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// BuiltinCodec encodes booleans, strings, []byte and all numeric types (but not named types
+// derived from them) natively, falling back to GobCodec for any other type. It is the codec used
+// by the MarshalBinary/UnmarshalBinary and GobEncode/GobDecode methods of the maps
+// implementations, call the MarshalBinary and UnmarshalBinary functions to use any other codec.
+//
+// As the type of the values is not encoded, interface types (e.g. OMap[string, any]) are not
+// supported, use JSONCodec for them.
+type BuiltinCodec struct{}
+
+// GobCodec encodes each key/value using encoding/gob. As BuiltinCodec, it doesn't support
+// interface types.
+type GobCodec struct{}
+
+// JSONCodec encodes each key/value using encoding/json.
+type JSONCodec struct{}
+
+func (BuiltinCodec) Marshal(v any) ([]byte, error) {
+	switch x := v.(type) {
+	case string:
+		return []byte(x), nil
+	case []byte:
+		return x, nil
+	case bool:
+		if x {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case int:
+		return appendVarint(nil, int64(x)), nil
+	case int8:
+		return appendVarint(nil, int64(x)), nil
+	case int16:
+		return appendVarint(nil, int64(x)), nil
+	case int32:
+		return appendVarint(nil, int64(x)), nil
+	case int64:
+		return appendVarint(nil, x), nil
+	case uint:
+		return appendUvarint(nil, uint64(x)), nil
+	case uint8:
+		return appendUvarint(nil, uint64(x)), nil
+	case uint16:
+		return appendUvarint(nil, uint64(x)), nil
+	case uint32:
+		return appendUvarint(nil, uint64(x)), nil
+	case uint64:
+		return appendUvarint(nil, x), nil
+	case float32:
+		buf := make([]byte, 4)
+		binary.LittleEndian.PutUint32(buf, math.Float32bits(x))
+		return buf, nil
+	case float64:
+		buf := make([]byte, 8)
+		binary.LittleEndian.PutUint64(buf, math.Float64bits(x))
+		return buf, nil
+	default:
+		return GobCodec{}.Marshal(v)
+	}
+}
+
+func (BuiltinCodec) Unmarshal(data []byte, v any) error {
+	switch x := v.(type) {
+	case *string:
+		*x = string(data)
+	case *[]byte:
+		*x = append([]byte(nil), data...)
+	case *bool:
+		if len(data) != 1 {
+			return fmt.Errorf("%w: invalid bool of len %d", ErrInvalidBinary, len(data))
+		}
+		*x = data[0] != 0
+	case *int, *int8, *int16, *int32, *int64:
+		i, n := binary.Varint(data)
+		if n <= 0 || n != len(data) {
+			return fmt.Errorf("%w: invalid varint", ErrInvalidBinary)
+		}
+		dst := reflect.ValueOf(v).Elem()
+		if dst.OverflowInt(i) {
+			return fmt.Errorf("%w: %d overflows %v", ErrInvalidBinary, i, dst.Type())
+		}
+		dst.SetInt(i)
+	case *uint, *uint8, *uint16, *uint32, *uint64:
+		u, n := binary.Uvarint(data)
+		if n <= 0 || n != len(data) {
+			return fmt.Errorf("%w: invalid uvarint", ErrInvalidBinary)
+		}
+		dst := reflect.ValueOf(v).Elem()
+		if dst.OverflowUint(u) {
+			return fmt.Errorf("%w: %d overflows %v", ErrInvalidBinary, u, dst.Type())
+		}
+		dst.SetUint(u)
+	case *float32:
+		if len(data) != 4 {
+			return fmt.Errorf("%w: invalid float32 of len %d", ErrInvalidBinary, len(data))
+		}
+		*x = math.Float32frombits(binary.LittleEndian.Uint32(data))
+	case *float64:
+		if len(data) != 8 {
+			return fmt.Errorf("%w: invalid float64 of len %d", ErrInvalidBinary, len(data))
+		}
+		*x = math.Float64frombits(binary.LittleEndian.Uint64(data))
+	default:
+		return GobCodec{}.Unmarshal(data, v)
+	}
+	return nil
+}
+
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	var b bytes.Buffer
+	if err := gob.NewEncoder(&b).Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// binary.AppendUvarint and binary.AppendVarint are only available on Go >= 1.19
+func appendUvarint(buf []byte, x uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], x)
+	return append(buf, tmp[:n]...)
+}
+
+func appendVarint(buf []byte, x int64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(tmp[:], x)
+	return append(buf, tmp[:n]...)
+}
+
+// Return an error if K or V is an interface type and codec is one of the codecs that encode only
+// the dynamic value (BuiltinCodec or GobCodec), so it couldn't be decoded back.
+func checkCodecTypes[K comparable, V any](codec Codec) error {
+	switch codec.(type) {
+	case BuiltinCodec, *BuiltinCodec, GobCodec, *GobCodec:
+	default:
+		return nil
+	}
+	for _, t := range []reflect.Type{reflect.TypeOf((*K)(nil)).Elem(), reflect.TypeOf((*V)(nil)).Elem()} {
+		if t.Kind() == reflect.Interface {
+			return fmt.Errorf("%w: interface type %v is not supported by %T, use JSONCodec or a custom Codec", ErrInvalidBinary, t, codec)
+		}
+	}
+	return nil
+}
+
+// Iterate over the given iterator it, from the given position, and encode the key/values into a
+// compact binary format, using codec to encode each key and value. Fails with ErrInvalidBinary if
+// codec can't decode the types of the map back (see BuiltinCodec).
+//
+// The format is the version byte (BinaryFormatVersion) followed by each entry, in order, as the
+// uvarint length of the key, the encoded key, the uvarint length of the value and the encoded
+// value.
+// This is a handy function to construct a encoding.BinaryMarshaler implementation.
+// Note: the iterator will be at EOF after this function returns with success.
+func MarshalBinary[K comparable, V any](it OMapIterator[K, V], codec Codec) ([]byte, error) {
+	if err := checkCodecTypes[K, V](codec); err != nil {
+		return nil, err
+	}
+	buf := []byte{BinaryFormatVersion}
+	for it.Next() {
+		key, err := codec.Marshal(it.Key())
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal key: %w", err)
+		}
+		val, err := codec.Marshal(it.Value())
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal value: %w", err)
+		}
+		buf = appendUvarint(buf, uint64(len(key)))
+		buf = append(buf, key...)
+		buf = appendUvarint(buf, uint64(len(val)))
+		buf = append(buf, val...)
+	}
+	return buf, nil
+}
+
+// Process given binary data at b, as produced by MarshalBinary, and for each key/value found,
+// call given putFunc function with same definition of OMap.Put to add the given key/value into a
+// map.
+//
+// This is a handy function to construct a encoding.BinaryUnmarshaler implementation.
+func UnmarshalBinary[K comparable, V any](putFunc func(K, V), b []byte, codec Codec) error {
+	if err := checkCodecTypes[K, V](codec); err != nil {
+		return err
+	}
+	if len(b) == 0 {
+		return fmt.Errorf("%w: empty input", ErrInvalidBinary)
+	}
+	if b[0] != BinaryFormatVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidBinary, b[0])
+	}
+	b = b[1:]
+	next := func() ([]byte, error) {
+		l, n := binary.Uvarint(b)
+		if n <= 0 || l > uint64(len(b)-n) {
+			return nil, fmt.Errorf("%w: truncated data", ErrInvalidBinary)
+		}
+		ret := b[n : n+int(l)]
+		b = b[n+int(l):]
+		return ret, nil
+	}
+	for len(b) > 0 {
+		var key K
+		var value V
+		data, err := next()
+		if err != nil {
+			return err
+		}
+		if err := codec.Unmarshal(data, &key); err != nil {
+			return fmt.Errorf("failed to unmarshal key: %w", err)
+		}
+		if data, err = next(); err != nil {
+			return err
+		}
+		if err := codec.Unmarshal(data, &value); err != nil {
+			return fmt.Errorf("failed to unmarshal value: %w", err)
+		}
+		putFunc(key, value)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package omap_test
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"fmt"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+func TestMarshalBinary(t *testing.T) {
+	for _, impl := range implementations {
+		if !impl.isOrdered || impl.name == implSimple || impl.name == implFolded {
+			continue
+		}
+		t.Run(impl.name, func(t *testing.T) {
+			m := impl.initializerStrInt()
+			m.Put("foo", 1)
+			m.Put("bar", -2)
+			m.Put("baz", 300000)
+			bm, ok := m.(encoding.BinaryMarshaler)
+			if !ok {
+				t.Fatalf("expected %T to implement encoding.BinaryMarshaler", m)
+			}
+			data, err := bm.MarshalBinary()
+			th.AssertErrNil(t, err, "unexpected error on MarshalBinary")
+			m2 := impl.initializerStrInt()
+			m2.Put("to be removed", 0)
+			th.AssertErrNil(t, m2.(encoding.BinaryUnmarshaler).UnmarshalBinary(data), "unexpected error on UnmarshalBinary")
+			th.ValidateIterator(t, m2.Iterator(), impl.isOrdered, th.JsonToKV[string, int](`[["foo",1],["bar",-2],["baz",300000]]`))
+		})
+	}
+}
+
+func TestGob(t *testing.T) {
+	type payload struct {
+		Name   string
+		Linked *omap.OMapLinked[string, float64]
+		Hash   *omap.OMapLinkedHash[int, string]
+		Sync   *omap.OMapSync[string, []byte]
+	}
+	linked := omap.NewOMapLinked[string, float64]()
+	linked.Put("pi", 3.14)
+	linked.Put("e", 2.71)
+	linked.Put("zero", 0)
+	hash := omap.NewOMapLinkedHash[int, string]()
+	hash.Put(3, "three")
+	hash.Put(1, "one")
+	hash.Put(2, "two")
+	sync := omap.NewOMapSync[string, []byte]()
+	sync.Put("b", []byte("bee"))
+	sync.Put("a", nil)
+	in := payload{
+		Name:   "test",
+		Linked: linked.(*omap.OMapLinked[string, float64]),
+		Hash:   hash.(*omap.OMapLinkedHash[int, string]),
+		Sync:   sync.(*omap.OMapSync[string, []byte]),
+	}
+	var buf bytes.Buffer
+	th.AssertErrNil(t, gob.NewEncoder(&buf).Encode(in), "unexpected error on gob encode")
+	var out payload
+	th.AssertErrNil(t, gob.NewDecoder(&buf).Decode(&out), "unexpected error on gob decode")
+	if out.Name != "test" {
+		t.Errorf("expected name \"test\", found %q", out.Name)
+	}
+	th.ValidateIterator(t, out.Linked.Iterator(), true, th.JsonToKV[string, float64](`[["pi",3.14],["e",2.71],["zero",0]]`))
+	th.ValidateIterator(t, out.Hash.Iterator(), true, th.JsonToKV[int, string](`[[3,"three"],[1,"one"],[2,"two"]]`))
+	if keys := omap.IteratorKeysToSlice(out.Sync.Iterator()); len(keys) != 2 || keys[0] != "b" || keys[1] != "a" {
+		t.Errorf("expected keys [b a], found %v", keys)
+	}
+	if v, _ := out.Sync.Get("b"); string(v) != "bee" {
+		t.Errorf("expected \"bee\", found %q", v)
+	}
+}
+
+func TestBinaryCodecs(t *testing.T) {
+	type complexKey struct {
+		Id   int
+		Name string
+	}
+	codecs := map[string]omap.Codec{"builtin": omap.BuiltinCodec{}, "gob": omap.GobCodec{}, "json": omap.JSONCodec{}}
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			m := omap.New[complexKey, bool]()
+			m.Put(complexKey{2, "b"}, true)
+			m.Put(complexKey{1, "a"}, false)
+			data, err := omap.MarshalBinary(m.Iterator(), codec)
+			th.AssertErrNil(t, err, "unexpected error on MarshalBinary")
+			m2 := omap.New[complexKey, bool]()
+			th.AssertErrNil(t, omap.UnmarshalBinary(m2.Put, data, codec), "unexpected error on UnmarshalBinary")
+			if keys := omap.IteratorKeysToSlice(m2.Iterator()); len(keys) != 2 || keys[0] != (complexKey{2, "b"}) || keys[1] != (complexKey{1, "a"}) {
+				t.Errorf("unexpected keys %v", keys)
+			}
+		})
+	}
+}
+
+func roundTripBuiltin[T comparable](t *testing.T, values ...T) {
+	t.Helper()
+	for _, v := range values {
+		data, err := omap.BuiltinCodec{}.Marshal(v)
+		th.AssertErrNil(t, err, "unexpected error on Marshal")
+		var res T
+		th.AssertErrNil(t, omap.BuiltinCodec{}.Unmarshal(data, &res), "unexpected error on Unmarshal")
+		if res != v {
+			t.Errorf("expected %v, found %v", v, res)
+		}
+	}
+}
+
+func TestBuiltinCodec(t *testing.T) {
+	roundTripBuiltin(t, "", "foo")
+	roundTripBuiltin(t, true, false)
+	roundTripBuiltin(t, 0, -1, 1<<40)
+	roundTripBuiltin[int8](t, -128, 127)
+	roundTripBuiltin[int16](t, -300, 300)
+	roundTripBuiltin[int32](t, -70000, 70000)
+	roundTripBuiltin[int64](t, -1<<62, 1<<62)
+	roundTripBuiltin[uint](t, 0, 1<<40)
+	roundTripBuiltin[uint8](t, 0, 255)
+	roundTripBuiltin[uint16](t, 0, 65535)
+	roundTripBuiltin[uint32](t, 0, 1<<31)
+	roundTripBuiltin[uint64](t, 0, 1<<63)
+	roundTripBuiltin[float32](t, 0, -1.5, 3.25)
+	roundTripBuiltin[float64](t, 0, -1.5, 1e300)
+	var b []byte
+	th.AssertErrNil(t, omap.BuiltinCodec{}.Unmarshal([]byte("bytes"), &b), "unexpected error on Unmarshal")
+	if string(b) != "bytes" {
+		t.Errorf("expected \"bytes\", found %q", b)
+	}
+	// errors
+	var i int
+	var u uint
+	var bo bool
+	var f32 float32
+	var f64 float64
+	var s struct{ A int }
+	codec := omap.BuiltinCodec{}
+	th.AssertErrIs(t, codec.Unmarshal([]byte{}, &i), omap.ErrInvalidBinary, "expected error with invalid varint")
+	th.AssertErrIs(t, codec.Unmarshal([]byte{0x80}, &u), omap.ErrInvalidBinary, "expected error with invalid uvarint")
+	th.AssertErrIs(t, codec.Unmarshal([]byte{1, 1}, &bo), omap.ErrInvalidBinary, "expected error with invalid bool")
+	th.AssertErrIs(t, codec.Unmarshal([]byte{1}, &f32), omap.ErrInvalidBinary, "expected error with invalid float32")
+	th.AssertErrIs(t, codec.Unmarshal([]byte{1}, &f64), omap.ErrInvalidBinary, "expected error with invalid float64")
+	th.AssertErrNotNil(t, codec.Unmarshal([]byte{1}, &s), "expected error with invalid gob data")
+	// overflow
+	var i8 int8
+	var u16 uint16
+	data, _ := codec.Marshal(256)
+	th.AssertErrIs(t, codec.Unmarshal(data, &i8), omap.ErrInvalidBinary, "expected error with int8 overflow")
+	data, _ = codec.Marshal(uint(1 << 16))
+	th.AssertErrIs(t, codec.Unmarshal(data, &u16), omap.ErrInvalidBinary, "expected error with uint16 overflow")
+}
+
+func TestBinaryInterfaceTypes(t *testing.T) {
+	m := omap.NewOMapLinked[string, any]()
+	m.Put("a", 1)
+	m.Put("b", "foo")
+	// the type of the values is not encoded by builtin and gob codecs
+	_, err := m.(encoding.BinaryMarshaler).MarshalBinary()
+	th.AssertErrIs(t, err, omap.ErrInvalidBinary, "expected error marshaling interface values")
+	for _, codec := range []omap.Codec{omap.BuiltinCodec{}, &omap.GobCodec{}} {
+		_, err := omap.MarshalBinary(m.Iterator(), codec)
+		th.AssertErrIs(t, err, omap.ErrInvalidBinary, "expected error marshaling interface values")
+		th.AssertErrIs(t, omap.UnmarshalBinary(omap.New[string, any]().Put, []byte{omap.BinaryFormatVersion}, codec), omap.ErrInvalidBinary, "expected error unmarshaling interface values")
+	}
+	// but they are by JSON
+	data, err := omap.MarshalBinary(m.Iterator(), omap.JSONCodec{})
+	th.AssertErrNil(t, err, "unexpected error on MarshalBinary")
+	m2 := omap.NewOMapLinked[string, any]()
+	th.AssertErrNil(t, omap.UnmarshalBinary(m2.Put, data, omap.JSONCodec{}), "unexpected error on UnmarshalBinary")
+	if s := fmt.Sprint(m2); s != "omap.OMapLinked[a:1 b:foo]" {
+		t.Errorf("unexpected map after round-trip %s", s)
+	}
+}
+
+func TestUnmarshalBinaryErrors(t *testing.T) {
+	m := omap.New[string, int]()
+	invalid := [][]byte{
+		{},                                 // empty
+		{omap.BinaryFormatVersion + 1},     // unsupported version
+		{omap.BinaryFormatVersion, 5, 'a'}, // truncated key
+		{omap.BinaryFormatVersion, 1, 'a'}, // missing value
+		{omap.BinaryFormatVersion, 1, 'a', 1, 0x80}, // invalid value
+	}
+	for _, data := range invalid {
+		th.AssertErrIs(t, omap.UnmarshalBinary(m.Put, data, omap.BuiltinCodec{}), omap.ErrInvalidBinary, "expected ErrInvalidBinary")
+	}
+	mInt := omap.New[int, int]()
+	th.AssertErrIs(t, omap.UnmarshalBinary(mInt.Put, []byte{omap.BinaryFormatVersion, 1, 0x80, 1, 0}, omap.BuiltinCodec{}), omap.ErrInvalidBinary, "expected ErrInvalidBinary with invalid key")
+	// marshal failures
+	mKeyInvalid := omap.New[failonly, string]()
+	mKeyInvalid.Put(failonly{"hello"}, "hello")
+	_, err := omap.MarshalBinary(mKeyInvalid.Iterator(), omap.JSONCodec{})
+	th.AssertErrNotNil(t, err, "expected error with invalid key")
+	mValInvalid := omap.New[string, failonly]()
+	mValInvalid.Put("world", failonly{"world"})
+	_, err = omap.MarshalBinary(mValInvalid.Iterator(), omap.JSONCodec{})
+	th.AssertErrNotNil(t, err, "expected error with invalid value")
+	_, err = omap.MarshalBinary(mValInvalid.Iterator().MoveFront(), omap.GobCodec{})
+	th.AssertErrNotNil(t, err, "expected gob error with unexported fields")
+}
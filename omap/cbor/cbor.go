@@ -0,0 +1,501 @@
+// cbor package implements a CBOR (RFC 8949) encoder/decoder for ordered maps, without external
+// dependencies.
+//
+// Ordered maps (omap and omultimap types) are encoded in iteration order, and decoding keeps the
+// order of the keys as found in the input, so the output can be used when key order is
+// significant (e.g. signatures). The encoding is deterministic: integers, lengths and headers
+// always use the shortest form, floats use the shortest of half, single or double precision that
+// represents the value exactly (NaN is always encoded as 0xf97e00), definite lengths are always
+// used, and builtin Go maps are encoded with keys sorted by their encoded form (as the "core
+// deterministic encoding" of RFC 8949). Ordered maps are the exception, as they keep their order.
+//
+// Supported Go types are nil, bool, integers, floats, string, []byte, slices/arrays, builtin maps,
+// pointers and ordered maps. When decoding into an interface (e.g. any), integers are decoded as
+// int64 (or uint64 if too large), floats as float64, arrays as []any and maps as
+// omap.OMap[string, any]. Tags are ignored on decoding (only the tagged item is decoded), and
+// indefinite-length items are accepted.
+//
+// Nested maps can be decoded into builtin Go maps, or into omap.OMap[string, any] (or any) to keep
+// their order, but not into other ordered map types (e.g. omap.OMap[string, int] or
+// *omap.OMapLinked[string, int]), which fail with an error. Decode them as omap.OMap[string, any]
+// and convert the values as needed.
+package cbor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/matheusoliveira/go-ordered-map/internal/codecutil"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+var (
+	// Returned, wrapped, by any decoding failure due to malformed or unsupported input.
+	ErrInvalidData = errors.New("cbor: invalid data")
+)
+
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+
+	// additional information meaning an indefinite length, or the "break" stop code at major 7
+	indefinite = 31
+	breakCode  = 0xff
+)
+
+// Encode the given value v into CBOR.
+func Marshal(v any) ([]byte, error) {
+	w := &writer{}
+	if err := codecutil.Encode(w, v); err != nil {
+		return nil, fmt.Errorf("cbor: %w", err)
+	}
+	return w.buf, nil
+}
+
+// Iterate over the given iterator it, from the given position, and encode the key/values as a
+// CBOR map, in the same order.
+// Note: the iterator will be at EOF after this function returns with success.
+func MarshalIterator[K comparable, V any](it omap.OMapIterator[K, V]) ([]byte, error) {
+	w := &writer{}
+	if err := codecutil.EncodeIterator(w, it); err != nil {
+		return nil, fmt.Errorf("cbor: %w", err)
+	}
+	return w.buf, nil
+}
+
+// Process given CBOR map at b and for each key/value found, call given putFunc function with same
+// definition of OMap.Put to add the given key/value into a map (use a closure to put into an
+// omultimap.OMultiMap).
+func Unmarshal[K comparable, V any](putFunc func(K, V), b []byte) error {
+	d := &decoder{b: b}
+	major, info, err := d.head()
+	if err != nil {
+		return err
+	}
+	if major != majorMap {
+		return fmt.Errorf("%w: expected a map, found major type %d", ErrInvalidData, major)
+	}
+	err = d.mapEntries(info, func(k, v any) error {
+		key, err := codecutil.Convert[K](k)
+		if err != nil {
+			return fmt.Errorf("cbor: could not decode key: %w", err)
+		}
+		value, err := codecutil.Convert[V](v)
+		if err != nil {
+			return fmt.Errorf("cbor: could not decode value of key %v: %w", key, err)
+		}
+		putFunc(key, value)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(d.b) > 0 {
+		return fmt.Errorf("%w: %d bytes of trailing data", ErrInvalidData, len(d.b))
+	}
+	return nil
+}
+
+// Decode a single CBOR item at b into the generic representation (see package docs).
+func Decode(b []byte) (any, error) {
+	d := &decoder{b: b}
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if len(d.b) > 0 {
+		return nil, fmt.Errorf("%w: %d bytes of trailing data", ErrInvalidData, len(d.b))
+	}
+	return v, nil
+}
+
+//// writer ////
+
+type writer struct {
+	buf []byte
+}
+
+func (w *writer) head(major byte, n uint64) {
+	major <<= 5
+	switch {
+	case n < 24:
+		w.buf = append(w.buf, major|byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, major|25, 0, 0)
+		binary.BigEndian.PutUint16(w.buf[len(w.buf)-2:], uint16(n))
+	case n <= math.MaxUint32:
+		w.buf = append(w.buf, major|26, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(w.buf[len(w.buf)-4:], uint32(n))
+	default:
+		w.buf = append(w.buf, major|27, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(w.buf[len(w.buf)-8:], n)
+	}
+}
+
+func (w *writer) Nil() {
+	w.buf = append(w.buf, 0xf6)
+}
+
+func (w *writer) Bool(b bool) {
+	if b {
+		w.buf = append(w.buf, 0xf5)
+	} else {
+		w.buf = append(w.buf, 0xf4)
+	}
+}
+
+func (w *writer) Int(i int64) {
+	if i >= 0 {
+		w.head(majorUint, uint64(i))
+	} else {
+		// -1 - i, without overflow
+		w.head(majorNegInt, uint64(^i))
+	}
+}
+
+func (w *writer) Uint(u uint64) {
+	w.head(majorUint, u)
+}
+
+func (w *writer) Float32(f float32) {
+	w.Float64(float64(f))
+}
+
+// Write f with the shortest precision that keeps its value.
+func (w *writer) Float64(f float64) {
+	if h, ok := float64ToHalf(f); ok {
+		w.buf = append(w.buf, 0xf9, 0, 0)
+		binary.BigEndian.PutUint16(w.buf[len(w.buf)-2:], h)
+	} else if f32 := float32(f); float64(f32) == f {
+		w.buf = append(w.buf, 0xfa, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(w.buf[len(w.buf)-4:], math.Float32bits(f32))
+	} else {
+		w.buf = append(w.buf, 0xfb, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(w.buf[len(w.buf)-8:], math.Float64bits(f))
+	}
+}
+
+func (w *writer) String(s string) {
+	w.head(majorText, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *writer) Bytes(b []byte) {
+	w.head(majorBytes, uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *writer) ArrayHeader(n int) {
+	w.head(majorArray, uint64(n))
+}
+
+func (w *writer) MapHeader(n int) {
+	w.head(majorMap, uint64(n))
+}
+
+func (w *writer) Raw(b []byte) {
+	w.buf = append(w.buf, b...)
+}
+
+func (w *writer) New() codecutil.Writer {
+	return &writer{}
+}
+
+func (w *writer) Encoded() []byte {
+	return w.buf
+}
+
+//// decoder ////
+
+type decoder struct {
+	b []byte
+	// nesting level of the value being decoded, see codecutil.MaxDepth
+	depth int
+}
+
+func (d *decoder) next(n uint64) ([]byte, error) {
+	if uint64(len(d.b)) < n {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidData)
+	}
+	ret := d.b[:n]
+	d.b = d.b[n:]
+	return ret, nil
+}
+
+// Read the initial byte of an item, returning the major type and the additional information.
+func (d *decoder) head() (byte, byte, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return 0, 0, err
+	}
+	return b[0] >> 5, b[0] & 0x1f, nil
+}
+
+// Read the argument of an item, given its additional information.
+func (d *decoder) argument(info byte) (uint64, error) {
+	if info < 24 {
+		return uint64(info), nil
+	}
+	var size uint64
+	switch info {
+	case 24:
+		size = 1
+	case 25:
+		size = 2
+	case 26:
+		size = 4
+	case 27:
+		size = 8
+	default:
+		return 0, fmt.Errorf("%w: invalid additional information %d", ErrInvalidData, info)
+	}
+	b, err := d.next(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+// Return true, consuming it, if next byte is the break stop code.
+func (d *decoder) isBreak() bool {
+	if len(d.b) > 0 && d.b[0] == breakCode {
+		d.b = d.b[1:]
+		return true
+	}
+	return false
+}
+
+// Call f for each entry of a map of given additional information, in order.
+func (d *decoder) mapEntries(info byte, f func(k, v any) error) error {
+	n := uint64(math.MaxUint64)
+	if info != indefinite {
+		var err error
+		if n, err = d.argument(info); err != nil {
+			return err
+		}
+	}
+	for i := uint64(0); i < n; i++ {
+		if info == indefinite && d.isBreak() {
+			return nil
+		}
+		k, err := d.value()
+		if err != nil {
+			return err
+		}
+		v, err := d.value()
+		if err != nil {
+			return err
+		}
+		if err := f(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *decoder) value() (any, error) {
+	if d.depth >= codecutil.MaxDepth {
+		return nil, fmt.Errorf("%w: exceeded max depth of %d", ErrInvalidData, codecutil.MaxDepth)
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+	major, info, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case majorUint:
+		u, err := d.argument(info)
+		if err != nil {
+			return nil, err
+		}
+		if u <= math.MaxInt64 {
+			return int64(u), nil
+		}
+		return u, nil
+	case majorNegInt:
+		u, err := d.argument(info)
+		if err != nil {
+			return nil, err
+		}
+		if u > math.MaxInt64 {
+			return nil, fmt.Errorf("%w: negative integer overflows int64", ErrInvalidData)
+		}
+		return -1 - int64(u), nil
+	case majorBytes, majorText:
+		b, err := d.str(major, info)
+		if err != nil {
+			return nil, err
+		}
+		if major == majorText {
+			return string(b), nil
+		}
+		return b, nil
+	case majorArray:
+		ret := make([]any, 0)
+		n := uint64(math.MaxUint64)
+		if info != indefinite {
+			if n, err = d.argument(info); err != nil {
+				return nil, err
+			}
+		}
+		for i := uint64(0); i < n; i++ {
+			if info == indefinite && d.isBreak() {
+				break
+			}
+			v, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, v)
+		}
+		return ret, nil
+	case majorMap:
+		m := codecutil.NewNestedMap()
+		err := d.mapEntries(info, func(k, v any) error {
+			codecutil.PutNested(m, k, v)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
+	case majorTag:
+		if _, err := d.argument(info); err != nil {
+			return nil, err
+		}
+		return d.value()
+	default: // majorSimple
+		return d.simple(info)
+	}
+}
+
+// Read a byte or text string, including indefinite-length ones (concatenating its chunks).
+func (d *decoder) str(major byte, info byte) ([]byte, error) {
+	if info != indefinite {
+		n, err := d.argument(info)
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	}
+	ret := make([]byte, 0)
+	for !d.isBreak() {
+		chunkMajor, chunkInfo, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if chunkMajor != major || chunkInfo == indefinite {
+			return nil, fmt.Errorf("%w: invalid chunk of indefinite-length string", ErrInvalidData)
+		}
+		chunk, err := d.str(chunkMajor, chunkInfo)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, chunk...)
+	}
+	return ret, nil
+}
+
+func (d *decoder) simple(info byte) (any, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23: // null and undefined
+		return nil, nil
+	case 25:
+		u, err := d.argument(info)
+		return halfToFloat64(uint16(u)), err
+	case 26:
+		u, err := d.argument(info)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 27:
+		u, err := d.argument(info)
+		return math.Float64frombits(u), err
+	}
+	return nil, fmt.Errorf("%w: unsupported simple value %d", ErrInvalidData, info)
+}
+
+// Convert f to an IEEE 754 half-precision float, if it can be done without losing precision.
+func float64ToHalf(f float64) (uint16, bool) {
+	if math.IsNaN(f) {
+		return 0x7e00, true
+	}
+	var sign uint16
+	if math.Signbit(f) {
+		sign = 0x8000
+		f = -f
+	}
+	if math.IsInf(f, 1) {
+		return sign | 0x7c00, true
+	}
+	if f == 0 {
+		return sign, true
+	}
+	frac, exp := math.Frexp(f) // f = frac * 2^exp, with frac in [0.5, 1)
+	if exp >= -13 && exp <= 16 {
+		// normal, f = (1 + mant/1024) * 2^(exp-1)
+		mant := (frac*2 - 1) * 1024
+		if mant != math.Trunc(mant) {
+			return 0, false
+		}
+		return sign | uint16(exp+14)<<10 | uint16(mant), true
+	}
+	if exp < -13 {
+		// subnormal, f = mant * 2^-24
+		mant := math.Ldexp(f, 24)
+		if mant != math.Trunc(mant) {
+			return 0, false
+		}
+		return sign | uint16(mant), true
+	}
+	return 0, false
+}
+
+// Convert an IEEE 754 half-precision float to float64.
+func halfToFloat64(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,271 @@
+package cbor_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/matheusoliveira/go-ordered-map/internal/codecutil"
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omap/cbor"
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Vectors from RFC 8949, Appendix A
+func TestMarshalValues(t *testing.T) {
+	cases := []struct {
+		value any
+		exp   string
+	}{
+		{nil, "f6"},
+		{false, "f4"},
+		{true, "f5"},
+		{0, "00"},
+		{23, "17"},
+		{24, "1818"},
+		{1000, "1903e8"},
+		{1000000, "1a000f4240"},
+		{uint64(1000000000000), "1b000000e8d4a51000"},
+		{uint64(math.MaxUint64), "1bffffffffffffffff"},
+		{-1, "20"},
+		{-100, "3863"},
+		{-1000, "3903e7"},
+		{int64(math.MinInt64), "3b7fffffffffffffff"},
+		{0.0, "f90000"},
+		{math.Copysign(0, -1), "f98000"},
+		{1.0, "f93c00"},
+		{1.1, "fb3ff199999999999a"},
+		{1.5, "f93e00"},
+		{65504.0, "f97bff"},
+		{100000.0, "fa47c35000"},
+		{float32(100000.0), "fa47c35000"},
+		{3.4028234663852886e+38, "fa7f7fffff"},
+		{1.0e+300, "fb7e37e43c8800759c"},
+		{5.960464477539063e-8, "f90001"},
+		{0.00006103515625, "f90400"},
+		{-4.0, "f9c400"},
+		{float32(-4.0), "f9c400"},
+		{-4.1, "fbc010666666666666"},
+		{math.Inf(1), "f97c00"},
+		{math.NaN(), "f97e00"},
+		{float32(math.NaN()), "f97e00"},
+		{math.Inf(-1), "f9fc00"},
+		{65520.0, "fa477ff000"},
+		{3.0e-8, "fb3e601b2b29a4692b"},
+		{float32(1.0 / 3.0), "fa3eaaaaab"},
+		{"", "60"},
+		{"IETF", "6449455446"},
+		{"ü", "62c3bc"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{[]int{}, "80"},
+		{[]any{1, []int{2, 3}, []int{4, 5}}, "8301820203820405"},
+		{map[string]int{"b": 2, "a": 1}, "a2616101616202"},
+		{map[int]string{10: "x", -1: "y", 100: "z"}, "a30a61781864617a206179"},
+		{(*int)(nil), "f6"},
+	}
+	for _, c := range cases {
+		res, err := cbor.Marshal(c.value)
+		th.AssertErrNil(t, err, "unexpected error on Marshal")
+		if hex.EncodeToString(res) != c.exp {
+			t.Errorf("Marshal(%v): expected %s, found %x", c.value, c.exp, res)
+		}
+		if _, err := cbor.Decode(res); err != nil {
+			t.Errorf("Decode(%x) failed: %v", res, err)
+		}
+	}
+}
+
+func TestDecodeValues(t *testing.T) {
+	cases := []struct {
+		data string
+		exp  any
+	}{
+		{"f7", nil},
+		{"1bffffffffffffffff", uint64(math.MaxUint64)},
+		{"3903e7", int64(-1000)},
+		{"f93e00", 1.5},
+		{"f90001", 5.960464477539063e-8},
+		{"f97c00", math.Inf(1)},
+		{"f9fc00", math.Inf(-1)},
+		{"fa47c35000", 100000.0},
+		{"c074323031332d30332d32315432303a30343a30305a", "2013-03-21T20:04:00Z"},
+		{"5f42010243030405ff", []byte{1, 2, 3, 4, 5}},
+		{"7f657374726561646d696e67ff", "streaming"},
+		{"9f018202039f0405ffff", []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}},
+	}
+	for _, c := range cases {
+		v, err := cbor.Decode(mustHex(c.data))
+		th.AssertErrNil(t, err, "unexpected error on Decode")
+		if !reflect.DeepEqual(v, c.exp) {
+			t.Errorf("Decode(%s): expected %#v, found %#v", c.data, c.exp, v)
+		}
+	}
+	v, err := cbor.Decode(mustHex("f97e00"))
+	th.AssertErrNil(t, err, "unexpected error on Decode")
+	if f, ok := v.(float64); !ok || !math.IsNaN(f) {
+		t.Errorf("expected NaN, found %#v", v)
+	}
+	// indefinite map, nested ordered map with non-string key
+	v, err = cbor.Decode(mustHex("bf 61 7a 01 61 61 a1 02 f5 ff"))
+	th.AssertErrNil(t, err, "unexpected error on Decode")
+	m, ok := v.(omap.OMap[string, any])
+	if !ok {
+		t.Fatalf("expected omap.OMap[string, any], found %T", v)
+	}
+	if keys := omap.IteratorKeysToSlice(m.Iterator()); !reflect.DeepEqual(keys, []string{"z", "a"}) {
+		t.Errorf("expected keys [z a], found %v", keys)
+	}
+	nested, _ := m.Get("a")
+	if val, _ := nested.(omap.OMap[string, any]).Get("2"); val != true {
+		t.Errorf("expected nested key \"2\" to be true, found %v", val)
+	}
+}
+
+func TestOrderedMaps(t *testing.T) {
+	inner := omap.New[string, any]()
+	inner.Put("z", 1)
+	inner.Put("a", []any{"x", nil})
+	m := omap.New[string, any]()
+	m.Put("b", inner)
+	m.Put("a", float32(1.5))
+	res, err := cbor.MarshalIterator(m.Iterator())
+	th.AssertErrNil(t, err, "unexpected error on MarshalIterator")
+	exp := "a2 6162 a2617a016161826178f6 6161 f93e00"
+	if hex.EncodeToString(res) != strings.ReplaceAll(exp, " ", "") {
+		t.Errorf("expected %s, found %x", exp, res)
+	}
+	res2, err := cbor.Marshal(m)
+	th.AssertErrNil(t, err, "unexpected error on Marshal")
+	if string(res) != string(res2) {
+		t.Errorf("Marshal and MarshalIterator differ: %x / %x", res, res2)
+	}
+	// multimap, with repeated keys kept in order
+	mm := omultimap.New[string, int]()
+	mm.Put("x", 1)
+	mm.Put("y", 2)
+	mm.Put("x", 3)
+	res, err = cbor.Marshal(mm)
+	th.AssertErrNil(t, err, "unexpected error on Marshal")
+	if exp := "a3617801617902617803"; hex.EncodeToString(res) != exp {
+		t.Errorf("expected %s, found %x", exp, res)
+	}
+	mm2 := omultimap.New[string, int]()
+	th.AssertErrNil(t, cbor.Unmarshal(func(k string, v int) { mm2.Put(k, v) }, res), "unexpected error on Unmarshal")
+	th.ValidateIterator(t, mm2.Iterator(), true, []th.KeyValue[string, int]{{Key: "x", Value: 1}, {Key: "y", Value: 2}, {Key: "x", Value: 3}})
+	// indefinite-length map into a typed map
+	m2 := omap.New[string, []uint8]()
+	th.AssertErrNil(t, cbor.Unmarshal(m2.Put, mustHex("bf 6162 420102 6161 5f4103ff ff")), "unexpected error on Unmarshal")
+	exp2 := map[string][]byte{"b": {1, 2}, "a": {3}}
+	if keys := omap.IteratorKeysToSlice(m2.Iterator()); !reflect.DeepEqual(keys, []string{"b", "a"}) {
+		t.Errorf("expected keys [b a], found %v", keys)
+	}
+	for k, v := range exp2 {
+		if found, _ := m2.Get(k); !reflect.DeepEqual(found, v) {
+			t.Errorf("expected %v at key %q, found %v", v, k, found)
+		}
+	}
+}
+
+func TestNestedMaps(t *testing.T) {
+	data := mustHex("a1 616e a2 617a 01 6161 02")
+	// ordered, as omap.OMap[string, any]
+	m := omap.New[string, any]()
+	th.AssertErrNil(t, cbor.Unmarshal(m.Put, data), "unexpected error on Unmarshal")
+	nested, _ := m.Get("n")
+	if s := fmt.Sprint(nested); s != "omap.OMapLinked[z:1 a:2]" {
+		t.Errorf("unexpected nested map %s", s)
+	}
+	mAny := omap.New[string, omap.OMap[string, any]]()
+	th.AssertErrNil(t, cbor.Unmarshal(mAny.Put, data), "unexpected error on Unmarshal")
+	// unordered, as builtin map
+	mBuiltin := omap.New[string, map[string]int]()
+	th.AssertErrNil(t, cbor.Unmarshal(mBuiltin.Put, data), "unexpected error on Unmarshal")
+	if nested, _ := mBuiltin.Get("n"); !reflect.DeepEqual(nested, map[string]int{"z": 1, "a": 2}) {
+		t.Errorf("unexpected nested map %v", nested)
+	}
+	// other ordered map types are not supported
+	mTyped := omap.New[string, omap.OMap[string, int]]()
+	th.AssertErrNotNil(t, cbor.Unmarshal(mTyped.Put, data), "expected error with nested omap.OMap[string, int]")
+	mLinked := omap.New[string, *omap.OMapLinked[string, int]]()
+	th.AssertErrNotNil(t, cbor.Unmarshal(mLinked.Put, data), "expected error with nested *omap.OMapLinked[string, int]")
+}
+
+func TestErrors(t *testing.T) {
+	invalid := []string{
+		"",                         // empty
+		"01",                       // not a map
+		"a1",                       // missing key
+		"a16161",                   // missing value
+		"a1616118",                 // truncated argument
+		"a161611c",                 // invalid additional information
+		"a16161f8",                 // unsupported simple value
+		"a1616162",                 // truncated string
+		"a161615f",                 // unterminated indefinite string
+		"a161615f6161ff",           // chunk of another type
+		"a161615f5f",               // nested indefinite chunk
+		"a1616181",                 // truncated array
+		"a161619f",                 // unterminated indefinite array
+		"a16161bf",                 // unterminated indefinite map
+		"a16161bf01",               // indefinite map missing value
+		"a16161c6",                 // tag without content
+		"a16161d8",                 // truncated tag
+		"a161613bffffffffffffffff", // negative integer overflow
+		"a1616101ff",               // trailing data
+		"a19a",                     // truncated indefinite map length
+		"a2616101",                 // missing second entry
+		"a16161a1",                 // truncated nested map
+	}
+	for _, data := range invalid {
+		m := omap.New[string, any]()
+		err := cbor.Unmarshal(m.Put, mustHex(data))
+		if !errors.Is(err, cbor.ErrInvalidData) {
+			t.Errorf("expected ErrInvalidData for %s, found %v", data, err)
+		}
+	}
+	m := omap.New[int, int8]()
+	th.AssertErrNotNil(t, cbor.Unmarshal(m.Put, mustHex("a1616101")), "expected error with string key into int")
+	th.AssertErrNotNil(t, cbor.Unmarshal(m.Put, mustHex("a1011903e8")), "expected error with int8 overflow")
+	_, err := cbor.Decode(mustHex("f6f6"))
+	th.AssertErrIs(t, err, cbor.ErrInvalidData, "expected error with trailing data")
+	_, err = cbor.Decode(mustHex(""))
+	th.AssertErrIs(t, err, cbor.ErrInvalidData, "expected error with empty data")
+	_, err = cbor.Marshal(struct{}{})
+	th.AssertErrNotNil(t, err, "expected error with unsupported type")
+	invalidValue := omap.New[string, any]()
+	invalidValue.Put("a", make(chan int))
+	_, err = cbor.MarshalIterator(invalidValue.Iterator())
+	th.AssertErrNotNil(t, err, "expected error with unsupported value")
+}
+
+// nested one-element arrays, ending with a 0, under key "a"
+func nestedArrays(n int) []byte {
+	b := append(mustHex("a16161"), bytes.Repeat([]byte{0x81}, n)...)
+	return append(b, 0x00)
+}
+
+func TestMaxDepth(t *testing.T) {
+	// the value inside the arrays is at depth n+1
+	m := omap.New[string, any]()
+	th.AssertErrNil(t, cbor.Unmarshal(m.Put, nestedArrays(codecutil.MaxDepth-1)), "unexpected error at max depth")
+	th.AssertErrIs(t, cbor.Unmarshal(m.Put, nestedArrays(codecutil.MaxDepth)), cbor.ErrInvalidData, "expected error above max depth")
+	// would overflow the stack without the limit
+	th.AssertErrIs(t, cbor.Unmarshal(m.Put, nestedArrays(50<<20)), cbor.ErrInvalidData, "expected error with deeply nested arrays")
+	for name, item := range map[string]string{"maps": "a16161", "indefinite arrays": "9f", "tags": "c0"} {
+		_, err := cbor.Decode(append(bytes.Repeat(mustHex(item), codecutil.MaxDepth+1), 0x00))
+		th.AssertErrIs(t, err, cbor.ErrInvalidData, "expected error with deeply nested "+name)
+	}
+}
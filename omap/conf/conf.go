@@ -0,0 +1,333 @@
+// conf package parses and writes configuration files (INI, Java properties and dotenv) into ordered
+// maps, keeping the order of the keys along with comments, blank lines and the original text of
+// each entry, so a file written back without changes is byte-identical to the one parsed, and a
+// file edited through the maps (Put, Delete, omap.MoveAfter, etc.) produces minimal diffs.
+//
+// Each key is mapped to an *Entry, holding the decoded value and the comment/blank lines found
+// before it. Changing the Value of an entry rewrites only its value in the original text, new
+// entries are written in a canonical form of the format. Sections of INI files are nested maps.
+//
+// Use Parse to read into omap.OMap (files with repeated keys or sections are rejected) and
+// ParseMulti to read into omultimap.OMultiMap (repeated keys/sections are kept).
+package conf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+)
+
+var (
+	// Returned, wrapped, when parsing invalid input.
+	ErrSyntax = errors.New("conf: syntax error")
+	// Returned, wrapped, by Parse when a key or section is repeated, use ParseMulti instead.
+	ErrDuplicateKey = errors.New("conf: duplicate key")
+	// Returned, wrapped, when writing a key, value or section that cannot be represented in the format.
+	ErrInvalidEntry = errors.New("conf: invalid entry")
+)
+
+// The supported file formats.
+type Format int
+
+const (
+	// INI files: "key = value" (or "key: value") entries, grouped by "[section]" headers, comment
+	// lines starting with ';' or '#'. Values are the text after the separator, with spaces
+	// trimmed, no quoting or escaping is done.
+	INI Format = iota
+	// Java properties files: "key=value", "key: value" or "key value" entries, with backslash
+	// escapes and line continuations, comment lines starting with '#' or '!'.
+	Properties
+	// Dotenv (.env) files: "KEY=value" entries (optionally with "export " prefix), values unquoted
+	// (with trailing "# comment" ignored), single-quoted (literal) or double-quoted (with backslash
+	// escapes, may span multiple lines), comment lines starting with '#'. Variables are not expanded.
+	Dotenv
+)
+
+// Entry is the value of a key, along with the text around it needed to write it back unchanged.
+type Entry struct {
+	// The value, decoded (unquoted and unescaped) according to the format.
+	Value string
+	// Comment and blank lines before the entry, as found in the file (including line breaks).
+	Comments string
+	// original text of the entry (including line break) and the key and value it was parsed as
+	raw      string
+	rawKey   string
+	rawValue string
+	// position of the encoded value in raw
+	valueStart int
+	valueEnd   int
+}
+
+// File is a configuration file with keys in omap.OMap, see Parse.
+type File struct {
+	// Entries of properties and dotenv files, or entries before the first section of INI files.
+	Entries omap.OMap[string, *Entry]
+	// Sections of INI files, must be empty for other formats.
+	Sections omap.OMap[string, *Section]
+	// Comment and blank lines after the last entry or section.
+	Trailer string
+	format  Format
+	newline string
+}
+
+// Section is a section of an INI file, with keys in omap.OMap.
+type Section struct {
+	Entries omap.OMap[string, *Entry]
+	// Comment and blank lines before the section header.
+	Comments string
+	header
+}
+
+// MultiFile is a configuration file with keys in omultimap.OMultiMap, see ParseMulti.
+type MultiFile struct {
+	// Entries of properties and dotenv files, or entries before the first section of INI files.
+	Entries omultimap.OMultiMap[string, *Entry]
+	// Sections of INI files, must be empty for other formats.
+	Sections omultimap.OMultiMap[string, *MultiSection]
+	// Comment and blank lines after the last entry or section.
+	Trailer string
+	format  Format
+	newline string
+}
+
+// MultiSection is a section of an INI file, with keys in omultimap.OMultiMap.
+type MultiSection struct {
+	Entries omultimap.OMultiMap[string, *Entry]
+	// Comment and blank lines before the section header.
+	Comments string
+	header
+}
+
+// original text of a section header and the name it was parsed as
+type header struct {
+	raw     string
+	rawName string
+}
+
+// Create a new empty File of the given format.
+func New(format Format) *File {
+	return &File{
+		Entries:  omap.New[string, *Entry](),
+		Sections: omap.New[string, *Section](),
+		format:   format,
+		newline:  "\n",
+	}
+}
+
+// Create a new empty MultiFile of the given format.
+func NewMulti(format Format) *MultiFile {
+	return &MultiFile{
+		Entries:  omultimap.New[string, *Entry](),
+		Sections: omultimap.New[string, *MultiSection](),
+		format:   format,
+		newline:  "\n",
+	}
+}
+
+// Create a new empty Section.
+func NewSection() *Section {
+	return &Section{Entries: omap.New[string, *Entry]()}
+}
+
+// Create a new empty MultiSection.
+func NewMultiSection() *MultiSection {
+	return &MultiSection{Entries: omultimap.New[string, *Entry]()}
+}
+
+// Parse the configuration file read from r, in the given format, into a File. Repeated keys (in
+// the same section) or sections return an error wrapping ErrDuplicateKey.
+func Parse(r io.Reader, format Format) (*File, error) {
+	p, err := parse(r, format)
+	if err != nil {
+		return nil, err
+	}
+	f := New(format)
+	f.Trailer, f.newline = p.trailer, p.newline
+	if err := putEntries(f.Entries, p.entries); err != nil {
+		return nil, err
+	}
+	for _, ps := range p.sections {
+		if _, ok := f.Sections.Get(ps.name); ok {
+			return nil, fmt.Errorf("%w: section %q at line %d", ErrDuplicateKey, ps.name, ps.line)
+		}
+		s := &Section{Entries: omap.New[string, *Entry](), Comments: ps.comments, header: ps.header}
+		if err := putEntries(s.Entries, ps.entries); err != nil {
+			return nil, err
+		}
+		f.Sections.Put(ps.name, s)
+	}
+	return f, nil
+}
+
+func putEntries(m omap.OMap[string, *Entry], entries []parsedEntry) error {
+	for _, e := range entries {
+		if _, ok := m.Get(e.key); ok {
+			return fmt.Errorf("%w: %q at line %d", ErrDuplicateKey, e.key, e.line)
+		}
+		m.Put(e.key, e.entry)
+	}
+	return nil
+}
+
+// Parse the configuration file read from r, in the given format, into a MultiFile.
+func ParseMulti(r io.Reader, format Format) (*MultiFile, error) {
+	p, err := parse(r, format)
+	if err != nil {
+		return nil, err
+	}
+	f := NewMulti(format)
+	f.Trailer, f.newline = p.trailer, p.newline
+	for _, e := range p.entries {
+		f.Entries.Put(e.key, e.entry)
+	}
+	for _, ps := range p.sections {
+		s := &MultiSection{Entries: omultimap.New[string, *Entry](), Comments: ps.comments, header: ps.header}
+		for _, e := range ps.entries {
+			s.Entries.Put(e.key, e.entry)
+		}
+		f.Sections.Put(ps.name, s)
+	}
+	return f, nil
+}
+
+//// writer ////
+
+type output struct {
+	bytes.Buffer
+	format  Format
+	newline string
+}
+
+// Write the given text, ensuring the previous one ended with a line break.
+func (o *output) text(s string) {
+	if s == "" {
+		return
+	}
+	if o.Len() > 0 && o.Bytes()[o.Len()-1] != '\n' {
+		o.WriteString(o.newline)
+	}
+	o.WriteString(s)
+}
+
+func (o *output) entries(it omap.OMapIterator[string, *Entry]) error {
+	for it.Next() {
+		key, e := it.Key(), it.Value()
+		if e == nil {
+			e = &Entry{}
+		}
+		o.text(e.Comments)
+		switch {
+		case e.raw != "" && key == e.rawKey && e.Value == e.rawValue:
+			o.text(e.raw)
+		case e.raw != "" && key == e.rawKey:
+			value, err := encodeValue(o.format, e.Value)
+			if err != nil {
+				return fmt.Errorf("%w: value of key %q", err, key)
+			}
+			o.text(e.raw[:e.valueStart] + value + e.raw[e.valueEnd:])
+		default:
+			line, err := encodeEntry(o.format, key, e.Value)
+			if err != nil {
+				return err
+			}
+			o.text(line + o.newline)
+		}
+	}
+	return nil
+}
+
+func (o *output) section(name string, comments string, h header) error {
+	if o.format != INI {
+		return fmt.Errorf("%w: sections are supported only by INI", ErrInvalidEntry)
+	}
+	o.text(comments)
+	if h.raw != "" && name == h.rawName {
+		o.text(h.raw)
+		return nil
+	}
+	if name == "" || strings.ContainsAny(name, "[]\r\n") {
+		return fmt.Errorf("%w: section name %q", ErrInvalidEntry, name)
+	}
+	o.text("[" + name + "]" + o.newline)
+	return nil
+}
+
+func (o *output) flush(w io.Writer, trailer string) (int64, error) {
+	o.text(trailer)
+	return o.WriteTo(w)
+}
+
+// Write the file to w. Nothing is written if any entry or section cannot be represented in the
+// format of the file.
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	o := &output{format: f.format, newline: f.newline}
+	if f.Entries != nil {
+		if err := o.entries(f.Entries.Iterator()); err != nil {
+			return 0, err
+		}
+	}
+	if f.Sections != nil {
+		for it := f.Sections.Iterator(); it.Next(); {
+			s := it.Value()
+			if s == nil {
+				s = NewSection()
+			}
+			if err := o.section(it.Key(), s.Comments, s.header); err != nil {
+				return 0, err
+			}
+			if s.Entries != nil {
+				if err := o.entries(s.Entries.Iterator()); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+	return o.flush(w, f.Trailer)
+}
+
+// Write the file to w. Nothing is written if any entry or section cannot be represented in the
+// format of the file.
+func (f *MultiFile) WriteTo(w io.Writer) (int64, error) {
+	o := &output{format: f.format, newline: f.newline}
+	if f.Entries != nil {
+		if err := o.entries(f.Entries.Iterator()); err != nil {
+			return 0, err
+		}
+	}
+	if f.Sections != nil {
+		for it := f.Sections.Iterator(); it.Next(); {
+			s := it.Value()
+			if s == nil {
+				s = NewMultiSection()
+			}
+			if err := o.section(it.Key(), s.Comments, s.header); err != nil {
+				return 0, err
+			}
+			if s.Entries != nil {
+				if err := o.entries(s.Entries.Iterator()); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+	return o.flush(w, f.Trailer)
+}
+
+// Implement fmt.Stringer interface, returning the file content (empty if it cannot be written).
+func (f *File) String() string {
+	var b strings.Builder
+	f.WriteTo(&b)
+	return b.String()
+}
+
+// Implement fmt.Stringer interface, returning the file content (empty if it cannot be written).
+func (f *MultiFile) String() string {
+	var b strings.Builder
+	f.WriteTo(&b)
+	return b.String()
+}
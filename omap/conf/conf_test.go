@@ -0,0 +1,383 @@
+package conf_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omap/conf"
+)
+
+const iniFile = `; global settings
+name = demo
+empty =
+
+# database
+[database]
+host=localhost
+port : 5432
+
+; the cache
+[ cache ]
+enabled = true
+; trailing comment
+`
+
+const propertiesFile = `# comment
+! another comment
+app.name = My App
+app.path=C:\\dir\\file
+multi = first, \
+        second, \
+        third
+key\ with\ spaces value
+unicode=caf\u00e9 \ud83d\ude00
+empty
+`
+
+const dotenvFile = "# env\r\n" +
+	"export HOST=localhost\r\n" +
+	"PORT = 8080 # inline\r\n" +
+	"\r\n" +
+	"SINGLE='lit $x \\n'\r\n" +
+	"DOUBLE=\"a\\tb \\\"q\\\" \\$HOME\"\r\n" +
+	"MULTI=\"line1\r\nline2\"\r\n" +
+	"EMPTY=\r\n" +
+	"# end"
+
+type entryKV = th.KeyValue[string, string]
+
+func values(it omap.OMapIterator[string, *conf.Entry]) []entryKV {
+	ret := make([]entryKV, 0)
+	for it.Next() {
+		ret = append(ret, entryKV{Key: it.Key(), Value: it.Value().Value})
+	}
+	return ret
+}
+
+func assertValues(t *testing.T, it omap.OMapIterator[string, *conf.Entry], exp []entryKV) {
+	t.Helper()
+	found := values(it)
+	if len(found) != len(exp) {
+		t.Fatalf("expected %q, found %q", exp, found)
+	}
+	for i := range exp {
+		if found[i] != exp[i] {
+			t.Errorf("expected %q, found %q", exp, found)
+			return
+		}
+	}
+}
+
+func assertContent(t *testing.T, s interface{ String() string }, exp string) {
+	t.Helper()
+	if found := s.String(); found != exp {
+		t.Errorf("expected:\n%q\nfound:\n%q", exp, found)
+	}
+}
+
+func TestINI(t *testing.T) {
+	f, err := conf.Parse(strings.NewReader(iniFile), conf.INI)
+	th.AssertErrNil(t, err, "unexpected error on Parse")
+	assertContent(t, f, iniFile)
+	assertValues(t, f.Entries.Iterator(), []entryKV{{Key: "name", Value: "demo"}, {Key: "empty", Value: ""}})
+	db, _ := f.Sections.Get("database")
+	assertValues(t, db.Entries.Iterator(), []entryKV{{Key: "host", Value: "localhost"}, {Key: "port", Value: "5432"}})
+	if db.Comments != "\n# database\n" {
+		t.Errorf("unexpected section comments %q", db.Comments)
+	}
+	cache, _ := f.Sections.Get("cache")
+	assertValues(t, cache.Entries.Iterator(), []entryKV{{Key: "enabled", Value: "true"}})
+	if f.Trailer != "; trailing comment\n" {
+		t.Errorf("unexpected trailer %q", f.Trailer)
+	}
+	// edits: change values, move, delete and add entries and sections
+	host, _ := db.Entries.Get("host")
+	host.Value = "db.local"
+	th.AssertErrNil(t, omap.MoveFirst(db.Entries, "port"), "unexpected error on MoveFirst")
+	f.Entries.Delete("empty")
+	empty, _ := f.Entries.Get("name")
+	empty.Value = ""
+	cache.Entries.Put("size", &conf.Entry{Value: "10", Comments: "; new entry"})
+	logs := conf.NewSection()
+	logs.Entries.Put("level", &conf.Entry{Value: "debug"})
+	f.Sections.Put("logs", logs)
+	exp := `; global settings
+name = 
+
+# database
+[database]
+port : 5432
+host=db.local
+
+; the cache
+[ cache ]
+enabled = true
+; new entry
+size = 10
+[logs]
+level = debug
+; trailing comment
+`
+	assertContent(t, f, exp)
+	// rename of a section rewrites its header
+	f.Sections.Delete("cache")
+	f.Sections.Put("store", cache)
+	th.AssertErrNil(t, omap.MoveAfter(f.Sections, "store", "database"), "unexpected error on MoveAfter")
+	if s := f.String(); !strings.Contains(s, "\n; the cache\n[store]\nenabled = true\n") {
+		t.Errorf("expected renamed section, found %q", s)
+	}
+}
+
+func TestProperties(t *testing.T) {
+	f, err := conf.Parse(strings.NewReader(propertiesFile), conf.Properties)
+	th.AssertErrNil(t, err, "unexpected error on Parse")
+	assertContent(t, f, propertiesFile)
+	assertValues(t, f.Entries.Iterator(), []entryKV{
+		{Key: "app.name", Value: "My App"},
+		{Key: "app.path", Value: `C:\dir\file`},
+		{Key: "multi", Value: "first, second, third"},
+		{Key: "key with spaces", Value: "value"},
+		{Key: "unicode", Value: "café 😀"},
+		{Key: "empty", Value: ""},
+	})
+	if f.Sections.Len() != 0 {
+		t.Errorf("expected no sections, found %d", f.Sections.Len())
+	}
+	multi, _ := f.Entries.Get("multi")
+	multi.Value = "one line"
+	path, _ := f.Entries.Get("app.path")
+	path.Value = " D:\\x\ty=z#"
+	f.Entries.Put("new key", &conf.Entry{Value: "a:b\nc"})
+	f.Entries.Put("#", &conf.Entry{Value: "!"})
+	exp := `# comment
+! another comment
+app.name = My App
+app.path=\ D:\\x\ty=z#
+multi = one line
+key\ with\ spaces value
+unicode=caf\u00e9 \ud83d\ude00
+empty
+new\ key=a:b\nc
+\#=\!
+`
+	assertContent(t, f, exp)
+	// round-trip of written values
+	f2, err := conf.Parse(strings.NewReader(exp), conf.Properties)
+	th.AssertErrNil(t, err, "unexpected error on Parse")
+	assertValues(t, f2.Entries.Iterator(), values(f.Entries.Iterator()))
+	// control chars
+	f = conf.New(conf.Properties)
+	f.Entries.Put("k", &conf.Entry{Value: "\x01\f\r"})
+	assertContent(t, f, "k=\\u0001\\f\\r\n")
+	f2, err = conf.Parse(strings.NewReader(f.String()), conf.Properties)
+	th.AssertErrNil(t, err, "unexpected error on Parse")
+	assertValues(t, f2.Entries.Iterator(), []entryKV{{Key: "k", Value: "\x01\f\r"}})
+	// other separators and escapes
+	f, err = conf.Parse(strings.NewReader("a:b\nc   d\n  e=\\x\\\n"), conf.Properties)
+	th.AssertErrNil(t, err, "unexpected error on Parse")
+	assertValues(t, f.Entries.Iterator(), []entryKV{{Key: "a", Value: "b"}, {Key: "c", Value: "d"}, {Key: "e", Value: "x"}})
+}
+
+func TestDotenv(t *testing.T) {
+	f, err := conf.Parse(strings.NewReader(dotenvFile), conf.Dotenv)
+	th.AssertErrNil(t, err, "unexpected error on Parse")
+	assertContent(t, f, dotenvFile)
+	assertValues(t, f.Entries.Iterator(), []entryKV{
+		{Key: "HOST", Value: "localhost"},
+		{Key: "PORT", Value: "8080"},
+		{Key: "SINGLE", Value: `lit $x \n`},
+		{Key: "DOUBLE", Value: "a\tb \"q\" $HOME"},
+		{Key: "MULTI", Value: "line1\r\nline2"},
+		{Key: "EMPTY", Value: ""},
+	})
+	host, _ := f.Entries.Get("HOST")
+	host.Value = "db"
+	port, _ := f.Entries.Get("PORT")
+	port.Value = "80 81"
+	multi, _ := f.Entries.Get("MULTI")
+	multi.Value = "single"
+	f.Entries.Put("NEW", &conf.Entry{Value: "$x\\"})
+	exp := "# env\r\n" +
+		"export HOST=db\r\n" +
+		"PORT = \"80 81\" # inline\r\n" +
+		"\r\n" +
+		"SINGLE='lit $x \\n'\r\n" +
+		"DOUBLE=\"a\\tb \\\"q\\\" \\$HOME\"\r\n" +
+		"MULTI=single\r\n" +
+		"EMPTY=\r\n" +
+		"NEW=\"\\$x\\\\\"\r\n" +
+		"# end"
+	assertContent(t, f, exp)
+	f2, err := conf.Parse(strings.NewReader(exp), conf.Dotenv)
+	th.AssertErrNil(t, err, "unexpected error on Parse")
+	assertValues(t, f2.Entries.Iterator(), values(f.Entries.Iterator()))
+	// other escapes and comments after quotes
+	f, err = conf.Parse(strings.NewReader("A=\"\\n\\r\\x\" # c\nB='x' \nC=a#b\n"), conf.Dotenv)
+	th.AssertErrNil(t, err, "unexpected error on Parse")
+	assertValues(t, f.Entries.Iterator(), []entryKV{{Key: "A", Value: "\n\r\\x"}, {Key: "B", Value: "x"}, {Key: "C", Value: "a#b"}})
+}
+
+func TestMulti(t *testing.T) {
+	input := "a = 1\n[s]\nx = 1\nx = 2\n[s]\nx = 3\n"
+	_, err := conf.Parse(strings.NewReader(input), conf.INI)
+	th.AssertErrIs(t, err, conf.ErrDuplicateKey, "expected ErrDuplicateKey with repeated key")
+	_, err = conf.Parse(strings.NewReader("[s]\n[s]\n"), conf.INI)
+	th.AssertErrIs(t, err, conf.ErrDuplicateKey, "expected ErrDuplicateKey with repeated section")
+	_, err = conf.Parse(strings.NewReader("a=1\na=2\n"), conf.Dotenv)
+	th.AssertErrIs(t, err, conf.ErrDuplicateKey, "expected ErrDuplicateKey with repeated top-level key")
+	f, err := conf.ParseMulti(strings.NewReader(input), conf.INI)
+	th.AssertErrNil(t, err, "unexpected error on ParseMulti")
+	assertContent(t, f, input)
+	sections := omap.IteratorValuesToSlice(f.Sections.GetValuesOf("s"))
+	if len(sections) != 2 {
+		t.Fatalf("expected 2 sections, found %d", len(sections))
+	}
+	assertValues(t, sections[0].Entries.Iterator(), []entryKV{{Key: "x", Value: "1"}, {Key: "x", Value: "2"}})
+	// edits
+	it := sections[0].Entries.GetValuesOf("x")
+	it.Next()
+	it.Value().Value = "one"
+	sections[1].Entries.Put("y", &conf.Entry{Value: "4"})
+	f.Entries.Put("a", &conf.Entry{Value: "again"})
+	ns := conf.NewMultiSection()
+	ns.Entries.Put("k", &conf.Entry{Value: "v"}, &conf.Entry{Value: "w"})
+	f.Sections.Put("new", ns)
+	assertContent(t, f, "a = 1\na = again\n[s]\nx = one\nx = 2\n[s]\nx = 3\ny = 4\n[new]\nk = v\nk = w\n")
+	// properties
+	mf := conf.NewMulti(conf.Properties)
+	mf.Entries.Put("k", &conf.Entry{Value: "1"}, &conf.Entry{Value: "2"})
+	assertContent(t, mf, "k=1\nk=2\n")
+	_, err = conf.ParseMulti(strings.NewReader("bad"), conf.INI)
+	th.AssertErrIs(t, err, conf.ErrSyntax, "expected ErrSyntax")
+}
+
+func TestWriteEdgeCases(t *testing.T) {
+	// no final line break, new entries and trailer
+	f, err := conf.Parse(strings.NewReader("a=1"), conf.Dotenv)
+	th.AssertErrNil(t, err, "unexpected error on Parse")
+	assertContent(t, f, "a=1")
+	f.Entries.Put("b", &conf.Entry{Value: "2", Comments: "# no break"})
+	f.Trailer = "# end"
+	assertContent(t, f, "a=1\n# no break\nb=2\n# end")
+	// nil entries, sections and maps
+	f = conf.New(conf.INI)
+	f.Entries.Put("a", nil)
+	f.Sections.Put("s", nil)
+	f.Sections.Put("t", &conf.Section{})
+	assertContent(t, f, "a =\n[s]\n[t]\n")
+	f.Entries, f.Sections = nil, nil
+	assertContent(t, f, "")
+	mf := conf.NewMulti(conf.INI)
+	mf.Sections.Put("s", nil, &conf.MultiSection{})
+	assertContent(t, mf, "[s]\n[s]\n")
+	mf.Entries, mf.Sections = nil, nil
+	assertContent(t, mf, "")
+	// values with only spaces
+	f, err = conf.Parse(strings.NewReader("a =  \t\n"), conf.INI)
+	th.AssertErrNil(t, err, "unexpected error on Parse")
+	assertValues(t, f.Entries.Iterator(), []entryKV{{Key: "a", Value: ""}})
+	a, _ := f.Entries.Get("a")
+	a.Value = "x"
+	assertContent(t, f, "a =  \tx\n")
+	f, err = conf.Parse(strings.NewReader("A=  \n"), conf.Dotenv)
+	th.AssertErrNil(t, err, "unexpected error on Parse")
+	assertValues(t, f.Entries.Iterator(), []entryKV{{Key: "A", Value: ""}})
+	// empty input
+	f, err = conf.Parse(strings.NewReader(""), conf.Properties)
+	th.AssertErrNil(t, err, "unexpected error on Parse")
+	assertContent(t, f, "")
+}
+
+type failReader struct{}
+
+func (failReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestErrors(t *testing.T) {
+	invalid := []struct {
+		format conf.Format
+		input  string
+	}{
+		{conf.INI, "[section\n"},
+		{conf.INI, "novalue\n"},
+		{conf.INI, " = value\n"},
+		{conf.Properties, "a=\\u12\n"},
+		{conf.Properties, "a\\uzzzz=b\n"},
+		{conf.Dotenv, "novalue\n"},
+		{conf.Dotenv, "1A=x\n"},
+		{conf.Dotenv, "A B=x\n"},
+		{conf.Dotenv, "A=\"unterminated\n"},
+		{conf.Dotenv, "A='x' y\n"},
+	}
+	for _, c := range invalid {
+		_, err := conf.Parse(strings.NewReader(c.input), c.format)
+		th.AssertErrIs(t, err, conf.ErrSyntax, "expected ErrSyntax with "+c.input)
+		_, err = conf.ParseMulti(strings.NewReader(c.input), c.format)
+		th.AssertErrIs(t, err, conf.ErrSyntax, "expected ErrSyntax with "+c.input)
+	}
+	_, err := conf.Parse(failReader{}, conf.INI)
+	th.AssertErrNotNil(t, err, "expected error from reader")
+	_, err = conf.Parse(strings.NewReader("[s]\na=1\na=2\n"), conf.INI)
+	th.AssertErrIs(t, err, conf.ErrDuplicateKey, "expected ErrDuplicateKey in section")
+	// values and keys that cannot be written
+	var b strings.Builder
+	f, _ := conf.Parse(strings.NewReader("a = 1\n"), conf.INI)
+	a, _ := f.Entries.Get("a")
+	a.Value = "multi\nline"
+	_, err = f.WriteTo(&b)
+	th.AssertErrIs(t, err, conf.ErrInvalidEntry, "expected ErrInvalidEntry with multi-line INI value")
+	if b.Len() != 0 || f.String() != "" {
+		t.Errorf("expected nothing written, found %q", b.String())
+	}
+	for _, key := range []string{"", " a", "a=b", "[a", ";a"} {
+		f = conf.New(conf.INI)
+		f.Entries.Put(key, &conf.Entry{Value: "x"})
+		_, err = f.WriteTo(&b)
+		th.AssertErrIs(t, err, conf.ErrInvalidEntry, "expected ErrInvalidEntry with INI key "+key)
+	}
+	f = conf.New(conf.INI)
+	f.Entries.Put("a", &conf.Entry{Value: " x"})
+	_, err = f.WriteTo(&b)
+	th.AssertErrIs(t, err, conf.ErrInvalidEntry, "expected ErrInvalidEntry with INI value with spaces")
+	for _, name := range []string{"", "a]"} {
+		f = conf.New(conf.INI)
+		f.Sections.Put(name, conf.NewSection())
+		_, err = f.WriteTo(&b)
+		th.AssertErrIs(t, err, conf.ErrInvalidEntry, "expected ErrInvalidEntry with section "+name)
+	}
+	s := conf.NewSection()
+	s.Entries.Put("", &conf.Entry{})
+	f = conf.New(conf.INI)
+	f.Sections.Put("s", s)
+	_, err = f.WriteTo(&b)
+	th.AssertErrIs(t, err, conf.ErrInvalidEntry, "expected ErrInvalidEntry with key in section")
+	f = conf.New(conf.Dotenv)
+	f.Entries.Put("A B", &conf.Entry{})
+	_, err = f.WriteTo(&b)
+	th.AssertErrIs(t, err, conf.ErrInvalidEntry, "expected ErrInvalidEntry with dotenv key")
+	f = conf.New(conf.Properties)
+	f.Sections.Put("s", conf.NewSection())
+	_, err = f.WriteTo(&b)
+	th.AssertErrIs(t, err, conf.ErrInvalidEntry, "expected ErrInvalidEntry with section in properties")
+	mf := conf.NewMulti(conf.Dotenv)
+	mf.Sections.Put("s", conf.NewMultiSection())
+	_, err = mf.WriteTo(&b)
+	th.AssertErrIs(t, err, conf.ErrInvalidEntry, "expected ErrInvalidEntry with section in dotenv")
+	mf = conf.NewMulti(conf.Dotenv)
+	mf.Entries.Put("", &conf.Entry{})
+	_, err = mf.WriteTo(&b)
+	th.AssertErrIs(t, err, conf.ErrInvalidEntry, "expected ErrInvalidEntry with empty dotenv key")
+	ms := conf.NewMultiSection()
+	ms.Entries.Put("", &conf.Entry{})
+	mf = conf.NewMulti(conf.INI)
+	mf.Sections.Put("s", ms)
+	_, err = mf.WriteTo(&b)
+	th.AssertErrIs(t, err, conf.ErrInvalidEntry, "expected ErrInvalidEntry with key in multi section")
+	if mf.String() != "" {
+		t.Errorf("expected empty String on error")
+	}
+}
@@ -0,0 +1,487 @@
+package conf
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type parsedEntry struct {
+	key   string
+	entry *Entry
+	line  int
+}
+
+type parsedSection struct {
+	name     string
+	comments string
+	header
+	entries []parsedEntry
+	line    int
+}
+
+type parsed struct {
+	entries  []parsedEntry
+	sections []parsedSection
+	trailer  string
+	newline  string
+}
+
+// Split data into lines, keeping the line breaks.
+func splitLines(data string) []string {
+	lines := strings.SplitAfter(data, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func isComment(format Format, trimmed string) bool {
+	switch format {
+	case INI:
+		return trimmed[0] == ';' || trimmed[0] == '#'
+	case Properties:
+		return trimmed[0] == '#' || trimmed[0] == '!'
+	default:
+		return trimmed[0] == '#'
+	}
+}
+
+func parse(r io.Reader, format Format) (*parsed, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &parsed{newline: "\n"}
+	if strings.Contains(string(data), "\r\n") {
+		p.newline = "\r\n"
+	}
+	lines := splitLines(string(data))
+	entries := &p.entries
+	comments := ""
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || isComment(format, trimmed) {
+			comments += line
+			i++
+			continue
+		}
+		if format == INI && trimmed[0] == '[' {
+			if !strings.HasSuffix(trimmed, "]") {
+				return nil, fmt.Errorf("%w: line %d: unterminated section header", ErrSyntax, i+1)
+			}
+			name := strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			p.sections = append(p.sections, parsedSection{
+				name:     name,
+				comments: comments,
+				header:   header{raw: line, rawName: name},
+				line:     i + 1,
+			})
+			entries = &p.sections[len(p.sections)-1].entries
+			comments = ""
+			i++
+			continue
+		}
+		var res entryResult
+		switch format {
+		case INI:
+			res, err = parseINIEntry(line)
+		case Properties:
+			res, err = parsePropertiesEntry(lines[i:])
+		default:
+			res, err = parseDotenvEntry(lines[i:])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrSyntax, i+1, err)
+		}
+		raw := strings.Join(lines[i:i+res.lines], "")
+		*entries = append(*entries, parsedEntry{
+			key: res.key,
+			entry: &Entry{
+				Value:      res.value,
+				Comments:   comments,
+				raw:        raw,
+				rawKey:     res.key,
+				rawValue:   res.value,
+				valueStart: res.valueStart,
+				valueEnd:   res.valueEnd,
+			},
+			line: i + 1,
+		})
+		comments = ""
+		i += res.lines
+	}
+	p.trailer = comments
+	return p, nil
+}
+
+// An entry parsed, with the position of the encoded value in the (joined) lines consumed.
+type entryResult struct {
+	key        string
+	value      string
+	lines      int
+	valueStart int
+	valueEnd   int
+}
+
+// Return the position of the end of s ignoring the line break.
+func contentEnd(s string) int {
+	return len(strings.TrimRight(s, "\r\n"))
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\f'
+}
+
+// Return the position of the first non-space character of s, from position i.
+func skipSpaces(s string, i int) int {
+	for i < len(s) && isSpace(s[i]) {
+		i++
+	}
+	return i
+}
+
+// Return the position after the last non-space character of s.
+func trimSpacesEnd(s string, end int) int {
+	for end > 0 && isSpace(s[end-1]) {
+		end--
+	}
+	return end
+}
+
+//// INI ////
+
+func parseINIEntry(line string) (entryResult, error) {
+	end := contentEnd(line)
+	sep := strings.IndexAny(line[:end], "=:")
+	if sep < 0 {
+		return entryResult{}, fmt.Errorf("expected key = value")
+	}
+	start := skipSpaces(line, 0)
+	if start >= sep {
+		return entryResult{}, fmt.Errorf("empty key")
+	}
+	key := line[start:trimSpacesEnd(line, sep)]
+	valueStart := skipSpaces(line, sep+1)
+	valueEnd := trimSpacesEnd(line, end)
+	if valueEnd < valueStart {
+		valueEnd = valueStart
+	}
+	return entryResult{key: key, value: line[valueStart:valueEnd], lines: 1, valueStart: valueStart, valueEnd: valueEnd}, nil
+}
+
+func encodeINIEntry(key, value string) (string, error) {
+	if key == "" || key != strings.TrimSpace(key) || strings.ContainsAny(key, "=:\r\n") ||
+		key[0] == '[' || isComment(INI, key) {
+		return "", fmt.Errorf("%w: INI key %q", ErrInvalidEntry, key)
+	}
+	encoded, err := encodeINIValue(value)
+	if err != nil {
+		return "", fmt.Errorf("%w: value of key %q", err, key)
+	}
+	if encoded == "" {
+		return key + " =", nil
+	}
+	return key + " = " + encoded, nil
+}
+
+func encodeINIValue(value string) (string, error) {
+	if value != strings.TrimSpace(value) || strings.ContainsAny(value, "\r\n") {
+		return "", ErrInvalidEntry
+	}
+	return value, nil
+}
+
+//// properties ////
+
+// Return the count of consecutive backslashes at the end of s.
+func trailingBackslashes(s string) int {
+	n := 0
+	for n < len(s) && s[len(s)-1-n] == '\\' {
+		n++
+	}
+	return n
+}
+
+func parsePropertiesEntry(lines []string) (entryResult, error) {
+	// join the logical line, keeping the position of each character on the raw lines
+	var logical strings.Builder
+	var positions []int
+	offset, n := 0, 0
+	for n < len(lines) {
+		line := lines[n]
+		end := contentEnd(line)
+		start := 0
+		if n > 0 {
+			start = skipSpaces(line, 0)
+		}
+		content := line[start:end]
+		continued := trailingBackslashes(content)%2 == 1
+		if continued {
+			content = content[:len(content)-1]
+		}
+		logical.WriteString(content)
+		for i := range content {
+			positions = append(positions, offset+start+i)
+		}
+		offset += len(line)
+		n++
+		if !continued {
+			break
+		}
+	}
+	s := logical.String()
+	positions = append(positions, offset-len(lines[n-1])+contentEnd(lines[n-1]))
+	// key, up to the first unescaped separator
+	i := skipSpaces(s, 0)
+	keyStart := i
+	for i < len(s) && s[i] != '=' && s[i] != ':' && !isSpace(s[i]) {
+		if s[i] == '\\' {
+			i++
+		}
+		i++
+	}
+	keyEnd := i
+	i = skipSpaces(s, i)
+	if i < len(s) && (s[i] == '=' || s[i] == ':') {
+		i = skipSpaces(s, i+1)
+	}
+	key, err := unescapeProperties(s[keyStart:keyEnd])
+	if err != nil {
+		return entryResult{}, err
+	}
+	value, err := unescapeProperties(s[i:])
+	if err != nil {
+		return entryResult{}, err
+	}
+	return entryResult{key: key, value: value, lines: n, valueStart: positions[i], valueEnd: positions[len(s)]}, nil
+}
+
+func unescapeProperties(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			break
+		}
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("malformed \\uxxxx escape")
+			}
+			code, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\uxxxx escape")
+			}
+			r := rune(code)
+			i += 4
+			// surrogate pair
+			if utf8.RuneLen(r) < 0 && i+7 <= len(s) && s[i+1:i+3] == "\\u" {
+				if low, err := strconv.ParseUint(s[i+3:i+7], 16, 16); err == nil && low >= 0xdc00 && low <= 0xdfff {
+					r = ((r - 0xd800) << 10) + (rune(low) - 0xdc00) + 0x10000
+					i += 6
+				}
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+func escapeProperties(s string, isKey bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\f':
+			b.WriteString(`\f`)
+		case ' ':
+			if isKey || i == 0 {
+				b.WriteString(`\ `)
+			} else {
+				b.WriteByte(c)
+			}
+		case '=', ':', '#', '!':
+			if isKey || i == 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(c)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&b, `\u%04x`, c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	return b.String()
+}
+
+//// dotenv ////
+
+func isDotenvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !(c == '_' || c == '.' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(i > 0 && c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseDotenvEntry(lines []string) (entryResult, error) {
+	line := lines[0]
+	i := skipSpaces(line, 0)
+	if rest := line[i:]; strings.HasPrefix(rest, "export") && len(rest) > 6 && isSpace(rest[6]) {
+		i = skipSpaces(line, i+6)
+	}
+	sep := strings.IndexByte(line, '=')
+	if sep < i {
+		return entryResult{}, fmt.Errorf("expected KEY=value")
+	}
+	key := line[i:trimSpacesEnd(line, sep)]
+	if !isDotenvKey(key) {
+		return entryResult{}, fmt.Errorf("invalid key %q", key)
+	}
+	start := skipSpaces(line, sep+1)
+	res := entryResult{key: key, lines: 1, valueStart: start}
+	end := contentEnd(line)
+	if start >= end || (line[start] != '"' && line[start] != '\'') {
+		// unquoted, up to an inline comment
+		valueEnd := end
+		for j := start; j < end; j++ {
+			if line[j] == '#' && j > start && isSpace(line[j-1]) {
+				valueEnd = j
+				break
+			}
+		}
+		res.valueEnd = trimSpacesEnd(line, valueEnd)
+		if res.valueEnd < start {
+			res.valueEnd = start
+		}
+		res.value = line[start:res.valueEnd]
+		return res, nil
+	}
+	// quoted, possibly spanning multiple lines
+	quote := line[start]
+	text := line
+	var b strings.Builder
+	j := start + 1
+	for {
+		if j >= len(text) {
+			if res.lines == len(lines) {
+				return entryResult{}, fmt.Errorf("unterminated quoted value")
+			}
+			text += lines[res.lines]
+			res.lines++
+			continue
+		}
+		c := text[j]
+		if c == quote {
+			break
+		}
+		if c == '\\' && quote == '"' && j+1 < len(text) {
+			j++
+			switch text[j] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$':
+				b.WriteByte(text[j])
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(text[j])
+			}
+		} else {
+			b.WriteByte(c)
+		}
+		j++
+	}
+	res.value = b.String()
+	res.valueEnd = j + 1
+	// only spaces or a comment allowed after the closing quote
+	rest := strings.TrimSpace(text[res.valueEnd:])
+	if rest != "" && rest[0] != '#' {
+		return entryResult{}, fmt.Errorf("unexpected text after quoted value")
+	}
+	return res, nil
+}
+
+func encodeDotenvValue(value string) string {
+	safe := true
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+			strings.IndexByte("_-.,/:@+%", c) >= 0) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return value
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(value) + `"`
+}
+
+//// encoding ////
+
+func encodeValue(format Format, value string) (string, error) {
+	switch format {
+	case INI:
+		return encodeINIValue(value)
+	case Properties:
+		return escapeProperties(value, false), nil
+	default:
+		return encodeDotenvValue(value), nil
+	}
+}
+
+func encodeEntry(format Format, key, value string) (string, error) {
+	switch format {
+	case INI:
+		return encodeINIEntry(key, value)
+	case Properties:
+		return escapeProperties(key, true) + "=" + escapeProperties(value, false), nil
+	default:
+		if !isDotenvKey(key) {
+			return "", fmt.Errorf("%w: dotenv key %q", ErrInvalidEntry, key)
+		}
+		return key + "=" + encodeDotenvValue(value), nil
+	}
+}
@@ -0,0 +1,249 @@
+// csv package reads and writes CSV files as ordered maps, using the header line (the first record)
+// as keys, so each record is a map from column name to field with the same order of the columns.
+//
+// It is built on top of encoding/csv, the underlying reader/writer is exposed (as field CSV of
+// Reader and Writer) so the format can be configured (separator, comments, quoting, etc.).
+package csv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+)
+
+var (
+	// Returned by Reader.Read when the header has repeated column names, use Reader.ReadMulti instead.
+	ErrDuplicateHeader = errors.New("csv: duplicate column in header")
+	// Returned, wrapped, by Writer.Write when a map has no value for a column and MissingError is used.
+	ErrMissingKey = errors.New("csv: missing key")
+	// Returned, wrapped, by Writer.Write when a map has keys not in the header and ExtraError is used.
+	ErrExtraKey = errors.New("csv: extra key")
+	// Returned by Writer.Write when the header is taken from the first map written and it is empty.
+	ErrEmptyHeader = errors.New("csv: empty header")
+)
+
+//// Reader ////
+
+// Reader reads records of a CSV file as ordered maps, see NewReader.
+type Reader struct {
+	// The underlying CSV reader, can be configured before the first read.
+	CSV    *csv.Reader
+	header []string
+	// true if header has repeated column names
+	duplicated bool
+}
+
+// Create a new Reader from r. The first record read is used as the header.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{CSV: csv.NewReader(r)}
+}
+
+// Return the header (the column names), reading it if not read yet.
+func (r *Reader) Header() ([]string, error) {
+	if r.header != nil {
+		return r.header, nil
+	}
+	header, err := r.CSV.Read()
+	if err != nil {
+		return nil, err
+	}
+	r.header = append([]string(nil), header...)
+	seen := make(map[string]bool, len(header))
+	for _, name := range header {
+		if seen[name] {
+			r.duplicated = true
+		}
+		seen[name] = true
+	}
+	return r.header, nil
+}
+
+func (r *Reader) readRecord() ([]string, error) {
+	if _, err := r.Header(); err != nil {
+		return nil, err
+	}
+	record, err := r.CSV.Read()
+	if err != nil {
+		return nil, err
+	}
+	if len(record) != len(r.header) {
+		// only possible with CSV.FieldsPerRecord < 0
+		line, _ := r.CSV.FieldPos(0)
+		return nil, &csv.ParseError{StartLine: line, Line: line, Column: 1, Err: csv.ErrFieldCount}
+	}
+	return record, nil
+}
+
+// Read the next record as an ordered map of column name to field, in the order of the columns.
+// It returns io.EOF when there are no more records, and ErrDuplicateHeader if the header has
+// repeated column names (use ReadMulti in such case).
+func (r *Reader) Read() (omap.OMap[string, string], error) {
+	record, err := r.readRecord()
+	if err != nil {
+		return nil, err
+	}
+	if r.duplicated {
+		return nil, fmt.Errorf("%w: %q", ErrDuplicateHeader, r.header)
+	}
+	m := omap.New[string, string]()
+	for i, field := range record {
+		m.Put(r.header[i], field)
+	}
+	return m, nil
+}
+
+// Same as Read, but returns an ordered multimap, so repeated column names are accepted.
+func (r *Reader) ReadMulti() (omultimap.OMultiMap[string, string], error) {
+	record, err := r.readRecord()
+	if err != nil {
+		return nil, err
+	}
+	m := omultimap.New[string, string]()
+	for i, field := range record {
+		m.Put(r.header[i], field)
+	}
+	return m, nil
+}
+
+// Read all the remaining records, see Read.
+func (r *Reader) ReadAll() ([]omap.OMap[string, string], error) {
+	ret := make([]omap.OMap[string, string], 0)
+	for {
+		m, err := r.Read()
+		if err == io.EOF {
+			return ret, nil
+		} else if err != nil {
+			return ret, err
+		}
+		ret = append(ret, m)
+	}
+}
+
+// Read all the remaining records, see ReadMulti.
+func (r *Reader) ReadAllMulti() ([]omultimap.OMultiMap[string, string], error) {
+	ret := make([]omultimap.OMultiMap[string, string], 0)
+	for {
+		m, err := r.ReadMulti()
+		if err == io.EOF {
+			return ret, nil
+		} else if err != nil {
+			return ret, err
+		}
+		ret = append(ret, m)
+	}
+}
+
+//// Writer ////
+
+// What to do when a map written has no value for a column of the header.
+type MissingKeyPolicy int
+
+const (
+	// Write an empty field.
+	MissingEmpty MissingKeyPolicy = iota
+	// Return an error wrapping ErrMissingKey.
+	MissingError
+)
+
+// What to do when a map written has keys that are not in the header.
+type ExtraKeyPolicy int
+
+const (
+	// Return an error wrapping ErrExtraKey.
+	ExtraError ExtraKeyPolicy = iota
+	// Ignore the extra keys.
+	ExtraIgnore
+)
+
+// Options for NewWriter.
+type WriterOptions struct {
+	// The header to write. If empty, the keys of the first map written are used, in its order.
+	Header []string
+	// Policy for keys of the header missing in a map, default is MissingEmpty.
+	Missing MissingKeyPolicy
+	// Policy for keys of a map not in the header, default is ExtraError.
+	Extra ExtraKeyPolicy
+}
+
+// Writer writes ordered maps as records of a CSV file, see NewWriter.
+type Writer struct {
+	// The underlying CSV writer, can be configured before the first write.
+	CSV           *csv.Writer
+	opts          WriterOptions
+	headerWritten bool
+}
+
+// Create a new Writer into w. The header is written along with the first map (see WriterOptions),
+// and every map written is converted to a record by matching its keys with the header columns.
+// Repeated keys (of an omultimap.OMultiMap) are matched to repeated columns in order.
+// As encoding/csv, writes are buffered, so Flush must be called at the end.
+func NewWriter(w io.Writer, opts ...WriterOptions) *Writer {
+	ret := &Writer{CSV: csv.NewWriter(w)}
+	if len(opts) > 0 {
+		ret.opts = opts[0]
+		ret.opts.Header = append([]string(nil), opts[0].Header...)
+	}
+	return ret
+}
+
+// Return the header, nil if not defined yet (before the first write with no header in options).
+func (w *Writer) Header() []string {
+	if len(w.opts.Header) == 0 {
+		return nil
+	}
+	return w.opts.Header
+}
+
+// Write the content of the given iterator (from an omap.OMap or omultimap.OMultiMap) as a record,
+// writing the header before if not written yet. If the header is taken from this map (see
+// WriterOptions) and it is empty, ErrEmptyHeader is returned and nothing is written.
+// Note: the iterator will be at EOF after this function returns with success.
+func (w *Writer) Write(it omap.OMapIterator[string, string]) error {
+	values := make(map[string][]string)
+	keys := make([]string, 0)
+	for it.Next() {
+		values[it.Key()] = append(values[it.Key()], it.Value())
+		keys = append(keys, it.Key())
+	}
+	if len(w.opts.Header) == 0 {
+		if len(keys) == 0 {
+			// nothing is written, so the header can still be taken from the next map
+			return ErrEmptyHeader
+		}
+		w.opts.Header = keys
+	}
+	record := make([]string, len(w.opts.Header))
+	for i, name := range w.opts.Header {
+		if list := values[name]; len(list) > 0 {
+			record[i] = list[0]
+			values[name] = list[1:]
+		} else if w.opts.Missing == MissingError {
+			return fmt.Errorf("%w: %q", ErrMissingKey, name)
+		}
+	}
+	if w.opts.Extra == ExtraError {
+		for _, key := range keys {
+			if len(values[key]) > 0 {
+				return fmt.Errorf("%w: %q", ErrExtraKey, key)
+			}
+		}
+	}
+	if !w.headerWritten {
+		if err := w.CSV.Write(w.opts.Header); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	return w.CSV.Write(record)
+}
+
+// Flush any buffered data to the underlying io.Writer, returning any error found by previous
+// writes or the flush.
+func (w *Writer) Flush() error {
+	w.CSV.Flush()
+	return w.CSV.Error()
+}
@@ -0,0 +1,192 @@
+package csv_test
+
+import (
+	"bytes"
+	stdcsv "encoding/csv"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omap/csv"
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+)
+
+func TestReader(t *testing.T) {
+	r := csv.NewReader(strings.NewReader("z,a,m\n1,2,3\n\"x,y\",,\"q\"\"\"\n"))
+	rows, err := r.ReadAll()
+	th.AssertErrNil(t, err, "unexpected error on ReadAll")
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, found %d", len(rows))
+	}
+	th.ValidateIterator(t, rows[0].Iterator(), true, []th.KeyValue[string, string]{{Key: "z", Value: "1"}, {Key: "a", Value: "2"}, {Key: "m", Value: "3"}})
+	th.ValidateIterator(t, rows[1].Iterator(), true, []th.KeyValue[string, string]{{Key: "z", Value: "x,y"}, {Key: "a", Value: ""}, {Key: "m", Value: "q\""}})
+	header, err := r.Header()
+	th.AssertErrNil(t, err, "unexpected error on Header")
+	if !reflect.DeepEqual(header, []string{"z", "a", "m"}) {
+		t.Errorf("unexpected header %v", header)
+	}
+	_, err = r.Read()
+	th.AssertErrIs(t, err, io.EOF, "expected EOF")
+	// configured underlying reader
+	r = csv.NewReader(strings.NewReader("# comment\nb;a\n1;2\n"))
+	r.CSV.Comma = ';'
+	r.CSV.Comment = '#'
+	m, err := r.Read()
+	th.AssertErrNil(t, err, "unexpected error on Read")
+	th.ValidateIterator(t, m.Iterator(), true, []th.KeyValue[string, string]{{Key: "b", Value: "1"}, {Key: "a", Value: "2"}})
+}
+
+func TestReaderDuplicateHeader(t *testing.T) {
+	input := "a,b,a\n1,2,3\n4,5,6\n"
+	r := csv.NewReader(strings.NewReader(input))
+	_, err := r.Read()
+	th.AssertErrIs(t, err, csv.ErrDuplicateHeader, "expected ErrDuplicateHeader")
+	_, err = csv.NewReader(strings.NewReader(input)).ReadAll()
+	th.AssertErrIs(t, err, csv.ErrDuplicateHeader, "expected ErrDuplicateHeader on ReadAll")
+	r = csv.NewReader(strings.NewReader(input))
+	rows, err := r.ReadAllMulti()
+	th.AssertErrNil(t, err, "unexpected error on ReadAllMulti")
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, found %d", len(rows))
+	}
+	th.ValidateIterator(t, rows[1].Iterator(), true, []th.KeyValue[string, string]{{Key: "a", Value: "4"}, {Key: "b", Value: "5"}, {Key: "a", Value: "6"}})
+	if values := omap.IteratorValuesToSlice(rows[0].GetValuesOf("a")); !reflect.DeepEqual(values, []string{"1", "3"}) {
+		t.Errorf("expected [1 3], found %v", values)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	// empty input, no header
+	_, err := csv.NewReader(strings.NewReader("")).Read()
+	th.AssertErrIs(t, err, io.EOF, "expected EOF")
+	_, err = csv.NewReader(strings.NewReader("")).ReadMulti()
+	th.AssertErrIs(t, err, io.EOF, "expected EOF")
+	// wrong number of fields
+	for _, fieldsPerRecord := range []int{0, -1} {
+		r := csv.NewReader(strings.NewReader("a,b\n1,2\n3\n"))
+		r.CSV.FieldsPerRecord = fieldsPerRecord
+		rows, err := r.ReadAll()
+		th.AssertErrIs(t, err, stdcsv.ErrFieldCount, "expected ErrFieldCount")
+		if len(rows) != 1 {
+			t.Errorf("expected 1 row before error, found %d", len(rows))
+		}
+		r = csv.NewReader(strings.NewReader("a,b\n1,2,3\n"))
+		r.CSV.FieldsPerRecord = fieldsPerRecord
+		_, err = r.ReadAllMulti()
+		th.AssertErrNotNil(t, err, "expected error with wrong number of fields")
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if w.Header() != nil {
+		t.Errorf("expected nil header before first write, found %v", w.Header())
+	}
+	m := omap.New[string, string]()
+	m.Put("z", "1")
+	m.Put("a", "x,y")
+	th.AssertErrNil(t, w.Write(m.Iterator()), "unexpected error on Write")
+	// different order, missing key
+	m = omap.New[string, string]()
+	m.Put("a", "2")
+	th.AssertErrNil(t, w.Write(m.Iterator()), "unexpected error on Write")
+	th.AssertErrNil(t, w.Flush(), "unexpected error on Flush")
+	if exp := "z,a\n1,\"x,y\"\n,2\n"; buf.String() != exp {
+		t.Errorf("expected %q, found %q", exp, buf.String())
+	}
+	if !reflect.DeepEqual(w.Header(), []string{"z", "a"}) {
+		t.Errorf("unexpected header %v", w.Header())
+	}
+	// round-trip
+	rows, err := csv.NewReader(&buf).ReadAll()
+	th.AssertErrNil(t, err, "unexpected error on ReadAll")
+	th.ValidateIterator(t, rows[1].Iterator(), true, []th.KeyValue[string, string]{{Key: "z", Value: ""}, {Key: "a", Value: "2"}})
+}
+
+func TestWriterMulti(t *testing.T) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.CSV.Comma = ';'
+	mm := omultimap.New[string, string]()
+	mm.Put("a", "1", "3")
+	mm.Put("b", "2")
+	th.AssertErrNil(t, w.Write(mm.Iterator()), "unexpected error on Write")
+	// repeated keys matched in order
+	mm = omultimap.New[string, string]()
+	mm.Put("b", "5")
+	mm.Put("a", "4", "6")
+	th.AssertErrNil(t, w.Write(mm.Iterator()), "unexpected error on Write")
+	th.AssertErrNil(t, w.Flush(), "unexpected error on Flush")
+	if exp := "a;a;b\n1;3;2\n4;6;5\n"; buf.String() != exp {
+		t.Errorf("expected %q, found %q", exp, buf.String())
+	}
+}
+
+func TestWriterPolicies(t *testing.T) {
+	var buf bytes.Buffer
+	header := []string{"a", "b"}
+	w := csv.NewWriter(&buf, csv.WriterOptions{Header: header, Missing: csv.MissingError, Extra: csv.ExtraError})
+	header[0] = "changed" // options are copied
+	m := omap.New[string, string]()
+	m.Put("a", "1")
+	th.AssertErrIs(t, w.Write(m.Iterator()), csv.ErrMissingKey, "expected ErrMissingKey")
+	m.Put("b", "2")
+	m.Put("c", "3")
+	th.AssertErrIs(t, w.Write(m.Iterator()), csv.ErrExtraKey, "expected ErrExtraKey")
+	mm := omultimap.New[string, string]()
+	mm.Put("a", "1", "2")
+	mm.Put("b", "3")
+	th.AssertErrIs(t, w.Write(mm.Iterator()), csv.ErrExtraKey, "expected ErrExtraKey with repeated key")
+	th.AssertErrNil(t, w.Flush(), "unexpected error on Flush")
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing written on errors, found %q", buf.String())
+	}
+	w = csv.NewWriter(&buf, csv.WriterOptions{Header: []string{"b", "a"}, Extra: csv.ExtraIgnore})
+	th.AssertErrNil(t, w.Write(m.Iterator()), "unexpected error on Write")
+	th.AssertErrNil(t, w.Flush(), "unexpected error on Flush")
+	if exp := "b,a\n2,1\n"; buf.String() != exp {
+		t.Errorf("expected %q, found %q", exp, buf.String())
+	}
+}
+
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestWriterErrors(t *testing.T) {
+	w := csv.NewWriter(failWriter{})
+	m := omap.New[string, string]()
+	m.Put("a", "1")
+	th.AssertErrNil(t, w.Write(m.Iterator()), "unexpected error on buffered Write")
+	th.AssertErrNotNil(t, w.Flush(), "expected error on Flush")
+	// invalid separator
+	w = csv.NewWriter(io.Discard)
+	w.CSV.Comma = '"'
+	th.AssertErrNotNil(t, w.Write(m.Iterator()), "expected error writing header with invalid separator")
+	// empty first map, header taken from the next one
+	var buf bytes.Buffer
+	w = csv.NewWriter(&buf)
+	th.AssertErrIs(t, w.Write(omap.New[string, string]().Iterator()), csv.ErrEmptyHeader, "expected error with empty first map")
+	if h := w.Header(); h != nil {
+		t.Errorf("expected no header after empty map, found %q", h)
+	}
+	m.Put("b", "2")
+	th.AssertErrNil(t, w.Write(m.Iterator()), "unexpected error on Write")
+	th.AssertErrNil(t, w.Flush(), "unexpected error on Flush")
+	if exp := "a,b\n1,2\n"; buf.String() != exp {
+		t.Errorf("expected %q, found %q", exp, buf.String())
+	}
+	// empty maps are fine once the header is known
+	th.AssertErrNil(t, w.Write(omap.New[string, string]().Iterator()), "unexpected error writing empty map")
+	th.AssertErrNil(t, w.Flush(), "unexpected error on Flush")
+	if exp := "a,b\n1,2\n,\n"; buf.String() != exp {
+		t.Errorf("expected %q, found %q", exp, buf.String())
+	}
+}
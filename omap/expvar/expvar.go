@@ -0,0 +1,148 @@
+// expvar package provides an expvar.Var holding other variables in insertion order, so the JSON
+// published by expvar (e.g. at /debug/vars) keeps related variables together in the order they
+// were declared, instead of sorted by name as with expvar.Map.
+package expvar
+
+import (
+	"encoding/json"
+	"expvar"
+	"strings"
+	"sync"
+
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+// Map is a string-to-Var map variable that satisfies the expvar.Var interface, with the same
+// methods and semantics of expvar.Map, but keeping the insertion order of the keys. It is safe for
+// concurrent use, and the zero value is an empty Map ready to use.
+type Map struct {
+	// guards vars itself (replaced by Init) and its content, so it is an omap.OMapLinked and not
+	// an omap.OMapSync, whose own lock could not prevent a change from reaching a replaced map
+	mx   sync.RWMutex
+	vars omap.OMap[string, expvar.Var]
+}
+
+// Create a new Map and publish it with the given name (see expvar.Publish, that panics if the
+// name is already registered).
+func NewMap(name string) *Map {
+	v := new(Map).Init()
+	expvar.Publish(name, v)
+	return v
+}
+
+// Remove all keys from the map.
+func (v *Map) Init() *Map {
+	v.mx.Lock()
+	defer v.mx.Unlock()
+	v.vars = omap.New[string, expvar.Var]()
+	return v
+}
+
+// Return the map to be changed, creating it if not initialized yet. Must be called with mx locked.
+func (v *Map) lockedVars() omap.OMap[string, expvar.Var] {
+	if v.vars == nil {
+		v.vars = omap.New[string, expvar.Var]()
+	}
+	return v.vars
+}
+
+// Implement expvar.Var interface, returning a JSON object with the keys in insertion order.
+func (v *Map) String() string {
+	var b strings.Builder
+	b.WriteString("{")
+	first := true
+	v.Do(func(kv expvar.KeyValue) {
+		if !first {
+			b.WriteString(", ")
+		}
+		first = false
+		key, _ := json.Marshal(kv.Key)
+		b.Write(key)
+		b.WriteString(": ")
+		b.WriteString(kv.Value.String())
+	})
+	b.WriteString("}")
+	return b.String()
+}
+
+// Return the variable of the given key, or nil if not found.
+func (v *Map) Get(key string) expvar.Var {
+	v.mx.RLock()
+	defer v.mx.RUnlock()
+	if v.vars == nil {
+		return nil
+	}
+	value, _ := v.vars.Get(key)
+	return value
+}
+
+// Set the variable of the given key. An existing key keeps its position.
+func (v *Map) Set(key string, av expvar.Var) {
+	v.mx.Lock()
+	defer v.mx.Unlock()
+	v.lockedVars().Put(key, av)
+}
+
+// Return the variable of the given key, adding the one returned by create at the end if not found.
+func (v *Map) getOrCreate(key string, create func() expvar.Var) expvar.Var {
+	if value := v.Get(key); value != nil {
+		return value
+	}
+	v.mx.Lock()
+	defer v.mx.Unlock()
+	m := v.lockedVars()
+	if value, ok := m.Get(key); ok {
+		return value
+	}
+	value := create()
+	m.Put(key, value)
+	return value
+}
+
+// Add delta to the *expvar.Int value of the given key, creating it if not found. Values of other
+// types are not changed.
+func (v *Map) Add(key string, delta int64) {
+	value := v.getOrCreate(key, func() expvar.Var { return new(expvar.Int) })
+	if iv, ok := value.(*expvar.Int); ok {
+		iv.Add(delta)
+	}
+}
+
+// Add delta to the *expvar.Float value of the given key, creating it if not found. Values of
+// other types are not changed.
+func (v *Map) AddFloat(key string, delta float64) {
+	value := v.getOrCreate(key, func() expvar.Var { return new(expvar.Float) })
+	if fv, ok := value.(*expvar.Float); ok {
+		fv.Add(delta)
+	}
+}
+
+// Delete the given key from the map.
+func (v *Map) Delete(key string) {
+	v.mx.Lock()
+	defer v.mx.Unlock()
+	v.lockedVars().Delete(key)
+}
+
+// Call f for each entry in the map, in insertion order. The entries are copied before calling f,
+// so the map may be changed concurrently (even by f) without affecting the iteration.
+func (v *Map) Do(f func(expvar.KeyValue)) {
+	for _, kv := range v.snapshot() {
+		f(kv)
+	}
+}
+
+// Return a copy of the entries, taken while holding the lock, so a concurrent Set never replaces
+// a value being read.
+func (v *Map) snapshot() []expvar.KeyValue {
+	v.mx.RLock()
+	defer v.mx.RUnlock()
+	if v.vars == nil {
+		return nil
+	}
+	ret := make([]expvar.KeyValue, 0, v.vars.Len())
+	for it := v.vars.Iterator(); it.Next(); {
+		ret = append(ret, expvar.KeyValue{Key: it.Key(), Value: it.Value()})
+	}
+	return ret
+}
@@ -0,0 +1,198 @@
+package expvar_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	ordexpvar "github.com/matheusoliveira/go-ordered-map/omap/expvar"
+)
+
+func TestMap(t *testing.T) {
+	v := ordexpvar.NewMap("test-ordered-map")
+	if expvar.Get("test-ordered-map") != v {
+		t.Fatalf("expected map published")
+	}
+	v.Add("requests", 1)
+	v.Add("requests", 2)
+	v.AddFloat("latency", 0.5)
+	v.AddFloat("latency", 0.25)
+	name := new(expvar.String)
+	name.Set("server \"1\"")
+	v.Set("name", name)
+	v.Set("alpha", new(expvar.Int))
+	nested := new(ordexpvar.Map)
+	nested.Add("z", 1)
+	nested.Add("a", 2)
+	v.Set("nested", nested)
+	exp := `{"requests": 3, "latency": 0.75, "name": "server \"1\"", "alpha": 0, "nested": {"z": 1, "a": 2}}`
+	if s := expvar.Get("test-ordered-map").String(); s != exp {
+		t.Errorf("expected %s, found %s", exp, s)
+	}
+	// valid JSON, in order
+	m := omap.New[string, any]()
+	th.AssertErrNil(t, json.Unmarshal([]byte(v.String()), &m), "unexpected error on Unmarshal")
+	keys := omap.IteratorKeysToSlice(m.Iterator())
+	if len(keys) != 5 || keys[0] != "requests" || keys[4] != "nested" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+	// type mismatch is ignored, as expvar.Map
+	v.Add("latency", 1)
+	v.AddFloat("requests", 1)
+	v.Add("name", 1)
+	if v.Get("requests").String() != "3" || v.Get("latency").String() != "0.75" {
+		t.Errorf("expected values unchanged, found %s", v.String())
+	}
+	// set keeps position, delete
+	v.Set("requests", new(expvar.Int))
+	v.Delete("latency")
+	v.Delete("missing")
+	if exp := `{"requests": 0, "name": "server \"1\"", "alpha": 0, "nested": {"z": 1, "a": 2}}`; v.String() != exp {
+		t.Errorf("expected %s, found %s", exp, v.String())
+	}
+	if v.Get("missing") != nil {
+		t.Errorf("expected nil for missing key")
+	}
+	v.Init()
+	if v.String() != "{}" {
+		t.Errorf("expected empty map after Init, found %s", v.String())
+	}
+}
+
+func TestMapZeroValue(t *testing.T) {
+	var v ordexpvar.Map
+	if v.String() != "{}" {
+		t.Errorf("expected empty map, found %s", v.String())
+	}
+	var keys []string
+	v.AddFloat("f", 1)
+	v.Add("i", 1)
+	v.Do(func(kv expvar.KeyValue) { keys = append(keys, kv.Key) })
+	if len(keys) != 2 || keys[0] != "f" || keys[1] != "i" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+}
+
+func TestMapConcurrent(t *testing.T) {
+	var v ordexpvar.Map
+	var wg sync.WaitGroup
+	keys := []string{"c", "b", "a"}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				v.Add(keys[j%len(keys)], 1)
+				v.AddFloat("f", 1)
+				_ = v.String()
+			}
+		}()
+	}
+	wg.Wait()
+	if v.Get("a").String() != "2664" || v.Get("c").String() != "2672" || v.Get("f").String() != "8000" {
+		t.Errorf("unexpected values %s", v.String())
+	}
+}
+
+func TestMapConcurrentSet(t *testing.T) {
+	var v ordexpvar.Map
+	keys := []string{"c", "b", "a"}
+	for _, k := range keys {
+		v.Set(k, new(expvar.Int))
+	}
+	// keep changing the map while String and Do are running, in parallel even on a single CPU
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	done := make(chan bool)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; ; j++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			v.Set(keys[j%len(keys)], new(expvar.Int))
+			if j%10 == 0 {
+				v.Delete(keys[j%len(keys)])
+			}
+		}
+	}()
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		for j := 0; j < 1000; j++ {
+			_ = v.String()
+		}
+	}()
+	go func() {
+		defer readers.Done()
+		for j := 0; j < 1000; j++ {
+			v.Do(func(kv expvar.KeyValue) {
+				_ = kv.Value.String()
+			})
+		}
+	}()
+	readers.Wait()
+	close(done)
+	wg.Wait()
+}
+
+func TestMapDoChanges(t *testing.T) {
+	var v ordexpvar.Map
+	for _, k := range []string{"c", "b", "a"} {
+		v.Set(k, new(expvar.Int))
+	}
+	// changes made by f don't affect the iteration
+	var visited []string
+	v.Do(func(kv expvar.KeyValue) {
+		visited = append(visited, kv.Key)
+		v.Set("new-"+kv.Key, new(expvar.Int))
+	})
+	if len(visited) != 3 || v.Get("new-a") == nil {
+		t.Errorf("expected 3 keys visited and new keys added, found %v and %s", visited, v.String())
+	}
+}
+
+func TestMapConcurrentInit(t *testing.T) {
+	var v ordexpvar.Map
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprint(i)
+			for j := 0; j < 1000; j++ {
+				switch j % 4 {
+				case 0:
+					v.Init()
+				case 1:
+					v.Set(key, new(expvar.Int))
+				case 2:
+					v.Add(key, 1)
+				default:
+					v.Delete(key)
+				}
+				_ = v.Get(key)
+				_ = v.String()
+			}
+		}(i)
+	}
+	wg.Wait()
+	// every change reaches the current map
+	v.Init()
+	v.Add("a", 1)
+	v.Set("b", new(expvar.Int))
+	v.Delete("a")
+	if s := v.String(); s != `{"b": 0}` {
+		t.Errorf("unexpected map %s", s)
+	}
+}
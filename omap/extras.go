@@ -0,0 +1,149 @@
+package omap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Extras is a catch-all for the JSON fields of a struct that have no matching struct field. Add it
+// as a field of the struct, tagged with `json:"-"`, and use MarshalWithExtras and
+// UnmarshalWithExtras instead of json.Marshal and json.Unmarshal: unknown fields are decoded into
+// the embedded OMap, and encoding writes them back along with the known fields, all of them in the
+// same order as originally decoded (the order of known and unknown fields interleaved included).
+//
+// Extras can also be changed through the embedded OMap: new keys are encoded after all the others,
+// and deleted keys are skipped. Keys that match a known field are ignored when encoding.
+type Extras struct {
+	OMap[string, json.RawMessage]
+	// all keys, known and extra ones, in the order they were decoded
+	order []string
+}
+
+var extrasType = reflect.TypeOf(Extras{})
+
+// Return the Extras field of the given struct value, which must be tagged with `json:"-"`.
+func extrasField(v any, rv reflect.Value) (reflect.Value, error) {
+	if rv.Kind() == reflect.Struct {
+		for i := 0; i < rv.NumField(); i++ {
+			sf := rv.Type().Field(i)
+			if sf.Type == extrasType && sf.Tag.Get("json") == "-" {
+				return rv.Field(i), nil
+			}
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("%w: expected a struct with an omap.Extras field tagged `json:\"-\"`, found %T", ErrOMap, v)
+}
+
+// Decode the JSON object at b into the struct pointed by v, as json.Unmarshal does, and the fields
+// with no matching struct field (following the same case-insensitive matching of encoding/json)
+// into its Extras field, see Extras.
+func UnmarshalWithExtras(b []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("%w: expected a non-nil pointer to struct, found %T", ErrOMap, v)
+	}
+	field, err := extrasField(v, rv.Elem())
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return err
+	}
+	all := New[string, json.RawMessage]()
+	if err := UnmarshalJSON(all.Put, b); err != nil {
+		return err
+	}
+	fields := structFields(rv.Elem().Type(), "json")
+	extras := Extras{OMap: New[string, json.RawMessage](), order: make([]string, 0, all.Len())}
+	for it := all.Iterator(); it.Next(); {
+		extras.order = append(extras.order, it.Key())
+		if knownField(fields, it.Key()) == "" {
+			extras.Put(it.Key(), it.Value())
+		}
+	}
+	field.Set(reflect.ValueOf(extras))
+	return nil
+}
+
+// Return the name of the field matching key, or "" if none.
+func knownField(fields []structField, key string) string {
+	found := ""
+	for _, f := range fields {
+		if f.name == key {
+			return f.name
+		} else if found == "" && strings.EqualFold(f.name, key) {
+			found = f.name
+		}
+	}
+	return found
+}
+
+// Encode the given struct v (or pointer to struct) as json.Marshal does, adding the fields of its
+// Extras field and keeping the order of the fields as originally decoded by UnmarshalWithExtras.
+// Known fields never decoded (e.g. omitted because empty) are written in the struct order, after
+// the ones decoded, followed by the extras added after decoding.
+//
+// Note: do not call this function from the MarshalJSON method of v itself, as it uses
+// json.Marshal(v) to encode the known fields (use a type definition without the method instead).
+func MarshalWithExtras(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	field, err := extrasField(v, rv)
+	if err != nil {
+		return nil, err
+	}
+	extras := field.Interface().(Extras)
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	known := New[string, json.RawMessage]()
+	if err := UnmarshalJSON(known.Put, b); err != nil {
+		return nil, err
+	}
+	fields := structFields(rv.Type(), "json")
+	written := make(map[string]bool, known.Len())
+	var w bytes.Buffer
+	w.WriteString("{")
+	write := func(key string, value json.RawMessage) {
+		if written[key] {
+			return
+		}
+		written[key] = true
+		if w.Len() > 1 {
+			w.WriteString(",")
+		}
+		keyJSON, _ := json.Marshal(key)
+		w.Write(keyJSON)
+		w.WriteString(":")
+		w.Write(value)
+	}
+	for _, key := range extras.order {
+		if name := knownField(fields, key); name != "" {
+			if value, ok := known.Get(name); ok {
+				write(name, value)
+			}
+		} else if extras.OMap != nil {
+			if value, ok := extras.Get(key); ok {
+				write(key, value)
+			}
+		}
+	}
+	for it := known.Iterator(); it.Next(); {
+		write(it.Key(), it.Value())
+	}
+	if extras.OMap != nil {
+		for it := extras.Iterator(); it.Next(); {
+			if knownField(fields, it.Key()) == "" {
+				write(it.Key(), it.Value())
+			}
+		}
+	}
+	w.WriteString("}")
+	return w.Bytes(), nil
+}
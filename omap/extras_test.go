@@ -0,0 +1,104 @@
+package omap_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+type extrasBase struct {
+	Kind string `json:"kind"`
+}
+
+type extrasDoc struct {
+	extrasBase
+	ID     int         `json:"id"`
+	Name   string      `json:"name,omitempty"`
+	Tags   []string    `json:"tags,omitempty"`
+	Extras omap.Extras `json:"-"`
+}
+
+func TestExtrasRoundTrip(t *testing.T) {
+	input := `{"z":1,"ID":7,"kind":"k","nested":{"b":1,"a":[2,3]},"name":"n","a":null}`
+	var doc extrasDoc
+	th.AssertErrNil(t, omap.UnmarshalWithExtras([]byte(input), &doc), "unexpected error on UnmarshalWithExtras")
+	if doc.ID != 7 || doc.Kind != "k" || doc.Name != "n" {
+		t.Errorf("known fields not decoded: %+v", doc)
+	}
+	keys := omap.IteratorKeysToSlice(doc.Extras.Iterator())
+	if len(keys) != 3 || keys[0] != "z" || keys[1] != "nested" || keys[2] != "a" {
+		t.Errorf("expected extras [z nested a], found %v", keys)
+	}
+	if v, _ := doc.Extras.Get("nested"); string(v) != `{"b":1,"a":[2,3]}` {
+		t.Errorf("unexpected raw value for nested: %s", v)
+	}
+	// re-encode, with the same order (known keys use their canonical name)
+	res, err := omap.MarshalWithExtras(doc)
+	th.AssertErrNil(t, err, "unexpected error on MarshalWithExtras")
+	exp := `{"z":1,"id":7,"kind":"k","nested":{"b":1,"a":[2,3]},"name":"n","a":null}`
+	if string(res) != exp {
+		t.Errorf("expected %s, found %s", exp, res)
+	}
+	// changes: new known field, deleted and added extras
+	doc.Tags = []string{"t"}
+	doc.Extras.Delete("nested")
+	doc.Extras.Put("new", json.RawMessage(`"v"`))
+	doc.Extras.Put("id", json.RawMessage(`0`)) // ignored, conflicts with known field
+	doc.Name = ""                              // omitted
+	res, err = omap.MarshalWithExtras(&doc)
+	th.AssertErrNil(t, err, "unexpected error on MarshalWithExtras")
+	exp = `{"z":1,"id":7,"kind":"k","a":null,"tags":["t"],"new":"v"}`
+	if string(res) != exp {
+		t.Errorf("expected %s, found %s", exp, res)
+	}
+}
+
+func TestExtrasNotDecoded(t *testing.T) {
+	// never decoded, the struct order is used and extras go at the end
+	doc := extrasDoc{ID: 1, extrasBase: extrasBase{Kind: "k"}}
+	res, err := omap.MarshalWithExtras(doc)
+	th.AssertErrNil(t, err, "unexpected error on MarshalWithExtras")
+	if exp := `{"kind":"k","id":1}`; string(res) != exp {
+		t.Errorf("expected %s, found %s", exp, res)
+	}
+	doc.Extras.OMap = omap.New[string, json.RawMessage]()
+	doc.Extras.Put("x", json.RawMessage(`[]`))
+	res, err = omap.MarshalWithExtras(doc)
+	th.AssertErrNil(t, err, "unexpected error on MarshalWithExtras")
+	if exp := `{"kind":"k","id":1,"x":[]}`; string(res) != exp {
+		t.Errorf("expected %s, found %s", exp, res)
+	}
+	// decoding again resets previous extras
+	th.AssertErrNil(t, omap.UnmarshalWithExtras([]byte(`{"y":true}`), &doc), "unexpected error on UnmarshalWithExtras")
+	if keys := omap.IteratorKeysToSlice(doc.Extras.Iterator()); len(keys) != 1 || keys[0] != "y" {
+		t.Errorf("expected extras [y], found %v", keys)
+	}
+}
+
+func TestExtrasErrors(t *testing.T) {
+	var doc extrasDoc
+	th.AssertErrIs(t, omap.UnmarshalWithExtras([]byte(`{}`), doc), omap.ErrOMap, "expected error with non-pointer")
+	th.AssertErrIs(t, omap.UnmarshalWithExtras([]byte(`{}`), (*extrasDoc)(nil)), omap.ErrOMap, "expected error with nil pointer")
+	th.AssertErrNotNil(t, omap.UnmarshalWithExtras([]byte(`{"id":"x"}`), &doc), "expected error with invalid field type")
+	th.AssertErrNotNil(t, omap.UnmarshalWithExtras([]byte(`[1]`), &doc), "expected error with non-object")
+	th.AssertErrNotNil(t, omap.UnmarshalWithExtras([]byte(`null`), &doc), "expected error with null")
+	untagged := struct {
+		A      int
+		Extras omap.Extras
+	}{}
+	th.AssertErrIs(t, omap.UnmarshalWithExtras([]byte(`{}`), &untagged), omap.ErrOMap, "expected error with untagged Extras")
+	var i int
+	th.AssertErrIs(t, omap.UnmarshalWithExtras([]byte(`{}`), &i), omap.ErrOMap, "expected error with non-struct")
+	_, err := omap.MarshalWithExtras(untagged)
+	th.AssertErrIs(t, err, omap.ErrOMap, "expected error with untagged Extras")
+	_, err = omap.MarshalWithExtras(nil)
+	th.AssertErrIs(t, err, omap.ErrOMap, "expected error with nil")
+	invalid := struct {
+		C      chan int
+		Extras omap.Extras `json:"-"`
+	}{}
+	_, err = omap.MarshalWithExtras(invalid)
+	th.AssertErrNotNil(t, err, "expected error with unsupported type")
+}
@@ -0,0 +1,159 @@
+package omap
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// What to do when a key is given again to a flag.Value, see FlagOptions.
+type DuplicatePolicy int
+
+const (
+	// The last value given is used, the key keeps its first position.
+	DuplicateOverwrite DuplicatePolicy = iota
+	// The first value given is used, others are ignored.
+	DuplicateKeepFirst
+	// Return an error wrapping ErrDuplicateKey.
+	DuplicateError
+)
+
+// Options of how command-line flags are parsed into key/values, see FlagValue and ParseFlagArg.
+type FlagOptions struct {
+	// Separator between a key and its value, "=" if empty.
+	Separator string
+	// Separator of multiple key/values in a single argument (e.g. "," for "-labels a=1,b=2"), if
+	// empty each argument is a single key/value.
+	ListSeparator string
+	// Trim spaces around keys and values (e.g. for "-header 'Name: value'" with ":" separator).
+	TrimSpace bool
+	// What to do with repeated keys, ignored for omultimap.
+	Duplicate DuplicatePolicy
+}
+
+// Parse the command-line argument s as key/values according to opts, calling putFunc with each
+// one. It returns an error wrapping ErrInvalidFlag, without calling putFunc, if any key/value
+// has no separator or an empty key.
+func ParseFlagArg(putFunc func(string, string), s string, opts FlagOptions) error {
+	sep := opts.Separator
+	if sep == "" {
+		sep = "="
+	}
+	items := []string{s}
+	if opts.ListSeparator != "" {
+		items = strings.Split(s, opts.ListSeparator)
+	}
+	keys := make([]string, len(items))
+	values := make([]string, len(items))
+	for i, item := range items {
+		key, value, found := strings.Cut(item, sep)
+		if opts.TrimSpace {
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		}
+		if !found || key == "" {
+			return fmt.Errorf("%w: expected key%svalue, found %q", ErrInvalidFlag, sep, item)
+		}
+		keys[i], values[i] = key, value
+	}
+	for i := range keys {
+		putFunc(keys[i], values[i])
+	}
+	return nil
+}
+
+// Format the key/values of the given iterator as command-line argument(s), the reverse of
+// ParseFlagArg (multiple key/values are separated by ListSeparator, or "," if empty).
+// Note: the iterator will be at EOF after this function returns.
+func FlagArgString(it OMapIterator[string, string], opts FlagOptions) string {
+	sep, listSep := opts.Separator, opts.ListSeparator
+	if sep == "" {
+		sep = "="
+	}
+	if listSep == "" {
+		listSep = ","
+	}
+	var b strings.Builder
+	for first := true; it.Next(); first = false {
+		if !first {
+			b.WriteString(listSep)
+		}
+		b.WriteString(it.Key())
+		b.WriteString(sep)
+		b.WriteString(it.Value())
+	}
+	return b.String()
+}
+
+// FlagValue is a flag.Value that parses each occurrence of a command-line flag
+// as key/values (e.g. "-label a=1 -label b=2") into an ordered map, keeping the order given.
+// The zero value is ready to use (with default options), see also NewFlagValue.
+type FlagValue struct {
+	// The map holding the key/values, created by the first Set if nil.
+	OMap[string, string]
+	Options FlagOptions
+}
+
+// Create a new FlagValue with the given options.
+func NewFlagValue(opts FlagOptions) *FlagValue {
+	return &FlagValue{OMap: New[string, string](), Options: opts}
+}
+
+// Implement flag.Value interface, see FlagArgString.
+func (v *FlagValue) String() string {
+	if v == nil || v.OMap == nil {
+		return ""
+	}
+	return FlagArgString(v.Iterator(), v.Options)
+}
+
+// Implement flag.Value interface, parsing s with ParseFlagArg. Repeated keys are handled according
+// to the Duplicate option, in case of error the map is not changed.
+func (v *FlagValue) Set(s string) error {
+	var keys, values []string
+	err := ParseFlagArg(func(key, value string) {
+		keys = append(keys, key)
+		values = append(values, value)
+	}, s, v.Options)
+	if err != nil {
+		return err
+	}
+	if v.OMap == nil {
+		v.OMap = New[string, string]()
+	}
+	if v.Options.Duplicate == DuplicateError {
+		seen := make(map[string]bool, len(keys))
+		for _, key := range keys {
+			if _, ok := v.OMap.Get(key); ok || seen[key] {
+				return fmt.Errorf("%w: %q", ErrDuplicateKey, key)
+			}
+			seen[key] = true
+		}
+	}
+	for i, key := range keys {
+		if _, ok := v.OMap.Get(key); ok && v.Options.Duplicate == DuplicateKeepFirst {
+			continue
+		}
+		v.OMap.Put(key, values[i])
+	}
+	return nil
+}
+
+// Return the environment variables starting with the given prefix, with the prefix removed from
+// the keys, in the same order as returned by os.Environ.
+func FromEnv(prefix string) OMap[string, string] {
+	return FromEnviron(os.Environ(), prefix)
+}
+
+// Same as FromEnv, but from the given list of "key=value" strings (as returned by os.Environ or
+// exec.Cmd.Environ). Repeated keys keep the position of the first one and the value of the last
+// one, and keys equal to the prefix are ignored.
+func FromEnviron(environ []string, prefix string) OMap[string, string] {
+	m := New[string, string]()
+	for _, kv := range environ {
+		key, value, found := strings.Cut(kv, "=")
+		if found && len(key) > len(prefix) && strings.HasPrefix(key, prefix) {
+			m.Put(strings.TrimPrefix(key, prefix), value)
+		}
+	}
+	return m
+}
@@ -0,0 +1,83 @@
+package omap_test
+
+import (
+	"flag"
+	"io"
+	"os"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+func TestFlagValue(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var labels omap.FlagValue
+	headers := omap.NewFlagValue(omap.FlagOptions{Separator: ":", TrimSpace: true})
+	fs.Var(&labels, "label", "labels")
+	fs.Var(headers, "header", "headers")
+	err := fs.Parse([]string{"-label", "z=1", "-header", "Accept : text/plain", "-label", "a=x=y", "-label", "z=2", "-header", "Host:h"})
+	th.AssertErrNil(t, err, "unexpected error on Parse")
+	th.ValidateIterator(t, labels.Iterator(), true, []th.KeyValue[string, string]{{Key: "z", Value: "2"}, {Key: "a", Value: "x=y"}})
+	th.ValidateIterator(t, headers.Iterator(), true, []th.KeyValue[string, string]{{Key: "Accept", Value: "text/plain"}, {Key: "Host", Value: "h"}})
+	if s := labels.String(); s != "z=2,a=x=y" {
+		t.Errorf("unexpected String() %q", s)
+	}
+	if s := headers.String(); s != "Accept:text/plain,Host:h" {
+		t.Errorf("unexpected String() %q", s)
+	}
+	// invalid values
+	for _, arg := range []string{"novalue", "=value", ""} {
+		th.AssertErrIs(t, labels.Set(arg), omap.ErrInvalidFlag, "expected ErrInvalidFlag with "+arg)
+	}
+	th.AssertErrIs(t, headers.Set("  : x"), omap.ErrInvalidFlag, "expected ErrInvalidFlag with empty trimmed key")
+	th.AssertErrNotNil(t, fs.Parse([]string{"-label", "invalid"}), "expected error from flag.Parse")
+	if labels.Len() != 2 {
+		t.Errorf("expected map unchanged on errors, found %v", labels.OMap)
+	}
+	// zero value, as used by flag.PrintDefaults
+	var zero *omap.FlagValue
+	if zero.String() != "" || (&omap.FlagValue{}).String() != "" {
+		t.Errorf("expected empty String() of zero values")
+	}
+}
+
+func TestFlagValueOptions(t *testing.T) {
+	v := omap.NewFlagValue(omap.FlagOptions{ListSeparator: ";", Duplicate: omap.DuplicateKeepFirst})
+	th.AssertErrNil(t, v.Set("b=1;a=2;b=3"), "unexpected error on Set")
+	th.AssertErrNil(t, v.Set("a=4"), "unexpected error on Set")
+	th.ValidateIterator(t, v.Iterator(), true, []th.KeyValue[string, string]{{Key: "b", Value: "1"}, {Key: "a", Value: "2"}})
+	if s := v.String(); s != "b=1;a=2" {
+		t.Errorf("unexpected String() %q", s)
+	}
+	th.AssertErrIs(t, v.Set("c=1;invalid"), omap.ErrInvalidFlag, "expected ErrInvalidFlag")
+	if _, ok := v.Get("c"); ok {
+		t.Errorf("expected no change on error")
+	}
+	v = omap.NewFlagValue(omap.FlagOptions{ListSeparator: ",", Duplicate: omap.DuplicateError})
+	th.AssertErrNil(t, v.Set("a=1"), "unexpected error on Set")
+	th.AssertErrIs(t, v.Set("b=1,a=2"), omap.ErrDuplicateKey, "expected ErrDuplicateKey with existing key")
+	th.AssertErrIs(t, v.Set("c=1,c=2"), omap.ErrDuplicateKey, "expected ErrDuplicateKey with key repeated in argument")
+	th.AssertErrIs(t, v.Set("c=1,c=2"), omap.ErrOMap, "expected ErrOMap")
+	th.ValidateIterator(t, v.Iterator(), true, []th.KeyValue[string, string]{{Key: "a", Value: "1"}})
+	// ParseFlagArg directly
+	m := omap.New[string, string]()
+	th.AssertErrNil(t, omap.ParseFlagArg(m.Put, " k = v ", omap.FlagOptions{}), "unexpected error on ParseFlagArg")
+	th.ValidateIterator(t, m.Iterator(), true, []th.KeyValue[string, string]{{Key: " k ", Value: " v "}})
+}
+
+func TestFromEnv(t *testing.T) {
+	environ := []string{"APP_Z=1", "OTHER=x", "APP_A=a=b", "APP_=ignored", "APP_Z=2", "invalid", "APP_EMPTY="}
+	m := omap.FromEnviron(environ, "APP_")
+	th.ValidateIterator(t, m.Iterator(), true, []th.KeyValue[string, string]{{Key: "Z", Value: "2"}, {Key: "A", Value: "a=b"}, {Key: "EMPTY", Value: ""}})
+	t.Setenv("OMAP_TEST_ENV_B", "b")
+	t.Setenv("OMAP_TEST_ENV_A", "a")
+	m = omap.FromEnv("OMAP_TEST_ENV_")
+	if m.Len() != 2 {
+		t.Errorf("expected 2 variables, found %v", m)
+	}
+	if v, _ := m.Get("A"); v != os.Getenv("OMAP_TEST_ENV_A") {
+		t.Errorf("unexpected value %q", v)
+	}
+}
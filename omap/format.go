@@ -0,0 +1,207 @@
+package omap
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/matheusoliveira/go-ordered-map/internal/gotype"
+)
+
+// Maximum number of entries printed by the fmt.Formatter implementations of the maps, when not
+// given by the precision of the verb (e.g. "%.10v"). Zero or negative means no limit.
+// Truncated maps end with "...", and "%#v" is never truncated.
+var FormatMaxEntries = 0
+
+// Format the key/values of the given iterator, from the given position, as fmt would format a
+// builtin map, but in iteration order and prefixed by typeName.
+//
+// The verb and flags are applied to each key and value (e.g. "%q" quotes all of them, "%x" prints
+// integers in hex), except "%s" that formats them as "%v" (same as IteratorToString) and "%+v"
+// that quotes the keys that are strings. The precision is the
+// maximum number of entries printed (see FormatMaxEntries), and the width pads the whole output.
+// The "%#v" format is not handled here, as it depends on the type of the map, see
+// IteratorToGoString.
+//
+// This is a handy function to implements fmt.Formatter interface.
+// Note: the iterator will be at EOF after this function returns, unless truncated.
+func IteratorFormat[K comparable, V any](f fmt.State, verb rune, typeName string, it OMapIterator[K, V]) {
+	limit, ok := f.Precision()
+	if !ok {
+		limit = FormatMaxEntries
+		if limit <= 0 {
+			limit = -1
+		}
+	}
+	flags := ""
+	for _, flag := range "+# " {
+		if f.Flag(int(flag)) {
+			flags += string(flag)
+		}
+	}
+	if verb == 's' {
+		// as String()
+		verb = 'v'
+	}
+	elemFormat := "%" + flags + string(verb)
+	quoteKeys := verb == 'v' && f.Flag('+')
+	var b strings.Builder
+	b.WriteString(typeName)
+	b.WriteString("[")
+	for n := 0; it.Next(); n++ {
+		if n > 0 {
+			b.WriteString(" ")
+		}
+		if n == limit {
+			b.WriteString("...")
+			break
+		}
+		if s, ok := any(it.Key()).(string); ok && quoteKeys {
+			b.WriteString(strconv.Quote(s))
+		} else {
+			fmt.Fprintf(&b, elemFormat, it.Key())
+		}
+		b.WriteString(":")
+		fmt.Fprintf(&b, elemFormat, it.Value())
+	}
+	b.WriteString("]")
+	s := b.String()
+	if width, ok := f.Width(); ok && width > utf8.RuneCountInString(s) {
+		padding := strings.Repeat(" ", width-utf8.RuneCountInString(s))
+		if f.Flag('-') {
+			s += padding
+		} else {
+			s = padding + s
+		}
+	}
+	io.WriteString(f, s)
+}
+
+// Iterate over the given iterator it, from the given position, and return a Go source expression
+// that reconstructs the map with the same key/values in the same order: a function literal
+// returning mapType that creates the map with the expression newExpr and puts every key/value,
+// formatted with "%#v".
+//
+// This is a handy function to implements fmt.GoStringer interface.
+// Note: the iterator will be at EOF after this function returns with success.
+func IteratorToGoString[K comparable, V any](mapType string, newExpr string, it OMapIterator[K, V]) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "func() %s { m := %s; ", mapType, newExpr)
+	for it.Next() {
+		b.WriteString("m.Put(")
+		writeGoValue(&b, it.Key())
+		b.WriteString(", ")
+		writeGoValue(&b, it.Value())
+		b.WriteString("); ")
+	}
+	b.WriteString("return m }()")
+	return b.String()
+}
+
+func writeGoValue(b *strings.Builder, v any) {
+	if v == nil {
+		// "%#v" gives "<nil>" for nil interfaces
+		b.WriteString("nil")
+	} else {
+		fmt.Fprintf(b, "%#v", v)
+	}
+}
+
+// Return the type parameters list of K and V, as in Go source (e.g. "[string, int]"), see
+// gotype.String for the types of other packages.
+func typeArgs[K comparable, V any]() string {
+	return "[" + gotype.String(reflect.TypeOf((*K)(nil)).Elem()) + ", " + gotype.String(reflect.TypeOf((*V)(nil)).Elem()) + "]"
+}
+
+// Implement fmt.Formatter interface, see IteratorFormat and GoString.
+func (m *OMapLinked[K, V]) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		io.WriteString(f, m.GoString())
+	} else {
+		IteratorFormat[K, V](f, verb, "omap.OMapLinked", m.Iterator())
+	}
+}
+
+// Implement fmt.GoStringer interface, see IteratorToGoString.
+func (m *OMapLinked[K, V]) GoString() string {
+	return IteratorToGoString[K, V]("omap.OMap"+typeArgs[K, V](), "omap.NewOMapLinked"+typeArgs[K, V]()+"()", m.Iterator())
+}
+
+// Implement fmt.Formatter interface, see IteratorFormat and GoString.
+func (m *OMapLinkedHash[K, V]) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		io.WriteString(f, m.GoString())
+	} else {
+		IteratorFormat[K, V](f, verb, "omap.OMapLinkedHash", m.Iterator())
+	}
+}
+
+// Implement fmt.GoStringer interface, see IteratorToGoString.
+func (m *OMapLinkedHash[K, V]) GoString() string {
+	return IteratorToGoString[K, V]("omap.OMap"+typeArgs[K, V](), "omap.NewOMapLinkedHash"+typeArgs[K, V]()+"()", m.Iterator())
+}
+
+// Implement fmt.Formatter interface, see IteratorFormat and GoString.
+func (m *OMapSimple[K, V]) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		io.WriteString(f, m.GoString())
+	} else {
+		IteratorFormat[K, V](f, verb, "omap.OMapSimple", m.Iterator())
+	}
+}
+
+// Implement fmt.GoStringer interface, see IteratorToGoString.
+func (m *OMapSimple[K, V]) GoString() string {
+	return IteratorToGoString[K, V]("omap.OMap"+typeArgs[K, V](), "omap.NewOMapSimple"+typeArgs[K, V]()+"()", m.Iterator())
+}
+
+// Implement fmt.Formatter interface, see IteratorFormat and GoString.
+func (m *OMapBuiltin[K, V]) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		io.WriteString(f, m.GoString())
+	} else {
+		IteratorFormat[K, V](f, verb, "omap.OMapBuiltin", m.Iterator())
+	}
+}
+
+// Implement fmt.GoStringer interface, see IteratorToGoString.
+func (m *OMapBuiltin[K, V]) GoString() string {
+	return IteratorToGoString[K, V]("omap.OMap"+typeArgs[K, V](), "omap.NewOMapBuiltin"+typeArgs[K, V]()+"()", m.Iterator())
+}
+
+// Implement fmt.Formatter interface, see IteratorFormat and GoString.
+func (m *OMapSync[K, V]) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		io.WriteString(f, m.GoString())
+		return
+	}
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	IteratorFormat[K, V](f, verb, "omap.OMapSync", m.om.Iterator())
+}
+
+// Implement fmt.GoStringer interface, see IteratorToGoString.
+func (m *OMapSync[K, V]) GoString() string {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return IteratorToGoString[K, V]("omap.OMap"+typeArgs[K, V](), "omap.NewOMapSync"+typeArgs[K, V]()+"()", m.om.Iterator())
+}
+
+// Implement fmt.Formatter interface, see IteratorFormat and GoString.
+func (m *OMapFolded[V]) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		io.WriteString(f, m.GoString())
+	} else {
+		IteratorFormat[string, V](f, verb, "omap.OMapFolded", m.Iterator())
+	}
+}
+
+// Implement fmt.GoStringer interface, see IteratorToGoString. The fold function cannot be
+// represented, so the expression uses the default one (strings.ToLower).
+func (m *OMapFolded[V]) GoString() string {
+	vType := gotype.String(reflect.TypeOf((*V)(nil)).Elem())
+	return IteratorToGoString[string, V]("omap.OMap"+typeArgs[string, V](), "omap.NewOMapFolded["+vType+"](nil)", m.Iterator())
+}
@@ -0,0 +1,114 @@
+package omap_test
+
+import (
+	"fmt"
+	"go/parser"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+func TestFormat(t *testing.T) {
+	constructors := map[string]string{
+		implBuiltin:    "omap.NewOMapBuiltin[string, int]()",
+		implSimple:     "omap.NewOMapSimple[string, int]()",
+		implLinked:     "omap.NewOMapLinked[string, int]()",
+		implLinkedHash: "omap.NewOMapLinkedHash[string, int]()",
+		implSync:       "omap.NewOMapSync[string, int]()",
+		implFolded:     "omap.NewOMapFolded[int](nil)",
+	}
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			m := impl.initializerStrInt()
+			m.Put("z", 26)
+			m.Put("a b", 1)
+			m.Put("m", 13)
+			goStr := fmt.Sprintf("%#v", m)
+			if impl.isOrdered && goStr != m.(fmt.GoStringer).GoString() {
+				t.Errorf("expected %%#v as GoString(), found %q", goStr)
+			}
+			if _, err := parser.ParseExpr(goStr); err != nil {
+				t.Errorf("%%#v is not a valid Go expression: %v\n%s", err, goStr)
+			}
+			if !strings.HasPrefix(goStr, "func() omap.OMap[string, int] { m := "+constructors[impl.name]+"; ") {
+				t.Errorf("unexpected %%#v %q", goStr)
+			}
+			if !impl.isOrdered {
+				return
+			}
+			str := m.(fmt.Stringer).String()
+			typeName := str[:strings.Index(str, "[")]
+			if s := fmt.Sprintf("%v", m); s != str {
+				t.Errorf("expected %%v as String(), found %q / %q", s, str)
+			}
+			if s := fmt.Sprintf("%s", m); s != str {
+				t.Errorf("expected %%s as String(), found %q / %q", s, str)
+			}
+			cases := []struct {
+				format string
+				exp    string
+			}{
+				{"%+v", typeName + `["z":26 "a b":1 "m":13]`},
+				{"%q", typeName + `["z":'\x1a' "a b":'\x01' "m":'\r']`},
+				{"%x", typeName + `[7a:1a 612062:1 6d:d]`},
+				{"%.2v", typeName + "[z:26 a b:1 ...]"},
+				{"%.0v", typeName + "[...]"},
+				{"%.3v", str},
+				{"%40v", strings.Repeat(" ", 40-len(str)) + str},
+				{"%-40v|", str + strings.Repeat(" ", 40-len(str)) + "|"},
+				{"%5v", str},
+				{"%#v", `func() omap.OMap[string, int] { m := ` + constructors[impl.name] + `; m.Put("z", 26); m.Put("a b", 1); m.Put("m", 13); return m }()`},
+			}
+			for _, c := range cases {
+				if s := fmt.Sprintf(c.format, m); s != c.exp {
+					t.Errorf("%s: expected %q, found %q", c.format, c.exp, s)
+				}
+			}
+			// default limit
+			omap.FormatMaxEntries = 1
+			defer func() { omap.FormatMaxEntries = 0 }()
+			if s, exp := fmt.Sprintf("%v", m), typeName+"[z:26 ...]"; s != exp {
+				t.Errorf("expected %q, found %q", exp, s)
+			}
+			if s, exp := fmt.Sprintf("%.5v", m), str; s != exp {
+				t.Errorf("expected precision over default limit %q, found %q", exp, s)
+			}
+			if !strings.HasSuffix(fmt.Sprintf("%#v", m), `m.Put("m", 13); return m }()`) {
+				t.Errorf("expected %%#v never truncated")
+			}
+		})
+	}
+}
+
+func TestFormatNested(t *testing.T) {
+	inner := omap.New[int, []string]()
+	inner.Put(2, []string{"x"})
+	m := omap.New[string, any]()
+	m.Put("in", inner)
+	m.Put("nil", nil)
+	if s, exp := fmt.Sprintf("%+v", m), `omap.OMapLinked["in":omap.OMapLinked[2:[x]] "nil":<nil>]`; s != exp {
+		t.Errorf("expected %q, found %q", exp, s)
+	}
+	exp := `func() omap.OMap[string, interface {}] { m := omap.NewOMapLinked[string, interface {}](); ` +
+		`m.Put("in", func() omap.OMap[int, []string] { m := omap.NewOMapLinked[int, []string](); m.Put(2, []string{"x"}); return m }()); ` +
+		`m.Put("nil", nil); return m }()`
+	if s := fmt.Sprintf("%#v", m); s != exp {
+		t.Errorf("expected %q, found %q", exp, s)
+	}
+	if _, err := parser.ParseExpr(exp); err != nil {
+		t.Errorf("%%#v is not a valid Go expression: %v", err)
+	}
+	// generic types with type arguments of other packages
+	urls := omap.New[string, omap.OMap[string, *url.URL]]()
+	urls.Put("empty", omap.New[string, *url.URL]())
+	exp = `func() omap.OMap[string, omap.OMap[string,*url.URL]] { m := omap.NewOMapLinked[string, omap.OMap[string,*url.URL]](); ` +
+		`m.Put("empty", func() omap.OMap[string, *url.URL] { m := omap.NewOMapLinked[string, *url.URL](); return m }()); return m }()`
+	if s := fmt.Sprintf("%#v", urls); s != exp {
+		t.Errorf("expected %q, found %q", exp, s)
+	}
+	if _, err := parser.ParseExpr(exp); err != nil {
+		t.Errorf("%%#v is not a valid Go expression: %v", err)
+	}
+}
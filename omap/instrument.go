@@ -0,0 +1,261 @@
+package omap
+
+import (
+	"errors"
+	"sync"
+)
+
+//// Metrics ////
+
+// Name of a metric counted by an instrumented map, see Instrument.
+type Metric string
+
+const (
+	// Calls to Put.
+	MetricPut Metric = "put"
+	// Calls to PutAfter, the failed ones are also counted by ErrorMetric(MetricPutAfterError, err).
+	MetricPutAfter Metric = "put_after"
+	// Base name of the errors of PutAfter, see ErrorMetric.
+	MetricPutAfterError Metric = "put_after.error"
+	// Calls to Get that found the key.
+	MetricGetHit Metric = "get.hit"
+	// Calls to Get that didn't find the key.
+	MetricGetMiss Metric = "get.miss"
+	// Calls to Delete.
+	MetricDelete Metric = "delete"
+	// Iterators created, by Iterator, GetIteratorAt or (for omultimap) GetValuesOf.
+	MetricIterator Metric = "iterator"
+	// Calls to Next or Prev of an iterator that moved to a valid entry.
+	MetricIteratorStep Metric = "iterator.step"
+	// Calls to GetValuesOf of omultimap.
+	MetricGetValuesOf Metric = "get_values_of"
+	// Calls to DeleteAll of omultimap.
+	MetricDeleteAll Metric = "delete_all"
+	// Calls to DeleteAt of omultimap, the failed ones are also counted by
+	// ErrorMetric(MetricDeleteAtError, err).
+	MetricDeleteAt Metric = "delete_at"
+	// Base name of the errors of DeleteAt, see ErrorMetric.
+	MetricDeleteAtError Metric = "delete_at.error"
+)
+
+// sentinel errors with their own metric, see ErrorMetric
+var metricErrors = []struct {
+	err  error
+	name string
+}{
+	{ErrInvalidIteratorType, "invalid_iterator_type"},
+	{ErrInvalidIteratorMap, "invalid_iterator_map"},
+	{ErrInvalidIteratorPos, "invalid_iterator_pos"},
+	{ErrInvalidIteratorKey, "invalid_iterator_key"},
+}
+
+// Return the metric counting err, base followed by the name of the sentinel error err wraps,
+// e.g. "put_after.error.invalid_iterator_type" for ErrInvalidIteratorType, or "other" if it
+// doesn't wrap any of the ErrInvalidIterator* errors.
+func ErrorMetric(base Metric, err error) Metric {
+	for _, e := range metricErrors {
+		if errors.Is(err, e.err) {
+			return base + "." + Metric(e.name)
+		}
+	}
+	return base + ".other"
+}
+
+// Receives the metrics of an instrumented map, see Instrument. Must be safe for concurrent use if
+// the instrumented map is used concurrently.
+type MetricsSink interface {
+	// Add delta to the counter of metric.
+	Add(metric Metric, delta int64)
+}
+
+// MetricsSink keeping the counters in memory, safe for concurrent use. The zero value is ready to
+// use.
+type MemorySink struct {
+	counters map[Metric]int64
+	mx       sync.Mutex
+}
+
+// Create a new empty MemorySink.
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+func (s *MemorySink) Add(metric Metric, delta int64) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.counters == nil {
+		s.counters = make(map[Metric]int64)
+	}
+	s.counters[metric] += delta
+}
+
+// Return the counter of metric, 0 if never added.
+func (s *MemorySink) Get(metric Metric) int64 {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.counters[metric]
+}
+
+// Return a copy of all counters.
+func (s *MemorySink) Snapshot() map[Metric]int64 {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	ret := make(map[Metric]int64, len(s.counters))
+	for k, v := range s.counters {
+		ret[k] = v
+	}
+	return ret
+}
+
+// Return the ratio of calls to Get that found the key, 0 if Get was never called.
+func (s *MemorySink) HitRatio() float64 {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	hits, misses := s.counters[MetricGetHit], s.counters[MetricGetMiss]
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// Return the average number of steps (see MetricIteratorStep) per iterator created, 0 if no
+// iterator was created.
+func (s *MemorySink) AvgIterationLength() float64 {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.counters[MetricIterator] == 0 {
+		return 0
+	}
+	return float64(s.counters[MetricIteratorStep]) / float64(s.counters[MetricIterator])
+}
+
+//// OMapInstrumented ////
+
+// Implements an OMap that counts the calls made to an inner OMap, and to its iterators, into a
+// MetricsSink, see the Metric* constants for the metrics counted. Iterators of this map are
+// unwrapped by PutAfter before reaching the inner map, iterators of other instrumented maps fail
+// with ErrInvalidIteratorMap (even if they share the same inner map), and any other iterator is
+// given to the inner map as is, so it returns the same errors it would return for its own
+// iterators.
+//
+// The instrumented map is safe for concurrent use if both inner and sink are.
+type OMapInstrumented[K comparable, V any] struct {
+	inner OMap[K, V]
+	sink  MetricsSink
+}
+
+// Iterator over a OMapInstrumented, counting its steps.
+type OMapInstrumentedIterator[K comparable, V any] struct {
+	it OMapIterator[K, V]
+	m  *OMapInstrumented[K, V]
+}
+
+// Return m instrumented to count its calls into sink, see OMapInstrumented.
+func Instrument[K comparable, V any](m OMap[K, V], sink MetricsSink) OMap[K, V] {
+	return &OMapInstrumented[K, V]{inner: m, sink: sink}
+}
+
+func (m *OMapInstrumented[K, V]) newIterator(it OMapIterator[K, V]) OMapIterator[K, V] {
+	m.sink.Add(MetricIterator, 1)
+	return &OMapInstrumentedIterator[K, V]{it: it, m: m}
+}
+
+func (m *OMapInstrumented[K, V]) Put(key K, value V) {
+	m.sink.Add(MetricPut, 1)
+	m.inner.Put(key, value)
+}
+
+func (m *OMapInstrumented[K, V]) PutAfter(interfaceIt OMapIterator[K, V], key K, value V) error {
+	m.sink.Add(MetricPutAfter, 1)
+	var err error
+	if it, ok := interfaceIt.(*OMapInstrumentedIterator[K, V]); !ok {
+		err = m.inner.PutAfter(interfaceIt, key, value)
+	} else if it.m != m {
+		err = ErrInvalidIteratorMap
+	} else {
+		err = m.inner.PutAfter(it.it, key, value)
+	}
+	if err != nil {
+		m.sink.Add(ErrorMetric(MetricPutAfterError, err), 1)
+	}
+	return err
+}
+
+func (m *OMapInstrumented[K, V]) Get(key K) (V, bool) {
+	v, ok := m.inner.Get(key)
+	if ok {
+		m.sink.Add(MetricGetHit, 1)
+	} else {
+		m.sink.Add(MetricGetMiss, 1)
+	}
+	return v, ok
+}
+
+func (m *OMapInstrumented[K, V]) GetIteratorAt(key K) OMapIterator[K, V] {
+	return m.newIterator(m.inner.GetIteratorAt(key))
+}
+
+func (m *OMapInstrumented[K, V]) Delete(key K) {
+	m.sink.Add(MetricDelete, 1)
+	m.inner.Delete(key)
+}
+
+func (m *OMapInstrumented[K, V]) Iterator() OMapIterator[K, V] {
+	return m.newIterator(m.inner.Iterator())
+}
+
+func (m *OMapInstrumented[K, V]) Len() int {
+	return m.inner.Len()
+}
+
+// Implement fmt.Stringer interface, the iteration over inner map is not counted.
+func (m *OMapInstrumented[K, V]) String() string {
+	return IteratorToString[K, V]("omap.OMapInstrumented", m.inner.Iterator())
+}
+
+// Implement json.Marshaler interface, the iteration over inner map is not counted.
+func (m *OMapInstrumented[K, V]) MarshalJSON() ([]byte, error) {
+	return MarshalJSON(m.inner.Iterator())
+}
+
+func (it *OMapInstrumentedIterator[K, V]) Next() bool {
+	ok := it.it.Next()
+	if ok {
+		it.m.sink.Add(MetricIteratorStep, 1)
+	}
+	return ok
+}
+
+func (it *OMapInstrumentedIterator[K, V]) EOF() bool {
+	return it.it.EOF()
+}
+
+func (it *OMapInstrumentedIterator[K, V]) Key() K {
+	return it.it.Key()
+}
+
+func (it *OMapInstrumentedIterator[K, V]) Value() V {
+	return it.it.Value()
+}
+
+func (it *OMapInstrumentedIterator[K, V]) IsValid() bool {
+	return it.it.IsValid()
+}
+
+func (it *OMapInstrumentedIterator[K, V]) MoveFront() OMapIterator[K, V] {
+	it.it.MoveFront()
+	return it
+}
+
+func (it *OMapInstrumentedIterator[K, V]) MoveBack() OMapIterator[K, V] {
+	it.it.MoveBack()
+	return it
+}
+
+func (it *OMapInstrumentedIterator[K, V]) Prev() bool {
+	ok := it.it.Prev()
+	if ok {
+		it.m.sink.Add(MetricIteratorStep, 1)
+	}
+	return ok
+}
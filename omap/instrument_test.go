@@ -0,0 +1,147 @@
+package omap_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omap/omaptest"
+)
+
+func TestInstrument(t *testing.T) {
+	sink := omap.NewMemorySink()
+	m := omap.Instrument(omap.NewOMapLinked[string, int](), sink)
+	m.Put("a", 1)
+	m.Put("b", 2)
+	m.Put("c", 3)
+	m.Get("a")
+	m.Get("b")
+	m.Get("x")
+	m.Delete("b")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["a",1],["c",3]]`))
+	th.ValidateIteratorBackward(t, m.Iterator().MoveBack(), true, th.JsonToKV[string, int](`[["a",1],["c",3]]`))
+	// iterators of the instrumented map must be accepted, helpers included
+	th.AssertErrNil(t, m.PutAfter(m.GetIteratorAt("a"), "b", 2), "unexpected error on PutAfter")
+	th.AssertErrNil(t, omap.MoveFirst(m, "c"), "unexpected error on MoveFirst")
+	th.ValidateIterator(t, m.Iterator().MoveFront(), true, th.JsonToKV[string, int](`[["c",3],["a",1],["b",2]]`))
+	// errors of the inner map
+	th.AssertErrIs(t, m.PutAfter(omap.NewOMapSimple[string, int]().Iterator(), "x", 0), omap.ErrInvalidIteratorType, "expected iterator of another type to fail")
+	th.AssertErrIs(t, m.PutAfter(omap.NewOMapLinked[string, int]().Iterator(), "x", 0), omap.ErrInvalidIteratorMap, "expected iterator of another map to fail")
+	th.AssertErrIs(t, m.PutAfter(omap.Instrument(omap.NewOMapLinked[string, int](), omap.NewMemorySink()).Iterator(), "x", 0), omap.ErrInvalidIteratorMap, "expected iterator of another instrumented map to fail")
+	th.AssertErrIs(t, m.PutAfter(m.GetIteratorAt("x"), "x", 0), omap.ErrInvalidIteratorPos, "expected iterator at EOF to fail")
+	if m.Len() != 3 {
+		t.Errorf("expected Len() of 3, found %d", m.Len())
+	}
+	expected := map[omap.Metric]int64{
+		omap.MetricPut:      3,
+		omap.MetricGetHit:   2,
+		omap.MetricGetMiss:  1,
+		omap.MetricDelete:   1,
+		omap.MetricPutAfter: 6,
+		// Iterator x3, GetIteratorAt x2, MoveFirst x2 (GetIteratorAt and Iterator)
+		omap.MetricIterator: 7,
+		// ValidateIterator goes forward and back: 2+2 (+2 of ValidateIteratorBackward) + 3+3
+		omap.MetricIteratorStep:                             12,
+		omap.MetricPutAfterError + ".invalid_iterator_type": 1,
+		omap.MetricPutAfterError + ".invalid_iterator_map":  2,
+		omap.MetricPutAfterError + ".invalid_iterator_pos":  1,
+	}
+	if s := sink.Snapshot(); !reflect.DeepEqual(s, expected) {
+		t.Errorf("expected metrics %v, found %v", expected, s)
+	}
+	if r := sink.HitRatio(); r != 2.0/3.0 {
+		t.Errorf("expected hit ratio of 0.66, found %v", r)
+	}
+	if avg := sink.AvgIterationLength(); avg != 12.0/7.0 {
+		t.Errorf("expected average iteration length of 1.71, found %v", avg)
+	}
+	// not counted
+	if s := fmt.Sprint(m); s != "omap.OMapInstrumented[c:3 a:1 b:2]" {
+		t.Errorf("unexpected String() %q", s)
+	}
+	if b, err := json.Marshal(m); err != nil || string(b) != `{"c":3,"a":1,"b":2}` {
+		t.Errorf("unexpected JSON %q, error: %v", b, err)
+	}
+	if s := sink.Snapshot(); !reflect.DeepEqual(s, expected) {
+		t.Errorf("expected metrics %v, found %v", expected, s)
+	}
+}
+
+func TestInstrumentSharedInner(t *testing.T) {
+	inner := omap.NewOMapLinked[string, int]()
+	inner.Put("a", 1)
+	sink := omap.NewMemorySink()
+	m1 := omap.Instrument(inner, sink)
+	m2 := omap.Instrument(inner, sink)
+	// iterators are owned by the instrumented map, even if the inner map is the same
+	th.AssertErrIs(t, m2.PutAfter(m1.GetIteratorAt("a"), "x", 0), omap.ErrInvalidIteratorMap, "expected iterator of another instrumented map to fail")
+	th.AssertErrNil(t, m1.PutAfter(m1.GetIteratorAt("a"), "x", 0), "unexpected error on PutAfter")
+	// iterators of the inner map are given to it
+	th.AssertErrNil(t, m2.PutAfter(inner.GetIteratorAt("x"), "y", 0), "unexpected error on PutAfter with iterator of inner map")
+	th.ValidateIterator(t, inner.Iterator(), true, th.JsonToKV[string, int](`[["a",1],["x",0],["y",0]]`))
+	if n := sink.Get(omap.MetricPutAfterError + ".invalid_iterator_map"); n != 1 {
+		t.Errorf("expected 1 invalid_iterator_map error, found %d", n)
+	}
+}
+
+func TestInstrumentConformance(t *testing.T) {
+	omaptest.RunConformance(t, func() omap.OMap[string, int] {
+		return omap.Instrument(omap.NewOMapLinked[string, int](), omap.NewMemorySink())
+	})
+}
+
+func TestInstrumentConcurrent(t *testing.T) {
+	var sink omap.MemorySink
+	m := omap.Instrument(omap.NewOMapSync[string, int](), &sink)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.Put(fmt.Sprint(j%10), j)
+				m.Get(fmt.Sprint(i))
+			}
+		}(i)
+	}
+	wg.Wait()
+	if sink.Get(omap.MetricPut) != 400 || sink.Get(omap.MetricGetHit)+sink.Get(omap.MetricGetMiss) != 400 {
+		t.Errorf("unexpected metrics %v", sink.Snapshot())
+	}
+}
+
+func TestMemorySink(t *testing.T) {
+	var sink omap.MemorySink
+	if sink.Get(omap.MetricPut) != 0 || sink.HitRatio() != 0 || sink.AvgIterationLength() != 0 || len(sink.Snapshot()) != 0 {
+		t.Errorf("expected empty metrics, found %v", sink.Snapshot())
+	}
+	sink.Add(omap.MetricPut, 2)
+	snapshot := sink.Snapshot()
+	sink.Add(omap.MetricPut, 3)
+	if snapshot[omap.MetricPut] != 2 || sink.Get(omap.MetricPut) != 5 {
+		t.Errorf("expected snapshot of 2 and counter of 5, found %d and %d", snapshot[omap.MetricPut], sink.Get(omap.MetricPut))
+	}
+}
+
+func TestErrorMetric(t *testing.T) {
+	for _, tc := range []struct {
+		err      error
+		expected omap.Metric
+	}{
+		{fmt.Errorf("%w - wrapped", omap.ErrInvalidIteratorType), "put_after.error.invalid_iterator_type"},
+		{omap.ErrInvalidIteratorMap, "put_after.error.invalid_iterator_map"},
+		{omap.ErrInvalidIteratorPos, "put_after.error.invalid_iterator_pos"},
+		{omap.ErrInvalidIteratorKey, "put_after.error.invalid_iterator_key"},
+		{omap.ErrKeyNotFound, "put_after.error.other"},
+		{errors.New("foo"), "put_after.error.other"},
+	} {
+		if m := omap.ErrorMetric(omap.MetricPutAfterError, tc.err); m != tc.expected {
+			t.Errorf("expected metric %q for error %v, found %q", tc.expected, tc.err, m)
+		}
+	}
+}
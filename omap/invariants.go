@@ -0,0 +1,64 @@
+package omap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/matheusoliveira/go-ordered-map/internal/listcheck"
+)
+
+// Implemented by maps that are able to check the consistency of their internal structures, see
+// CheckInvariants.
+type Validator interface {
+	// Check the internal structures of the map, returning an *InvariantError listing all the
+	// violations found, or nil if they are consistent.
+	Validate() error
+}
+
+// Error returned by Validate, listing all the violations found. Wraps ErrBrokenInvariant, so
+// errors.Is can be used.
+type InvariantError struct {
+	// Type of the map validated, e.g. "omap.OMapLinked".
+	Type string
+	// Description of each violation found.
+	Violations []string
+}
+
+func (e *InvariantError) Error() string {
+	return fmt.Sprintf("%v - %s: %s", ErrBrokenInvariant, e.Type, strings.Join(e.Violations, "; "))
+}
+
+func (e *InvariantError) Unwrap() error {
+	return ErrBrokenInvariant
+}
+
+// Add a violation, with the description formatted as in fmt.Sprintf.
+func (e *InvariantError) Addf(format string, args ...any) {
+	e.Violations = append(e.Violations, fmt.Sprintf(format, args...))
+}
+
+// Return e if any violation was added, or nil otherwise. Helper to return from Validate.
+func (e *InvariantError) Err() error {
+	if len(e.Violations) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Check the internal structures of m, if it implements Validator, returning nil if m is consistent
+// or does not implement Validator. Works for any map (e.g. OMultiMap), as m is not restricted to
+// OMap. Meant for tests and debugging, as it walks the whole map: O(n).
+func CheckInvariants(m any) error {
+	if v, ok := m.(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// Validate the linked list of mapEntry from head to tail, see listcheck.Validate.
+func validateList[K comparable, V any](errs *InvariantError, head, tail *mapEntry[K, V], n int, visit func(i int, e *mapEntry[K, V])) {
+	listcheck.Validate(errs.Addf, head, tail, n,
+		func(e *mapEntry[K, V]) *mapEntry[K, V] { return e.next },
+		func(e *mapEntry[K, V]) *mapEntry[K, V] { return e.prev },
+		visit)
+}
@@ -0,0 +1,149 @@
+package omap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func assertViolation(t *testing.T, err error, expected string) {
+	t.Helper()
+	var invErr *InvariantError
+	if !errors.Is(err, ErrBrokenInvariant) || !errors.As(err, &invErr) {
+		t.Errorf("expected an *InvariantError wrapping ErrBrokenInvariant, found %v", err)
+		return
+	}
+	for _, v := range invErr.Violations {
+		if strings.Contains(v, expected) {
+			return
+		}
+	}
+	t.Errorf("expected a violation containing %q, found %v", expected, err)
+}
+
+func newLinkedABC() *OMapLinked[string, int] {
+	m, _ := NewOMapLinked[string, int]().(*OMapLinked[string, int])
+	m.Put("a", 0)
+	m.Put("b", 1)
+	m.Put("c", 2)
+	return m
+}
+
+func TestValidateLinked(t *testing.T) {
+	if err := CheckInvariants(NewOMapLinked[string, int]()); err != nil {
+		t.Errorf("unexpected error on empty map: %v", err)
+	}
+	if err := CheckInvariants(newLinkedABC()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, tc := range []struct {
+		name     string
+		corrupt  func(m *OMapLinked[string, int])
+		expected []string
+	}{
+		{"nil head", func(m *OMapLinked[string, int]) { m.head = nil }, []string{"only one of head and tail is nil", "walking forward found 0 entries, expected 3"}},
+		{"head prev", func(m *OMapLinked[string, int]) { m.head.prev = m.tail }, []string{"head has a prev entry", "walking backward found more than the 3 expected entries"}},
+		{"tail next", func(m *OMapLinked[string, int]) { m.tail.next = m.head }, []string{"tail has a next entry", "walking forward found more than the 3 expected entries"}},
+		{"wrong tail", func(m *OMapLinked[string, int]) { m.tail = m.head.next }, []string{"tail has a next entry", "walking forward ended at entry 2, which is not the tail"}},
+		{"prev link", func(m *OMapLinked[string, int]) { m.head.next.prev = nil }, []string{"entry 1 does not point back to entry 0", "walking backward found 2 entries"}},
+		{"next link", func(m *OMapLinked[string, int]) { m.head.next = m.tail }, []string{"walking forward found 2 entries", "entry 2 from the tail does not point forward to entry 1 from the tail"}},
+		{"missing lookup", func(m *OMapLinked[string, int]) { delete(m.m, "b") }, []string{"entry 1 of key b is not the one in the lookup map"}},
+		{"nil lookup", func(m *OMapLinked[string, int]) { m.m["x"] = nil }, []string{"lookup map has a nil entry for key x"}},
+		{"wrong lookup", func(m *OMapLinked[string, int]) { m.m["a"] = m.tail }, []string{"lookup map has key a pointing to entry of key c", "entry 0 of key a is not the one"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newLinkedABC()
+			tc.corrupt(m)
+			err := m.Validate()
+			for _, exp := range tc.expected {
+				assertViolation(t, err, exp)
+			}
+		})
+	}
+}
+
+func TestValidateLinkedHash(t *testing.T) {
+	newMap := func() *OMapLinkedHash[string, int] {
+		m, _ := NewOMapLinkedHash[string, int]().(*OMapLinkedHash[string, int])
+		m.Put("a", 0)
+		m.Put("b", 1)
+		m.Put("c", 2)
+		return m
+	}
+	if err := CheckInvariants(newMap()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	hashOf := func(m *OMapLinkedHash[string, int], key string) uint32 { return m.hasher(&key) }
+	for _, tc := range []struct {
+		name     string
+		corrupt  func(m *OMapLinkedHash[string, int])
+		expected []string
+	}{
+		{"length", func(m *OMapLinkedHash[string, int]) { m.length = 2 }, []string{"length is 2, but buckets have 3 entries", "walking forward found more than the 2 expected entries"}},
+		{"wrong bucket", func(m *OMapLinkedHash[string, int]) {
+			h := hashOf(m, "a")
+			m.m[h+1] = append(m.m[h+1], m.m[h]...)
+			delete(m.m, h)
+		}, []string{"entry of key a has hash"}},
+		{"duplicated key", func(m *OMapLinkedHash[string, int]) {
+			h := hashOf(m, "b")
+			m.m[h] = append(m.m[h], m.m[h]...)
+		}, []string{"has key b more than once", "length is 3, but buckets have 4 entries"}},
+		{"nil entry", func(m *OMapLinkedHash[string, int]) {
+			h := hashOf(m, "b")
+			m.m[h] = append(m.m[h], nil)
+		}, []string{"has a nil entry at 1"}},
+		{"missing lookup", func(m *OMapLinkedHash[string, int]) { delete(m.m, hashOf(m, "c")) }, []string{"entry 2 of key c is not in the lookup map"}},
+		{"not in list", func(m *OMapLinkedHash[string, int]) {
+			m.head.next = m.tail
+			m.tail.prev = m.head
+		}, []string{"1 entries of the lookup map are not in the list", "walking forward found 2 entries, expected 3"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newMap()
+			tc.corrupt(m)
+			err := m.Validate()
+			for _, exp := range tc.expected {
+				assertViolation(t, err, exp)
+			}
+		})
+	}
+}
+
+func TestValidateWrappers(t *testing.T) {
+	// maps without Validate are always considered valid
+	if err := CheckInvariants(NewOMapSimple[string, int]()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// sync
+	s, _ := NewOMapSync[string, int]().(*OMapSync[string, int])
+	s.Put("a", 0)
+	if err := CheckInvariants(s); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	s.om = newLinkedABC()
+	delete(s.om.(*OMapLinked[string, int]).m, "a")
+	assertViolation(t, CheckInvariants(s), "entry 0 of key a is not the one in the lookup map")
+	// folded
+	f, _ := NewOMapFolded[int](nil).(*OMapFolded[int])
+	f.Put("Content-Type", 0)
+	if err := CheckInvariants(f); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	f.om.Put("Accept", foldedEntry[int]{"Accept", 1})
+	assertViolation(t, CheckInvariants(f), `key "Accept" is indexed as "Accept" instead of "accept"`)
+	delete(f.om.(*OMapLinked[string, foldedEntry[int]]).m, "Accept")
+	assertViolation(t, CheckInvariants(f), "walking forward found more than the 1 expected entries")
+}
+
+func TestInvariantError(t *testing.T) {
+	err := &InvariantError{Type: "omap.OMapLinked"}
+	if err.Err() != nil {
+		t.Errorf("expected nil error without violations, found %v", err.Err())
+	}
+	err.Addf("first %d", 1)
+	err.Addf("second")
+	if exp := "broken invariant, internal structures of the map are inconsistent - omap.OMapLinked: first 1; second"; !strings.HasSuffix(err.Err().Error(), exp) {
+		t.Errorf("expected error ending with %q, found %q", exp, err.Error())
+	}
+}
@@ -0,0 +1,147 @@
+package omap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Options used by UnmarshalJSONInto and JSONMerger implementations.
+type MergeOptions struct {
+	// If true, keys whose value is a JSON null are deleted from the map (RFC 7396 semantics),
+	// otherwise null is decoded as a regular value.
+	DeleteNulls bool
+	// Create the map holding a JSON object given to a key that is not in the map yet (or whose
+	// value is not a JSONMerger), which is then filled with MergeJSON using same options, so the
+	// order of the nested keys is kept and, if DeleteNulls is true, their null values are removed
+	// as RFC 7396 requires. It is used only if the returned value can be assigned to V, otherwise
+	// the object is decoded with json.Unmarshal (removing the nulls first if DeleteNulls is true).
+	// If nil, a new OMapLinked[string, any] is used, so with V of type any the nested objects are
+	// ordered maps instead of map[string]any. Objects inside arrays are always decoded with
+	// json.Unmarshal.
+	NewObject func() JSONMerger
+}
+
+// Maps that can merge a JSON object into its existing content, without resetting it, should
+// implement this interface. It is used by UnmarshalJSONInto to recursively merge nested maps.
+type JSONMerger interface {
+	MergeJSON(b []byte, opts MergeOptions) error
+}
+
+var jsonNull = []byte("null")
+
+// Process given json at b and merge each key/value found into the existing map m, instead of
+// resetting it as UnmarshalJSON does. Existing keys are updated in place (keeping their
+// position), new keys are appended at the end, and if opts.DeleteNulls is true keys with null
+// value are deleted.
+// If the current value of a key implements JSONMerger (e.g. a nested OMapLinked) and the new
+// value is a JSON object, the nested map is merged recursively with same options, similar to
+// JSON merge-patch (RFC 7396) but preserving the order of the keys. Objects given to new keys are
+// decoded as described in MergeOptions.NewObject.
+func UnmarshalJSONInto[K comparable, V any](m OMap[K, V], b []byte, opts MergeOptions) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	t, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to get first token: %w", err)
+	}
+	if d, ok := t.(json.Delim); !ok || d.String() != "{" {
+		return errors.New("JSON input does not start with \"{\"")
+	}
+	for dec.More() {
+		keyToken, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to get key token: %w", err)
+		}
+		key, ok := keyToken.(K)
+		if !ok {
+			return fmt.Errorf("could not parse token, wrong type of: %v", keyToken)
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("could not decode value: %w", err)
+		}
+		if opts.DeleteNulls && bytes.Equal(raw, jsonNull) {
+			m.Delete(key)
+			continue
+		}
+		if current, found := m.Get(key); found && len(raw) > 0 && raw[0] == '{' {
+			if merger, ok := any(current).(JSONMerger); ok {
+				if err := merger.MergeJSON(raw, opts); err != nil {
+					return fmt.Errorf("could not merge value of key %v: %w", key, err)
+				}
+				continue
+			}
+		}
+		if len(raw) > 0 && raw[0] == '{' {
+			if value, merger, ok := newJSONObject[V](opts); ok {
+				if err := merger.MergeJSON(raw, opts); err != nil {
+					return fmt.Errorf("could not decode value of key %v: %w", key, err)
+				}
+				m.Put(key, value)
+				continue
+			}
+			if opts.DeleteNulls {
+				if raw, err = stripJSONNulls(raw); err != nil {
+					return fmt.Errorf("could not decode value: %w", err)
+				}
+			}
+		}
+		var value V
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return fmt.Errorf("could not decode value: %w", err)
+		}
+		m.Put(key, value)
+	}
+	return nil
+}
+
+// create a new object with opts.NewObject, returning it as V as well if it can be assigned to V
+func newJSONObject[V any](opts MergeOptions) (V, JSONMerger, bool) {
+	var merger JSONMerger
+	if opts.NewObject != nil {
+		merger = opts.NewObject()
+	} else {
+		merger = NewOMapLinked[string, any]().(JSONMerger)
+	}
+	value, ok := merger.(V)
+	return value, merger, ok
+}
+
+// remove the members with null value from the JSON object raw, and from the objects nested in it,
+// keeping the order of the remaining members
+func stripJSONNulls(raw []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		if bytes.Equal(value, jsonNull) {
+			continue
+		}
+		if value[0] == '{' {
+			if value, err = stripJSONNulls(value); err != nil {
+				return nil, err
+			}
+		}
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		encodedKey, _ := json.Marshal(key)
+		b.Write(encodedKey)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
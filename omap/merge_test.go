@@ -0,0 +1,147 @@
+package omap_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+func TestUnmarshalJSONInto(t *testing.T) {
+	for _, impl := range implementations {
+		if !impl.isOrdered {
+			continue
+		}
+		t.Run(impl.name, func(t *testing.T) {
+			m := impl.initializerStrInt()
+			m.Put("a", 1)
+			m.Put("b", 2)
+			m.Put("c", 3)
+			th.AssertErrNil(t, omap.UnmarshalJSONInto(m, []byte(`{"b":20,"d":4,"a":null}`), omap.MergeOptions{DeleteNulls: true}), "unexpected error merging JSON")
+			th.ValidateIterator(t, m.Iterator(), impl.isOrdered, th.JsonToKV[string, int](`[["b",20],["c",3],["d",4]]`))
+			// without DeleteNulls null is just a zero value
+			th.AssertErrNil(t, omap.UnmarshalJSONInto(m, []byte(`{"c":null,"e":5}`), omap.MergeOptions{}), "unexpected error merging JSON")
+			th.ValidateIterator(t, m.Iterator(), impl.isOrdered, th.JsonToKV[string, int](`[["b",20],["c",0],["d",4],["e",5]]`))
+			// same through JSONMerger interface
+			merger, ok := m.(omap.JSONMerger)
+			if !ok {
+				t.Fatalf("expected %T to implement omap.JSONMerger", m)
+			}
+			th.AssertErrNil(t, merger.MergeJSON([]byte(`{"e":50,"b":null}`), omap.MergeOptions{DeleteNulls: true}), "unexpected error on MergeJSON")
+			th.ValidateIterator(t, m.Iterator(), impl.isOrdered, th.JsonToKV[string, int](`[["c",0],["d",4],["e",50]]`))
+		})
+	}
+}
+
+func TestUnmarshalJSONIntoNested(t *testing.T) {
+	base := omap.NewOMapLinked[string, any]()
+	th.AssertErrNil(t, json.Unmarshal([]byte(`{"name":"app","db":{"host":"localhost","port":5432,"user":"admin"}}`), base), "unexpected error on base config")
+	// json decodes nested objects as map[string]any, so replace it by an ordered map to be merged
+	db := omap.NewOMapLinked[string, any]()
+	th.AssertErrNil(t, json.Unmarshal([]byte(`{"host":"localhost","port":5432,"user":"admin"}`), db), "unexpected error on db config")
+	base.Put("db", db)
+	patch := []byte(`{"db":{"port":6543,"user":null,"ssl":true},"debug":true}`)
+	th.AssertErrNil(t, omap.UnmarshalJSONInto(base, patch, omap.MergeOptions{DeleteNulls: true}), "unexpected error merging nested JSON")
+	js, err := json.Marshal(base)
+	th.AssertErrNil(t, err, "unexpected error on json.Marshal")
+	exp := `{"name":"app","db":{"host":"localhost","port":6543,"ssl":true},"debug":true}`
+	if string(js) != exp {
+		t.Errorf("expected %s, found %s", exp, string(js))
+	}
+	// a non-object value replaces the nested map
+	th.AssertErrNil(t, omap.UnmarshalJSONInto(base, []byte(`{"db":"disabled"}`), omap.MergeOptions{}), "unexpected error replacing nested map")
+	if v, _ := base.Get("db"); v != "disabled" {
+		t.Errorf("expected db to be replaced by \"disabled\", found %v", v)
+	}
+}
+
+func TestUnmarshalJSONIntoErrors(t *testing.T) {
+	m := omap.New[string, int]()
+	m.Put("a", 1)
+	invalidJsons := [][]byte{
+		[]byte(`not a valid json`),
+		[]byte(`"foo"`),
+		[]byte(`{"a": 1, b}`),
+		[]byte(`{"a": what?}`),
+		[]byte(`{"a": "123"}`),
+	}
+	for _, invalidJson := range invalidJsons {
+		th.AssertErrNotNil(t, omap.UnmarshalJSONInto(m, invalidJson, omap.MergeOptions{}), "expecting an error with invalid JSON: "+string(invalidJson))
+	}
+	th.AssertErrNotNil(t, omap.UnmarshalJSONInto(omap.New[int, int](), []byte(`{"1": 2}`), omap.MergeOptions{}), "expecting an error with non-string key")
+	// nested merge failure is reported
+	nested := omap.New[string, omap.OMap[string, int]]()
+	nested.Put("n", omap.New[string, int]())
+	th.AssertErrNotNil(t, omap.UnmarshalJSONInto(nested, []byte(`{"n":{"x":"not an int"}}`), omap.MergeOptions{}), "expecting an error on nested merge")
+}
+
+func TestUnmarshalJSONIntoNewObjects(t *testing.T) {
+	// nested objects of new keys are decoded into ordered maps, without nulls
+	m := omap.NewOMapLinked[string, any]()
+	input := []byte(`{"db":{"user":"admin","host":"localhost","pass":null,"opts":{"z":1,"a":null,"m":[null]}},"name":null}`)
+	th.AssertErrNil(t, omap.UnmarshalJSONInto(m, input, omap.MergeOptions{DeleteNulls: true}), "unexpected error merging JSON")
+	js, err := json.Marshal(m)
+	th.AssertErrNil(t, err, "unexpected error on json.Marshal")
+	if exp := `{"db":{"user":"admin","host":"localhost","opts":{"z":1,"m":[null]}}}`; string(js) != exp {
+		t.Errorf("expected %s, found %s", exp, string(js))
+	}
+	db, _ := m.Get("db")
+	if _, ok := db.(*omap.OMapLinked[string, any]); !ok {
+		t.Fatalf("expected nested object to be decoded as *omap.OMapLinked[string, any], found %T", db)
+	}
+	// so they can be merged later
+	th.AssertErrNil(t, omap.UnmarshalJSONInto(m, []byte(`{"db":{"host":"remote","user":null}}`), omap.MergeOptions{DeleteNulls: true}), "unexpected error merging JSON")
+	js, _ = json.Marshal(m)
+	if exp := `{"db":{"host":"remote","opts":{"z":1,"m":[null]}}}`; string(js) != exp {
+		t.Errorf("expected %s, found %s", exp, string(js))
+	}
+	// nulls are kept without DeleteNulls
+	m = omap.NewOMapLinked[string, any]()
+	th.AssertErrNil(t, omap.UnmarshalJSONInto(m, []byte(`{"db":{"b":null,"a":1}}`), omap.MergeOptions{}), "unexpected error merging JSON")
+	if js, _ = json.Marshal(m); string(js) != `{"db":{"b":null,"a":1}}` {
+		t.Errorf("unexpected JSON %s", js)
+	}
+	// custom constructor
+	m = omap.NewOMapLinked[string, any]()
+	newSync := func() omap.JSONMerger { return omap.NewOMapSync[string, any]().(omap.JSONMerger) }
+	th.AssertErrNil(t, omap.UnmarshalJSONInto(m, []byte(`{"a":{"b":{"c":1}}}`), omap.MergeOptions{NewObject: newSync}), "unexpected error merging JSON")
+	a, _ := m.Get("a")
+	if _, ok := a.(*omap.OMapSync[string, any]); !ok {
+		t.Errorf("expected nested object to be created by NewObject, found %T", a)
+	} else if b, _ := a.(omap.OMap[string, any]).Get("b"); b == nil {
+		t.Error("expected object nested in nested object")
+	} else if _, ok := b.(*omap.OMapSync[string, any]); !ok {
+		t.Errorf("expected object nested in nested object to be created by NewObject, found %T", b)
+	}
+	// values that can't hold an ordered map are decoded by json.Unmarshal, without nulls
+	mm := omap.NewOMapLinked[string, map[string]map[string]*int]()
+	th.AssertErrNil(t, omap.UnmarshalJSONInto(mm, []byte(`{"a":{"x":{"v":1,"w":null},"y":null}}`), omap.MergeOptions{DeleteNulls: true}), "unexpected error merging JSON")
+	if a, _ := mm.Get("a"); len(a) != 1 || len(a["x"]) != 1 || *a["x"]["v"] != 1 {
+		t.Errorf("expected only x.v in decoded value, found %v", a)
+	}
+	type point struct {
+		X, Y *int
+	}
+	mp := omap.NewOMapLinked[string, point]()
+	th.AssertErrNil(t, omap.UnmarshalJSONInto(mp, []byte(`{"p":{"X":1,"Y":null}}`), omap.MergeOptions{DeleteNulls: true}), "unexpected error merging JSON")
+	if p, _ := mp.Get("p"); p.X == nil || *p.X != 1 || p.Y != nil {
+		t.Errorf("unexpected decoded value %v", p)
+	}
+	// ordered values that can't hold an ordered map of any keep their order without nulls
+	ml := omap.NewOMapLinked[string, *omap.OMapLinked[string, int]]()
+	th.AssertErrNil(t, omap.UnmarshalJSONInto(ml, []byte(`{"x":{"b":1,"n":null,"a":2}}`), omap.MergeOptions{DeleteNulls: true}), "unexpected error merging JSON")
+	if x, _ := ml.Get("x"); x == nil {
+		t.Error("expected x to be decoded")
+	} else {
+		th.ValidateIterator(t, x.Iterator(), true, th.JsonToKV[string, int](`[["b",1],["a",2]]`))
+	}
+	mr := omap.NewOMapLinked[string, json.RawMessage]()
+	th.AssertErrNil(t, omap.UnmarshalJSONInto(mr, []byte(`{"x":{"z<":1, "a":null,"c":{"z":null,"y":[null,{"n":null}]},"b":"\u00e9"}}`), omap.MergeOptions{DeleteNulls: true}), "unexpected error merging JSON")
+	if x, _ := mr.Get("x"); string(x) != `{"z\u003c":1,"c":{"y":[null,{"n":null}]},"b":"\u00e9"}` {
+		t.Errorf("unexpected value %s", x)
+	}
+	// errors of nested objects
+	th.AssertErrNotNil(t, omap.UnmarshalJSONInto(omap.NewOMapLinked[string, any](), []byte(`{"a":{"b":1}}`), omap.MergeOptions{NewObject: func() omap.JSONMerger { return omap.NewOMapLinked[int, int]().(omap.JSONMerger) }}), "expecting an error on nested object with wrong key type")
+	th.AssertErrNotNil(t, omap.UnmarshalJSONInto(omap.NewOMapLinked[string, omap.OMap[string, int]](), []byte(`{"a":{"b":"c"}}`), omap.MergeOptions{NewObject: func() omap.JSONMerger { return omap.NewOMapLinked[string, int]().(omap.JSONMerger) }}), "expecting an error on nested value of wrong type")
+}
@@ -0,0 +1,399 @@
+// msgpack package implements a MessagePack (https://msgpack.org) encoder/decoder for ordered maps,
+// without external dependencies.
+//
+// Ordered maps (omap and omultimap types) are encoded in iteration order, and decoding keeps the
+// order of the keys as found in the input, so the output can be used when key order is
+// significant (e.g. signatures). The encoding is deterministic: the smallest representation is
+// always used for integers, strings and headers, and builtin Go maps are encoded with keys sorted by
+// their encoded form.
+//
+// Supported Go types are nil, bool, integers, floats, string, []byte, slices/arrays, builtin maps,
+// pointers and ordered maps. When decoding into an interface (e.g. any), integers are decoded as
+// int64 (or uint64 if too large), floats as float64, arrays as []any and maps as
+// omap.OMap[string, any].
+package msgpack
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/matheusoliveira/go-ordered-map/internal/codecutil"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+var (
+	// Returned, wrapped, by any decoding failure due to malformed or unsupported input.
+	ErrInvalidData = errors.New("msgpack: invalid data")
+)
+
+// Encode the given value v into MessagePack.
+func Marshal(v any) ([]byte, error) {
+	w := &writer{}
+	if err := codecutil.Encode(w, v); err != nil {
+		return nil, fmt.Errorf("msgpack: %w", err)
+	}
+	return w.buf, nil
+}
+
+// Iterate over the given iterator it, from the given position, and encode the key/values as a
+// MessagePack map, in the same order.
+// Note: the iterator will be at EOF after this function returns with success.
+func MarshalIterator[K comparable, V any](it omap.OMapIterator[K, V]) ([]byte, error) {
+	w := &writer{}
+	if err := codecutil.EncodeIterator(w, it); err != nil {
+		return nil, fmt.Errorf("msgpack: %w", err)
+	}
+	return w.buf, nil
+}
+
+// Process given MessagePack map at b and for each key/value found, call given putFunc function
+// with same definition of OMap.Put to add the given key/value into a map (use a closure to put
+// into an omultimap.OMultiMap).
+func Unmarshal[K comparable, V any](putFunc func(K, V), b []byte) error {
+	d := &decoder{b: b}
+	c, err := d.readByte()
+	if err != nil {
+		return err
+	}
+	n, ok, err := d.mapLen(c)
+	if err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("%w: expected a map, found type 0x%02x", ErrInvalidData, c)
+	}
+	for i := 0; i < n; i++ {
+		k, err := d.value()
+		if err != nil {
+			return err
+		}
+		v, err := d.value()
+		if err != nil {
+			return err
+		}
+		key, err := codecutil.Convert[K](k)
+		if err != nil {
+			return fmt.Errorf("msgpack: could not decode key: %w", err)
+		}
+		value, err := codecutil.Convert[V](v)
+		if err != nil {
+			return fmt.Errorf("msgpack: could not decode value of key %v: %w", key, err)
+		}
+		putFunc(key, value)
+	}
+	if len(d.b) > 0 {
+		return fmt.Errorf("%w: %d bytes of trailing data", ErrInvalidData, len(d.b))
+	}
+	return nil
+}
+
+// Decode a single MessagePack value at b into the generic representation (see package docs).
+func Decode(b []byte) (any, error) {
+	d := &decoder{b: b}
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if len(d.b) > 0 {
+		return nil, fmt.Errorf("%w: %d bytes of trailing data", ErrInvalidData, len(d.b))
+	}
+	return v, nil
+}
+
+//// writer ////
+
+type writer struct {
+	buf []byte
+}
+
+func (w *writer) header(fix byte, fixMax int, c8, c16, c32 byte, n int) {
+	switch {
+	case n < fixMax:
+		w.buf = append(w.buf, fix|byte(n))
+	case c8 != 0 && n <= math.MaxUint8:
+		w.buf = append(w.buf, c8, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, c16, 0, 0)
+		binary.BigEndian.PutUint16(w.buf[len(w.buf)-2:], uint16(n))
+	default:
+		w.buf = append(w.buf, c32, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(w.buf[len(w.buf)-4:], uint32(n))
+	}
+}
+
+func (w *writer) Nil() {
+	w.buf = append(w.buf, 0xc0)
+}
+
+func (w *writer) Bool(b bool) {
+	if b {
+		w.buf = append(w.buf, 0xc3)
+	} else {
+		w.buf = append(w.buf, 0xc2)
+	}
+}
+
+func (w *writer) Int(i int64) {
+	switch {
+	case i >= 0:
+		w.Uint(uint64(i))
+	case i >= -32:
+		w.buf = append(w.buf, byte(int8(i)))
+	case i >= math.MinInt8:
+		w.buf = append(w.buf, 0xd0, byte(int8(i)))
+	case i >= math.MinInt16:
+		w.buf = append(w.buf, 0xd1, 0, 0)
+		binary.BigEndian.PutUint16(w.buf[len(w.buf)-2:], uint16(i))
+	case i >= math.MinInt32:
+		w.buf = append(w.buf, 0xd2, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(w.buf[len(w.buf)-4:], uint32(i))
+	default:
+		w.buf = append(w.buf, 0xd3, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(w.buf[len(w.buf)-8:], uint64(i))
+	}
+}
+
+func (w *writer) Uint(u uint64) {
+	switch {
+	case u <= math.MaxInt8:
+		w.buf = append(w.buf, byte(u))
+	case u <= math.MaxUint8:
+		w.buf = append(w.buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		w.buf = append(w.buf, 0xcd, 0, 0)
+		binary.BigEndian.PutUint16(w.buf[len(w.buf)-2:], uint16(u))
+	case u <= math.MaxUint32:
+		w.buf = append(w.buf, 0xce, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(w.buf[len(w.buf)-4:], uint32(u))
+	default:
+		w.buf = append(w.buf, 0xcf, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(w.buf[len(w.buf)-8:], u)
+	}
+}
+
+func (w *writer) Float32(f float32) {
+	w.buf = append(w.buf, 0xca, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(w.buf[len(w.buf)-4:], math.Float32bits(f))
+}
+
+func (w *writer) Float64(f float64) {
+	w.buf = append(w.buf, 0xcb, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint64(w.buf[len(w.buf)-8:], math.Float64bits(f))
+}
+
+func (w *writer) String(s string) {
+	w.header(0xa0, 32, 0xd9, 0xda, 0xdb, len(s))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *writer) Bytes(b []byte) {
+	w.header(0, 0, 0xc4, 0xc5, 0xc6, len(b))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *writer) ArrayHeader(n int) {
+	w.header(0x90, 16, 0, 0xdc, 0xdd, n)
+}
+
+func (w *writer) MapHeader(n int) {
+	w.header(0x80, 16, 0, 0xde, 0xdf, n)
+}
+
+func (w *writer) Raw(b []byte) {
+	w.buf = append(w.buf, b...)
+}
+
+func (w *writer) New() codecutil.Writer {
+	return &writer{}
+}
+
+func (w *writer) Encoded() []byte {
+	return w.buf
+}
+
+//// decoder ////
+
+type decoder struct {
+	b []byte
+	// nesting level of the value being decoded, see codecutil.MaxDepth
+	depth int
+}
+
+func (d *decoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.b) < n {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidData)
+	}
+	ret := d.b[:n]
+	d.b = d.b[n:]
+	return ret, nil
+}
+
+func (d *decoder) readByte() (byte, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (d *decoder) readUint(size int) (uint64, error) {
+	b, err := d.next(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+func (d *decoder) length(size int) (int, error) {
+	n, err := d.readUint(size)
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(len(d.b)) {
+		// every element takes at least one byte, so it can't be larger than remaining data
+		return 0, fmt.Errorf("%w: length %d larger than data", ErrInvalidData, n)
+	}
+	return int(n), nil
+}
+
+// Return the length of the map if c is a map type.
+func (d *decoder) mapLen(c byte) (int, bool, error) {
+	switch {
+	case c&0xf0 == 0x80:
+		return int(c & 0x0f), true, nil
+	case c == 0xde:
+		n, err := d.length(2)
+		return n, true, err
+	case c == 0xdf:
+		n, err := d.length(4)
+		return n, true, err
+	}
+	return 0, false, nil
+}
+
+func (d *decoder) value() (any, error) {
+	if d.depth >= codecutil.MaxDepth {
+		return nil, fmt.Errorf("%w: exceeded max depth of %d", ErrInvalidData, codecutil.MaxDepth)
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+	c, err := d.readByte()
+	if err != nil {
+		return nil, err
+	}
+	if n, ok, err := d.mapLen(c); err != nil {
+		return nil, err
+	} else if ok {
+		m := codecutil.NewNestedMap()
+		for i := 0; i < n; i++ {
+			k, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			codecutil.PutNested(m, k, v)
+		}
+		return m, nil
+	}
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x90:
+		return d.array(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		return d.str(int(c & 0x1f))
+	}
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.length(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case 0xca:
+		u, err := d.readUint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := d.readUint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.readUint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if u <= math.MaxInt64 {
+			return int64(u), nil
+		}
+		return u, nil
+	case 0xd0:
+		u, err := d.readUint(1)
+		return int64(int8(u)), err
+	case 0xd1:
+		u, err := d.readUint(2)
+		return int64(int16(u)), err
+	case 0xd2:
+		u, err := d.readUint(4)
+		return int64(int32(u)), err
+	case 0xd3:
+		u, err := d.readUint(8)
+		return int64(u), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.length(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(n)
+	case 0xdc, 0xdd:
+		n, err := d.length(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(n)
+	}
+	return nil, fmt.Errorf("%w: unsupported type 0x%02x", ErrInvalidData, c)
+}
+
+func (d *decoder) str(n int) (any, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (d *decoder) array(n int) (any, error) {
+	ret := make([]any, n)
+	for i := range ret {
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = v
+	}
+	return ret, nil
+}
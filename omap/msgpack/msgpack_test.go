@@ -0,0 +1,282 @@
+package msgpack_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/matheusoliveira/go-ordered-map/internal/codecutil"
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omap/msgpack"
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestMarshalValues(t *testing.T) {
+	cases := []struct {
+		value any
+		exp   string
+	}{
+		{nil, "c0"},
+		{false, "c2"},
+		{true, "c3"},
+		{0, "00"},
+		{127, "7f"},
+		{128, "cc80"},
+		{256, "cd0100"},
+		{65536, "ce00010000"},
+		{uint64(1 << 32), "cf0000000100000000"},
+		{-1, "ff"},
+		{-32, "e0"},
+		{-33, "d0df"},
+		{-129, "d1ff7f"},
+		{-32769, "d2ffff7fff"},
+		{int64(math.MinInt64), "d38000000000000000"},
+		{float32(1.5), "ca3fc00000"},
+		{1.5, "cb3ff8000000000000"},
+		{"", "a0"},
+		{"a", "a161"},
+		{strings.Repeat("x", 32), "d920" + strings.Repeat("78", 32)},
+		{strings.Repeat("x", 256), "da0100" + strings.Repeat("78", 256)},
+		{strings.Repeat("x", 65536), "db00010000" + strings.Repeat("78", 65536)},
+		{[]byte{1, 2}, "c4020102"},
+		{[2]byte{1, 2}, "c4020102"},
+		{[]byte(nil), "c0"},
+		{[]int{1, 2}, "920102"},
+		{make([]bool, 16), "dc0010" + strings.Repeat("c2", 16)},
+		{map[string]int{"b": 2, "a": 1}, "82a16101a16202"},
+		{map[string]int(nil), "c0"},
+		{(*int)(nil), "c0"},
+	}
+	for _, c := range cases {
+		res, err := msgpack.Marshal(c.value)
+		th.AssertErrNil(t, err, "unexpected error on Marshal")
+		if hex.EncodeToString(res) != c.exp {
+			t.Errorf("Marshal(%v): expected %s, found %x", c.value, c.exp, res)
+		}
+		// decode back
+		if _, err := msgpack.Decode(res); err != nil {
+			t.Errorf("Decode(%x) failed: %v", res, err)
+		}
+	}
+	// large containers
+	big := omap.New[int, bool]()
+	for i := 0; i < 70000; i++ {
+		big.Put(i, true)
+	}
+	res, err := msgpack.Marshal(big)
+	th.AssertErrNil(t, err, "unexpected error on Marshal")
+	if hex.EncodeToString(res[:5]) != "df00011170" {
+		t.Errorf("expected map32 header, found %x", res[:5])
+	}
+	res, err = msgpack.Marshal(make([]bool, 70000))
+	th.AssertErrNil(t, err, "unexpected error on Marshal")
+	if hex.EncodeToString(res[:5]) != "dd00011170" {
+		t.Errorf("expected array32 header, found %x", res[:5])
+	}
+	if v, err := msgpack.Decode(res); err != nil {
+		t.Errorf("unexpected error on Decode: %v", err)
+	} else if len(v.([]any)) != 70000 {
+		t.Errorf("expected array of len 70000, found %d", len(v.([]any)))
+	}
+	big2 := omap.New[int, bool]()
+	th.AssertErrNil(t, msgpack.Unmarshal(big2.Put, mustHex("de0002 01c3 02c2")), "unexpected error on Unmarshal map16")
+	th.ValidateIterator(t, big2.Iterator(), true, []th.KeyValue[int, bool]{{Key: 1, Value: true}, {Key: 2, Value: false}})
+}
+
+func TestOrderedMaps(t *testing.T) {
+	inner := omap.New[string, any]()
+	inner.Put("z", 1)
+	inner.Put("a", []any{"x", nil})
+	m := omap.New[string, any]()
+	m.Put("b", inner)
+	m.Put("a", 2.5)
+	res, err := msgpack.MarshalIterator(m.Iterator())
+	th.AssertErrNil(t, err, "unexpected error on MarshalIterator")
+	exp := "82 a162 82a17a01a16192a178c0 a161 cb4004000000000000"
+	if hex.EncodeToString(res) != strings.ReplaceAll(exp, " ", "") {
+		t.Errorf("expected %s, found %x", exp, res)
+	}
+	res2, err := msgpack.Marshal(m)
+	th.AssertErrNil(t, err, "unexpected error on Marshal")
+	if string(res) != string(res2) {
+		t.Errorf("Marshal and MarshalIterator differ: %x / %x", res, res2)
+	}
+	// decode into a new map, including the nested one
+	m2 := omap.New[string, any]()
+	th.AssertErrNil(t, msgpack.Unmarshal(m2.Put, res), "unexpected error on Unmarshal")
+	res3, err := msgpack.Marshal(m2)
+	th.AssertErrNil(t, err, "unexpected error on Marshal")
+	if string(res) != string(res3) {
+		t.Errorf("round-trip failed: %x / %x", res, res3)
+	}
+	nested, _ := m2.Get("b")
+	if nm, ok := nested.(omap.OMap[string, any]); !ok {
+		t.Errorf("expected nested omap.OMap[string, any], found %T", nested)
+	} else if keys := omap.IteratorKeysToSlice(nm.Iterator()); !reflect.DeepEqual(keys, []string{"z", "a"}) {
+		t.Errorf("expected nested keys [z a], found %v", keys)
+	}
+}
+
+func TestUnmarshalTyped(t *testing.T) {
+	// {"x": 1, "y": -2, "x": 3} with repeated key, into a multimap
+	data := mustHex("83 a178 01 a179 fe a178 03")
+	mm := omultimap.New[string, int8]()
+	th.AssertErrNil(t, msgpack.Unmarshal(func(k string, v int8) { mm.Put(k, v) }, data), "unexpected error on Unmarshal")
+	th.ValidateIterator(t, mm.Iterator(), true, []th.KeyValue[string, int8]{{Key: "x", Value: 1}, {Key: "y", Value: -2}, {Key: "x", Value: 3}})
+	// conversions to other types
+	type values struct {
+		I   int
+		U   uint16
+		F   float32
+		S   string
+		B   []byte
+		L   []int
+		M   map[string]uint
+		P   *string
+		Any any
+	}
+	in := values{I: -5, U: 500, F: 0.5, S: "s", B: []byte("b"), L: []int{1, 2}, M: map[string]uint{"k": 1}, Any: "any"}
+	str := "ptr"
+	in.P = &str
+	src := omap.New[string, any]()
+	src.Put("I", in.I)
+	src.Put("U", in.U)
+	src.Put("F", in.F)
+	src.Put("S", in.S)
+	src.Put("B", in.B)
+	src.Put("L", in.L)
+	src.Put("M", in.M)
+	src.Put("P", in.P)
+	src.Put("Any", in.Any)
+	data, err := msgpack.Marshal(src)
+	th.AssertErrNil(t, err, "unexpected error on Marshal")
+	var out values
+	put := func(k string, v any) {
+		var err error
+		switch k {
+		case "I":
+			err = convert(v, &out.I)
+		case "U":
+			err = convert(v, &out.U)
+		case "F":
+			err = convert(v, &out.F)
+		case "S":
+			err = convert(v, &out.S)
+		case "B":
+			err = convert(v, &out.B)
+		case "L":
+			err = convert(v, &out.L)
+		case "M":
+			err = convert(v, &out.M)
+		case "P":
+			err = convert(v, &out.P)
+		case "Any":
+			out.Any = v
+		}
+		if err != nil {
+			t.Errorf("failed to convert %q: %v", k, err)
+		}
+	}
+	th.AssertErrNil(t, msgpack.Unmarshal(put, data), "unexpected error on Unmarshal")
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("expected %+v, found %+v", in, out)
+	}
+}
+
+// re-encode v and decode it into a map of a single key, to exercise typed conversions
+func convert[T any](v any, dst *T) error {
+	m := omap.New[string, any]()
+	m.Put("v", v)
+	data, err := msgpack.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return msgpack.Unmarshal(func(_ string, value T) { *dst = value }, data)
+}
+
+func TestErrors(t *testing.T) {
+	invalid := []string{
+		"",             // empty
+		"01",           // not a map
+		"81",           // missing key
+		"81a1",         // truncated string
+		"81a161",       // missing value
+		"81a161c1",     // unsupported type
+		"81a161d4",     // unsupported ext
+		"de",           // truncated map16 length
+		"deffff",       // map16 length larger than data
+		"81a16101ff",   // trailing data
+		"81a161c4",     // truncated bin length
+		"81a161c405",   // truncated bin
+		"81a161cc",     // truncated uint8
+		"81a161d9",     // truncated str8 length
+		"81a161dc",     // truncated array16 length
+		"81a16191",     // truncated array
+		"81a16181",     // truncated nested map
+		"81a1618101",   // truncated nested map value
+		"81a161df0000", // truncated map32 length
+	}
+	for _, data := range invalid {
+		m := omap.New[string, any]()
+		err := msgpack.Unmarshal(m.Put, mustHex(data))
+		if !errors.Is(err, msgpack.ErrInvalidData) {
+			t.Errorf("expected ErrInvalidData for %s, found %v", data, err)
+		}
+	}
+	m := omap.New[int, int]()
+	th.AssertErrNotNil(t, msgpack.Unmarshal(m.Put, mustHex("81a16101")), "expected error with string key into int")
+	th.AssertErrNotNil(t, msgpack.Unmarshal(m.Put, mustHex("8101a161")), "expected error with string value into int")
+	m8 := omap.New[int, int8]()
+	th.AssertErrNotNil(t, msgpack.Unmarshal(m8.Put, mustHex("8101cd0100")), "expected error with int8 overflow")
+	_, err := msgpack.Decode(mustHex("c0c0"))
+	th.AssertErrIs(t, err, msgpack.ErrInvalidData, "expected error with trailing data")
+	_, err = msgpack.Decode(mustHex(""))
+	th.AssertErrIs(t, err, msgpack.ErrInvalidData, "expected error with empty data")
+	_, err = msgpack.Marshal(struct{}{})
+	th.AssertErrNotNil(t, err, "expected error with unsupported type")
+	_, err = msgpack.Marshal(map[string]any{"a": struct{}{}})
+	th.AssertErrNotNil(t, err, "expected error with unsupported map value")
+	_, err = msgpack.Marshal(map[any]int{struct{}{}: 1})
+	th.AssertErrNotNil(t, err, "expected error with unsupported map key")
+	_, err = msgpack.Marshal([]any{struct{}{}})
+	th.AssertErrNotNil(t, err, "expected error with unsupported array value")
+	invalidValue := omap.New[string, any]()
+	invalidValue.Put("a", struct{}{})
+	_, err = msgpack.MarshalIterator(invalidValue.Iterator())
+	th.AssertErrNotNil(t, err, "expected error with unsupported value")
+	invalidKey := omap.New[struct{}, int]()
+	invalidKey.Put(struct{}{}, 1)
+	_, err = msgpack.MarshalIterator(invalidKey.Iterator())
+	th.AssertErrNotNil(t, err, "expected error with unsupported key")
+}
+
+// nested one-element arrays, ending with a 0, under key "a"
+func nestedArrays(n int) []byte {
+	b := append(mustHex("81a161"), bytes.Repeat([]byte{0x91}, n)...)
+	return append(b, 0x00)
+}
+
+func TestMaxDepth(t *testing.T) {
+	// the value inside the arrays is at depth n+1
+	m := omap.New[string, any]()
+	th.AssertErrNil(t, msgpack.Unmarshal(m.Put, nestedArrays(codecutil.MaxDepth-1)), "unexpected error at max depth")
+	th.AssertErrIs(t, msgpack.Unmarshal(m.Put, nestedArrays(codecutil.MaxDepth)), msgpack.ErrInvalidData, "expected error above max depth")
+	// would overflow the stack without the limit
+	th.AssertErrIs(t, msgpack.Unmarshal(m.Put, nestedArrays(50<<20)), msgpack.ErrInvalidData, "expected error with deeply nested arrays")
+	nestedMaps := append(bytes.Repeat(mustHex("81a161"), codecutil.MaxDepth+1), 0x00)
+	_, err := msgpack.Decode(nestedMaps)
+	th.AssertErrIs(t, err, msgpack.ErrInvalidData, "expected error with deeply nested maps")
+}
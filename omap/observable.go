@@ -0,0 +1,215 @@
+package omap
+
+import (
+	"sync"
+)
+
+//// Events ////
+
+// Type of the change notified by an Event.
+type EventType int
+
+const (
+	// A new key was added, by Put or PutAfter.
+	EventInserted EventType = iota + 1
+	// The value of an existing key was replaced by Put, keeping its position.
+	EventUpdated
+	// A key was removed by Delete.
+	EventDeleted
+	// An existing key was given to PutAfter, e.g. by MoveFirst, MoveLast, MoveAfter or MoveBefore.
+	EventMoved
+)
+
+var eventTypeNames = [...]string{"", "inserted", "updated", "deleted", "moved"}
+
+// Implement fmt.Stringer interface.
+func (t EventType) String() string {
+	if t < EventInserted || t > EventMoved {
+		return "unknown"
+	}
+	return eventTypeNames[t]
+}
+
+// A change made to an OMapObservable. Positions are 0-based indexes in the iteration order, so
+// applying the events in the order received to a list (insert at Pos, replace at Pos, remove at
+// Pos, remove at OldPos and then insert at Pos) keeps it equal to the map.
+type Event[K comparable, V any] struct {
+	Type EventType
+	Key  K
+	// The value after the change, or the removed value for EventDeleted.
+	Value V
+	// The replaced value, for EventUpdated and EventMoved (PutAfter replaces the value as well).
+	OldValue V
+	// Position of the entry after the change, or the position it had for EventDeleted.
+	Pos int
+	// Position of the entry before the change, for EventMoved. Equal to Pos if the entry was put
+	// after the one already preceding it.
+	OldPos int
+	// Key of the entry preceding Pos, valid only if HasAfter is true (false at the first position).
+	After    K
+	HasAfter bool
+}
+
+//// OMapObservable ////
+
+// Implements an OMap that notifies every change made through it to an inner OMap, with the
+// position of the changed entry, to the callbacks and channels subscribed, which is useful to
+// mirror a map into another ordered structure (e.g. a list in a UI):
+//
+//	m := omap.NewObservable(omap.New[string, int]())
+//	unsubscribe := m.Subscribe(func(e omap.Event[string, int]) {
+//		fmt.Println(e.Type, e.Key, e.Pos)
+//	})
+//	defer unsubscribe()
+//
+// Since the Move* helpers are implemented with PutAfter, they are notified as EventMoved too.
+// Changes made to inner directly are not notified.
+//
+// Changes are serialized, and the events of a change are delivered before it returns, in the
+// order subscribers were added, so all subscribers see the same sequence. As positions are found
+// by iterating over inner, each change costs O(n) and inner must be an ordered implementation.
+// Reads are given to inner without locking, so it can be used concurrently if inner can.
+// Subscribers may read the map and unsubscribe, but must not change it.
+type OMapObservable[K comparable, V any] struct {
+	inner  OMap[K, V]
+	subs   []subscriber[K, V]
+	lastID int
+	mx     sync.Mutex // serializes changes
+	subsMx sync.Mutex
+}
+
+type subscriber[K comparable, V any] struct {
+	id int
+	fn func(Event[K, V])
+}
+
+// Create a new OMapObservable notifying the changes made through it to inner.
+func NewObservable[K comparable, V any](inner OMap[K, V]) *OMapObservable[K, V] {
+	return &OMapObservable[K, V]{inner: inner}
+}
+
+// Call fn with every change made to the map, until the returned function is called. The
+// returned function can be called more than once, and from within fn.
+func (m *OMapObservable[K, V]) Subscribe(fn func(Event[K, V])) (unsubscribe func()) {
+	m.subsMx.Lock()
+	defer m.subsMx.Unlock()
+	m.lastID++
+	id := m.lastID
+	m.subs = append(m.subs, subscriber[K, V]{id: id, fn: fn})
+	return func() {
+		m.subsMx.Lock()
+		defer m.subsMx.Unlock()
+		for i, s := range m.subs {
+			if s.id == id {
+				m.subs = append(m.subs[:i:i], m.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Send every change made to the map to ch, until the returned function is called. Sending
+// blocks the change being made, so ch must be buffered or received from concurrently.
+func (m *OMapObservable[K, V]) SubscribeChan(ch chan<- Event[K, V]) (unsubscribe func()) {
+	return m.Subscribe(func(e Event[K, V]) {
+		ch <- e
+	})
+}
+
+// deliver e to the current subscribers, out of subsMx so they can unsubscribe
+func (m *OMapObservable[K, V]) notify(e Event[K, V]) {
+	m.subsMx.Lock()
+	subs := m.subs
+	m.subsMx.Unlock()
+	for _, s := range subs {
+		s.fn(e)
+	}
+}
+
+// return the position of key in inner and the key preceding it, if any
+func (m *OMapObservable[K, V]) locate(key K) (pos int, after K, hasAfter bool) {
+	for it := m.inner.Iterator(); it.Next(); pos++ {
+		if it.Key() == key {
+			return pos, after, hasAfter
+		}
+		after, hasAfter = it.Key(), true
+	}
+	return -1, after, false
+}
+
+func (m *OMapObservable[K, V]) Put(key K, value V) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	oldValue, ok := m.inner.Get(key)
+	m.inner.Put(key, value)
+	e := Event[K, V]{Type: EventInserted, Key: key, Value: value}
+	if ok {
+		e.Type = EventUpdated
+		e.OldValue = oldValue
+	}
+	e.Pos, e.After, e.HasAfter = m.locate(key)
+	m.notify(e)
+}
+
+func (m *OMapObservable[K, V]) PutAfter(it OMapIterator[K, V], key K, value V) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	oldValue, ok := m.inner.Get(key)
+	oldPos := -1
+	if ok {
+		oldPos, _, _ = m.locate(key)
+	}
+	if err := m.inner.PutAfter(it, key, value); err != nil {
+		return err
+	}
+	e := Event[K, V]{Type: EventInserted, Key: key, Value: value}
+	if ok {
+		e.Type = EventMoved
+		e.OldValue = oldValue
+		e.OldPos = oldPos
+	}
+	e.Pos, e.After, e.HasAfter = m.locate(key)
+	m.notify(e)
+	return nil
+}
+
+func (m *OMapObservable[K, V]) Get(key K) (V, bool) {
+	return m.inner.Get(key)
+}
+
+// Return the iterator of inner at key, so it can be given to PutAfter.
+func (m *OMapObservable[K, V]) GetIteratorAt(key K) OMapIterator[K, V] {
+	return m.inner.GetIteratorAt(key)
+}
+
+func (m *OMapObservable[K, V]) Delete(key K) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	value, ok := m.inner.Get(key)
+	if !ok {
+		return
+	}
+	e := Event[K, V]{Type: EventDeleted, Key: key, Value: value}
+	e.Pos, e.After, e.HasAfter = m.locate(key)
+	m.inner.Delete(key)
+	m.notify(e)
+}
+
+// Return the iterator of inner, so it can be given to PutAfter.
+func (m *OMapObservable[K, V]) Iterator() OMapIterator[K, V] {
+	return m.inner.Iterator()
+}
+
+func (m *OMapObservable[K, V]) Len() int {
+	return m.inner.Len()
+}
+
+// Implement fmt.Stringer interface.
+func (m *OMapObservable[K, V]) String() string {
+	return IteratorToString[K, V]("omap.OMapObservable", m.inner.Iterator())
+}
+
+// Implement json.Marshaler interface.
+func (m *OMapObservable[K, V]) MarshalJSON() ([]byte, error) {
+	return MarshalJSON(m.inner.Iterator())
+}
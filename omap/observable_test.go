@@ -0,0 +1,174 @@
+package omap_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omap/omaptest"
+)
+
+// apply e to a list mirroring the map, checking the position information of the event
+func applyEvent(t *testing.T, list []th.KeyValue[string, int], e omap.Event[string, int]) []th.KeyValue[string, int] {
+	t.Helper()
+	kv := th.KeyValue[string, int]{Key: e.Key, Value: e.Value}
+	switch e.Type {
+	case omap.EventInserted:
+		list = append(list[:e.Pos], append([]th.KeyValue[string, int]{kv}, list[e.Pos:]...)...)
+	case omap.EventUpdated:
+		if list[e.Pos].Key != e.Key || list[e.Pos].Value != e.OldValue {
+			t.Errorf("%v event of %v, expected %v at position %d, found %v", e.Type, e.Key, e.OldValue, e.Pos, list[e.Pos])
+		}
+		list[e.Pos] = kv
+	case omap.EventDeleted:
+		if list[e.Pos].Key != e.Key || list[e.Pos].Value != e.Value {
+			t.Errorf("%v event of %v, expected %v at position %d, found %v", e.Type, e.Key, e.Value, e.Pos, list[e.Pos])
+		}
+		list = append(list[:e.Pos], list[e.Pos+1:]...)
+	case omap.EventMoved:
+		if list[e.OldPos].Key != e.Key || list[e.OldPos].Value != e.OldValue {
+			t.Errorf("%v event of %v, expected %v at position %d, found %v", e.Type, e.Key, e.OldValue, e.OldPos, list[e.OldPos])
+		}
+		list = append(list[:e.OldPos], list[e.OldPos+1:]...)
+		list = append(list[:e.Pos], append([]th.KeyValue[string, int]{kv}, list[e.Pos:]...)...)
+	default:
+		t.Fatalf("unexpected event type %v", e.Type)
+	}
+	if e.Type != omap.EventDeleted && e.HasAfter != (e.Pos > 0) {
+		t.Errorf("%v event of %v, expected HasAfter at position %d to be %v", e.Type, e.Key, e.Pos, e.Pos > 0)
+	} else if e.HasAfter && list[e.Pos-1].Key != e.After {
+		t.Errorf("%v event of %v, expected after %v, found %v", e.Type, e.Key, list[e.Pos-1].Key, e.After)
+	}
+	return list
+}
+
+func TestObservable(t *testing.T) {
+	for _, impl := range implementations {
+		if !impl.isOrdered {
+			continue
+		}
+		t.Run(impl.name, func(t *testing.T) {
+			m := omap.NewObservable(impl.initializerStrInt())
+			var list []th.KeyValue[string, int]
+			var types []omap.EventType
+			m.Subscribe(func(e omap.Event[string, int]) {
+				list = applyEvent(t, list, e)
+				types = append(types, e.Type)
+			})
+			m.Put("a", 1)
+			m.Put("b", 2)
+			m.Put("c", 3)
+			m.Put("b", 20)
+			th.AssertErrNil(t, m.PutAfter(m.Iterator(), "x", 0), "unexpected error on PutAfter at BOF")
+			th.AssertErrNil(t, m.PutAfter(m.GetIteratorAt("b"), "y", 9), "unexpected error on PutAfter")
+			th.AssertErrNil(t, omap.MoveLast[string, int](m, "a"), "unexpected error on MoveLast")
+			th.AssertErrNil(t, omap.MoveFirst[string, int](m, "c"), "unexpected error on MoveFirst")
+			th.AssertErrNil(t, omap.MoveAfter[string, int](m, "x", "y"), "unexpected error on MoveAfter")
+			th.AssertErrNil(t, omap.MoveBefore[string, int](m, "a", "b"), "unexpected error on MoveBefore")
+			m.Delete("y")
+			m.Delete("c")
+			// no changes
+			m.Delete("z")
+			th.AssertErrNotNil(t, m.PutAfter(m.GetIteratorAt("z"), "z", 0), "expected PutAfter at EOF to fail")
+			expected := th.JsonToKV[string, int](`[["a",1],["b",20],["x",0]]`)
+			th.ValidateIterator(t, m.Iterator(), true, expected)
+			if !reflect.DeepEqual(list, expected) {
+				t.Errorf("expected mirrored list %v, found %v", expected, list)
+			}
+			expTypes := []omap.EventType{
+				omap.EventInserted, omap.EventInserted, omap.EventInserted, omap.EventUpdated,
+				omap.EventInserted, omap.EventInserted,
+				omap.EventMoved, omap.EventMoved, omap.EventMoved, omap.EventMoved,
+				omap.EventDeleted, omap.EventDeleted,
+			}
+			if !reflect.DeepEqual(types, expTypes) {
+				t.Errorf("expected events %v, found %v", expTypes, types)
+			}
+		})
+	}
+}
+
+func TestObservableEvents(t *testing.T) {
+	m := omap.NewObservable(omap.NewOMapLinked[string, int]())
+	ch := make(chan omap.Event[string, int], 10)
+	unsubscribe := m.SubscribeChan(ch)
+	m.Put("a", 1)
+	m.Put("b", 2)
+	th.AssertErrNil(t, omap.MoveFirst[string, int](m, "b"), "unexpected error on MoveFirst")
+	m.Put("a", 10)
+	m.Delete("b")
+	unsubscribe()
+	unsubscribe()
+	m.Put("c", 3)
+	close(ch)
+	var events []omap.Event[string, int]
+	for e := range ch {
+		events = append(events, e)
+	}
+	expected := []omap.Event[string, int]{
+		{Type: omap.EventInserted, Key: "a", Value: 1, Pos: 0},
+		{Type: omap.EventInserted, Key: "b", Value: 2, Pos: 1, After: "a", HasAfter: true},
+		{Type: omap.EventMoved, Key: "b", Value: 2, OldValue: 2, Pos: 0, OldPos: 1},
+		{Type: omap.EventUpdated, Key: "a", Value: 10, OldValue: 1, Pos: 1, After: "b", HasAfter: true},
+		{Type: omap.EventDeleted, Key: "b", Value: 2, Pos: 0},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected events:\n%v\nfound:\n%v", expected, events)
+	}
+	if s := fmt.Sprint(m); s != "omap.OMapObservable[a:10 c:3]" {
+		t.Errorf("unexpected String() %q", s)
+	}
+	if b, err := json.Marshal(m); err != nil || string(b) != `{"a":10,"c":3}` {
+		t.Errorf("unexpected JSON %q, error: %v", b, err)
+	}
+}
+
+func TestObservableUnsubscribe(t *testing.T) {
+	m := omap.NewObservable(omap.NewOMapLinked[string, int]())
+	var first, second, third []string
+	var unsubscribeFirst func()
+	unsubscribeFirst = m.Subscribe(func(e omap.Event[string, int]) {
+		first = append(first, e.Key)
+		// unsubscribing and reading from within a callback
+		if v, _ := m.Get(e.Key); v == 2 {
+			unsubscribeFirst()
+		}
+	})
+	unsubscribeSecond := m.Subscribe(func(e omap.Event[string, int]) {
+		second = append(second, e.Key)
+	})
+	m.Subscribe(func(e omap.Event[string, int]) {
+		third = append(third, e.Key)
+	})
+	m.Put("a", 1)
+	m.Put("b", 2)
+	unsubscribeSecond()
+	m.Put("c", 3)
+	if !reflect.DeepEqual(first, []string{"a", "b"}) || !reflect.DeepEqual(second, []string{"a", "b"}) || !reflect.DeepEqual(third, []string{"a", "b", "c"}) {
+		t.Errorf("unexpected events received %v, %v and %v", first, second, third)
+	}
+}
+
+func TestObservableConformance(t *testing.T) {
+	omaptest.RunConformance(t, func() omap.OMap[string, int] {
+		return omap.NewObservable(omap.NewOMapLinked[string, int]())
+	})
+}
+
+func TestEventTypeString(t *testing.T) {
+	for typ, expected := range map[omap.EventType]string{
+		omap.EventInserted: "inserted",
+		omap.EventUpdated:  "updated",
+		omap.EventDeleted:  "deleted",
+		omap.EventMoved:    "moved",
+		0:                  "unknown",
+		42:                 "unknown",
+	} {
+		if s := typ.String(); s != expected {
+			t.Errorf("expected %q for %d, found %q", expected, int(typ), s)
+		}
+	}
+}
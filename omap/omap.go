@@ -79,4 +79,12 @@ var (
 	ErrInvalidIteratorPos  = fmt.Errorf("%w: iterator not positionated in a valid entry (either BOF or EOF)", ErrOMap)
 	ErrInvalidIteratorKey  = fmt.Errorf("%w: iterator seems valid but given key not found in the map anymore (concurrent access?)", ErrOMap)
 	ErrKeyNotFound         = fmt.Errorf("%w: key not found", ErrOMap)
+	ErrInvalidBinary       = fmt.Errorf("%w: invalid binary data", ErrOMap)
+	ErrUnknownKeys         = fmt.Errorf("%w: unknown keys", ErrOMap)
+	ErrInvalidFlag         = fmt.Errorf("%w: invalid flag value", ErrOMap)
+	ErrDuplicateKey        = fmt.Errorf("%w: duplicate key", ErrOMap)
+	ErrBrokenInvariant     = fmt.Errorf("%w: broken invariant, internal structures of the map are inconsistent", ErrOMap)
+	ErrInvalidRecord       = fmt.Errorf("%w: invalid record, can't replay the log", ErrOMap)
+	ErrReplayDivergence    = fmt.Errorf("%w: replay diverged from the recorded log", ErrOMap)
+	ErrInvalidXMLName      = fmt.Errorf("%w: key is not a valid XML name", ErrOMap)
 )
@@ -1,6 +1,7 @@
 package omap_test
 
 import (
+	"bytes"
 	"fmt"
 	"strconv"
 	"testing"
@@ -157,6 +158,11 @@ func FuzzOMapImpls(f *testing.F) {
 		for i, op := range ops {
 			op(t, maps, keys[i], vals[i])
 			opsDebug[i] = fmt.Sprintf("%s(%q,%v)", opsDebug[i], keys[i], vals[i])
+			for _, m := range maps {
+				if err := omap.CheckInvariants(m); err != nil {
+					t.Fatalf("%T: %v, operations: %v", m, err, opsDebug[:i+1])
+				}
+			}
 		}
 		// Iterate over all maps and see if they match perfectly
 		if !validateMapsEquality(t, maps) {
@@ -187,3 +193,101 @@ func FuzzOMapImpls(f *testing.F) {
 		}
 	})
 }
+
+// Wraps an OMap making Key and Value panic on invalid positions (BOF/EOF), where their behavior
+// is undefined and differs among implementations.
+type strictOMap struct {
+	omap.OMap[string, int]
+}
+
+type strictIterator struct {
+	omap.OMapIterator[string, int]
+}
+
+func (m strictOMap) Iterator() omap.OMapIterator[string, int] {
+	return &strictIterator{m.OMap.Iterator()}
+}
+
+func (m strictOMap) GetIteratorAt(key string) omap.OMapIterator[string, int] {
+	return &strictIterator{m.OMap.GetIteratorAt(key)}
+}
+
+func (m strictOMap) PutAfter(it omap.OMapIterator[string, int], key string, value int) error {
+	if sit, ok := it.(*strictIterator); ok {
+		it = sit.OMapIterator
+	}
+	return m.OMap.PutAfter(it, key, value)
+}
+
+func (it *strictIterator) Key() string {
+	if !it.IsValid() {
+		panic("Key called at invalid position")
+	}
+	return it.OMapIterator.Key()
+}
+
+func (it *strictIterator) Value() int {
+	if !it.IsValid() {
+		panic("Value called at invalid position")
+	}
+	return it.OMapIterator.Value()
+}
+
+func (it *strictIterator) MoveFront() omap.OMapIterator[string, int] {
+	it.OMapIterator.MoveFront()
+	return it
+}
+
+func (it *strictIterator) MoveBack() omap.OMapIterator[string, int] {
+	it.OMapIterator.MoveBack()
+	return it
+}
+
+// Replay a log of OMapRecorder on OMapLinked, recording it again, so the log has the results of
+// OMapLinked up to the first divergence or undefined behavior (if any), and then replay the new
+// log on the other ordered implementations, which must give the same results. A trace recorded in
+// production (see omap.NewRecorder) can be added as a seed at testdata/fuzz/FuzzOMapReplay, in
+// the format:
+//
+//	go test fuzz v1
+//	[]byte("{\"op\":\"Put\",\"k\":\"a\",\"v\":1}\n...")
+func FuzzOMapReplay(f *testing.F) {
+	f.Add([]byte(`{"op":"Put","k":"a","v":1}
+{"op":"Put","k":"b","v":2}
+{"op":"Iterator","it":1}
+{"op":"Next","it":1,"ok":true}
+{"op":"PutAfter","it":1,"k":"c","v":3,"ok":true}
+{"op":"Key","it":1,"k":"a"}
+{"op":"Next","it":1,"ok":true}
+{"op":"Value","it":1,"v":3}
+{"op":"Len","n":3}`))
+	f.Add([]byte(`{"op":"Put","k":"a","v":1}
+{"op":"Put","k":"b","v":2}
+{"op":"GetIteratorAt","it":1,"k":"b"}
+{"op":"Delete","k":"b"}
+{"op":"PutAfter","it":1,"k":"c","v":3}
+{"op":"Prev","it":1,"ok":true}
+{"op":"Get","k":"b"}
+{"op":"Iterator","it":2}
+{"op":"MoveBack","it":2}
+{"op":"Prev","it":2,"ok":true}
+{"op":"IsValid","it":2,"ok":true}
+{"op":"EOF","it":2}
+{"op":"MoveFront","it":2}
+{"op":"PutAfter","it":2,"k":"d","v":4,"ok":true}`))
+	f.Fuzz(func(t *testing.T, trace []byte) {
+		var log bytes.Buffer
+		// divergences from the trace are expected, as its results are arbitrary
+		_ = omap.Replay(bytes.NewReader(trace), func() omap.OMap[string, int] {
+			return omap.NewRecorder[string, int](strictOMap{omap.NewOMapLinked[string, int]()}, &log)
+		})
+		for _, impl := range implementations {
+			// iterators of OMapSimple are positions in a slice, so they move when keys are deleted
+			if impl.isOrdered && impl.name != implSimple {
+				if err := omap.Replay(bytes.NewReader(log.Bytes()), impl.initializerStrInt); err != nil {
+					t.Fatalf("%s: %v, log:\n%s", impl.name, err, log.String())
+				}
+			}
+		}
+	})
+}
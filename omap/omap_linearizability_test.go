@@ -0,0 +1,490 @@
+package omap_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	lin "github.com/matheusoliveira/go-ordered-map/internal/linearizability"
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+//// Sequential model of an OMap, to check histories of concurrent calls on OMapSync ////
+
+const linClients = 4
+
+type linOpKind int
+
+const (
+	linPut linOpKind = iota
+	linGet
+	linDelete
+	linLen
+	linSnapshot // json.Marshal, the only way to iterate the whole map atomically
+	// operations on the iterator of the client
+	linIterator      // Iterator()
+	linIteratorAt    // GetIteratorAt(key), ok is IsValid()
+	linNext          // Next(), along with Key() if ok
+	linPrev          // Prev(), along with Key() if ok
+	linValue         // Value()
+	linPutAfter      // PutAfter(iterator, key, value), ok if no error
+	linNumOperations // number of kinds
+)
+
+type linInput struct {
+	client int
+	kind   linOpKind
+	key    string
+	value  int
+}
+
+type linOutput struct {
+	key      string
+	value    int
+	ok       bool
+	snapshot []th.KeyValue[string, int]
+}
+
+// An entry of the map, live while in linState.order. As OMapLinked, a deleted entry keeps the
+// value and the links it had when deleted, so iterators positioned on it can still move.
+type linEntry struct {
+	key        string
+	value      int
+	deleted    bool
+	next, prev int
+}
+
+// An iterator of a client, positioned at an entry id (0 is none, at EOF unless bof).
+type linCursor struct {
+	cursor int
+	bof    bool
+}
+
+// state is the content of the map, each entry identified by its position in entries plus one,
+// and the iterators of the clients
+type linState struct {
+	entries []linEntry
+	order   []int
+	iters   [linClients]linCursor
+}
+
+func (s linState) clone() linState {
+	return linState{
+		entries: append([]linEntry{}, s.entries...),
+		order:   append([]int{}, s.order...),
+		iters:   s.iters,
+	}
+}
+
+// return the id of the live entry of key, or 0 if not found
+func (s linState) find(key string) int {
+	for _, id := range s.order {
+		if s.entries[id-1].key == key {
+			return id
+		}
+	}
+	return 0
+}
+
+// return the entry after (step 1) or before (step -1) id
+func (s linState) move(id, step int) int {
+	if e := s.entries[id-1]; e.deleted {
+		if step > 0 {
+			return e.next
+		}
+		return e.prev
+	}
+	for i, o := range s.order {
+		if o == id {
+			if i+step >= 0 && i+step < len(s.order) {
+				return s.order[i+step]
+			}
+			break
+		}
+	}
+	return 0
+}
+
+// delete key from the cloned state s, if found
+func (s *linState) delete(key string) {
+	id := s.find(key)
+	if id == 0 {
+		return
+	}
+	e := &s.entries[id-1]
+	e.deleted, e.next, e.prev = true, s.move(id, 1), s.move(id, -1)
+	for i, o := range s.order {
+		if o == id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// insert a new entry in the cloned state s at position pos of order
+func (s *linState) insert(pos int, key string, value int) {
+	s.entries = append(s.entries, linEntry{key: key, value: value})
+	s.order = append(s.order[:pos], append([]int{len(s.entries)}, s.order[pos:]...)...)
+}
+
+func (s linState) kvs() []th.KeyValue[string, int] {
+	ret := make([]th.KeyValue[string, int], 0, len(s.order))
+	for _, id := range s.order {
+		ret = append(ret, th.KeyValue[string, int]{Key: s.entries[id-1].key, Value: s.entries[id-1].value})
+	}
+	return ret
+}
+
+func equalKVs(s1, s2 []th.KeyValue[string, int]) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+	for i := range s1 {
+		if s1[i] != s2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// apply the PutAfter at the iterator of the client to the cloned state s, returning false if
+// it must fail
+func (s *linState) putAfter(client int, key string, value int) bool {
+	it := s.iters[client]
+	pos := 0
+	if !it.bof {
+		if it.cursor == 0 || s.entries[it.cursor-1].deleted {
+			return false
+		}
+		if s.entries[it.cursor-1].key == key {
+			s.entries[it.cursor-1].value = value
+			return true
+		}
+	}
+	s.delete(key)
+	if !it.bof {
+		for i, id := range s.order {
+			if id == it.cursor {
+				pos = i + 1
+			}
+		}
+	}
+	s.insert(pos, key, value)
+	return true
+}
+
+var omapModel = lin.Model[linState, linInput, linOutput]{
+	Init: func() linState { return linState{} },
+	Step: func(s linState, in linInput, out linOutput) (bool, linState) {
+		it := s.iters[in.client]
+		switch in.kind {
+		case linPut:
+			ret := s.clone()
+			if id := s.find(in.key); id != 0 {
+				ret.entries[id-1].value = in.value
+			} else {
+				ret.insert(len(ret.order), in.key, in.value)
+			}
+			return true, ret
+		case linGet:
+			if id := s.find(in.key); id != 0 {
+				return out.ok && out.value == s.entries[id-1].value, s
+			}
+			return !out.ok, s
+		case linDelete:
+			ret := s.clone()
+			ret.delete(in.key)
+			return true, ret
+		case linLen:
+			return out.value == len(s.order), s
+		case linSnapshot:
+			return equalKVs(s.kvs(), out.snapshot), s
+		case linIterator:
+			ret := s.clone()
+			ret.iters[in.client] = linCursor{bof: true}
+			return true, ret
+		case linIteratorAt:
+			ret := s.clone()
+			ret.iters[in.client] = linCursor{cursor: s.find(in.key)}
+			return out.ok == (ret.iters[in.client].cursor != 0), ret
+		case linNext, linPrev:
+			ret := s.clone()
+			if in.kind == linNext && it.bof {
+				it = linCursor{}
+				if len(s.order) > 0 {
+					it.cursor = s.order[0]
+				}
+			} else if in.kind == linNext {
+				it.cursor = s.move(it.cursor, 1)
+			} else if !it.bof && it.cursor == 0 {
+				if len(s.order) > 0 {
+					it.cursor = s.order[len(s.order)-1]
+				}
+			} else if !it.bof {
+				it.cursor = s.move(it.cursor, -1)
+			}
+			if in.kind == linPrev && it.cursor == 0 {
+				it.bof = true
+			}
+			ret.iters[in.client] = it
+			if valid := !it.bof && it.cursor != 0; valid != out.ok {
+				return false, s
+			} else if valid {
+				return out.key == s.entries[it.cursor-1].key, ret
+			}
+			return true, ret
+		case linValue:
+			return out.value == s.entries[it.cursor-1].value, s
+		default: // linPutAfter
+			ret := s.clone()
+			return ret.putAfter(in.client, in.key, in.value) == out.ok, ret
+		}
+	},
+	Equal: func(s1, s2 linState) bool {
+		if len(s1.entries) != len(s2.entries) || len(s1.order) != len(s2.order) || s1.iters != s2.iters {
+			return false
+		}
+		for i := range s1.entries {
+			if s1.entries[i] != s2.entries[i] {
+				return false
+			}
+		}
+		for i := range s1.order {
+			if s1.order[i] != s2.order[i] {
+				return false
+			}
+		}
+		return true
+	},
+	Describe: func(in linInput, out linOutput) string {
+		switch in.kind {
+		case linPut:
+			return fmt.Sprintf("Put(%q, %d)", in.key, in.value)
+		case linGet:
+			return fmt.Sprintf("Get(%q) = %d, %v", in.key, out.value, out.ok)
+		case linDelete:
+			return fmt.Sprintf("Delete(%q)", in.key)
+		case linLen:
+			return fmt.Sprintf("Len() = %d", out.value)
+		case linSnapshot:
+			return fmt.Sprintf("Snapshot() = %v", out.snapshot)
+		case linIterator:
+			return fmt.Sprintf("it%d = Iterator()", in.client)
+		case linIteratorAt:
+			return fmt.Sprintf("it%d = GetIteratorAt(%q) valid=%v", in.client, in.key, out.ok)
+		case linNext:
+			return fmt.Sprintf("it%d.Next() = %v key=%q", in.client, out.ok, out.key)
+		case linPrev:
+			return fmt.Sprintf("it%d.Prev() = %v key=%q", in.client, out.ok, out.key)
+		case linValue:
+			return fmt.Sprintf("it%d.Value() = %d", in.client, out.value)
+		default:
+			return fmt.Sprintf("PutAfter(it%d, %q, %d) ok=%v", in.client, in.key, in.value, out.ok)
+		}
+	},
+}
+
+// decode the JSON of a map into its key/values, in order
+func snapshotKVs(t *testing.T, m omap.OMap[string, int]) []th.KeyValue[string, int] {
+	ret := []th.KeyValue[string, int]{}
+	b, err := json.Marshal(m)
+	th.AssertErrNil(t, err, "unexpected error on json.Marshal")
+	th.AssertErrNil(t, omap.UnmarshalJSON(func(k string, v int) {
+		ret = append(ret, th.KeyValue[string, int]{Key: k, Value: v})
+	}, b), "unexpected error on omap.UnmarshalJSON")
+	return ret
+}
+
+// A client running operations on a map, keeping track of the position of its iterator (which
+// depends only on its own results), so it calls only the iterator operations defined for it:
+// Next is not defined at EOF and Value only at a valid position.
+type linClient struct {
+	m     omap.OMap[string, int]
+	it    omap.OMapIterator[string, int]
+	valid bool
+	eof   bool
+}
+
+// return in, replaced by another operation if not defined at the current iterator position
+func (c *linClient) fix(in linInput) linInput {
+	switch {
+	case c.it == nil && in.kind >= linIterator:
+		in.kind = linIterator
+	case in.kind == linNext && c.eof:
+		in.kind = linPrev
+	case in.kind == linValue && !c.valid:
+		in.kind = linIteratorAt
+	}
+	return in
+}
+
+func (c *linClient) run(t *testing.T, in linInput) linOutput {
+	var out linOutput
+	switch in.kind {
+	case linPut:
+		c.m.Put(in.key, in.value)
+	case linGet:
+		out.value, out.ok = c.m.Get(in.key)
+	case linDelete:
+		c.m.Delete(in.key)
+	case linLen:
+		out.value = c.m.Len()
+	case linSnapshot:
+		out.snapshot = snapshotKVs(t, c.m)
+	case linIterator:
+		c.it, c.valid, c.eof = c.m.Iterator(), false, false
+	case linIteratorAt:
+		c.it = c.m.GetIteratorAt(in.key)
+		out.ok = c.it.IsValid()
+		c.valid, c.eof = out.ok, !out.ok
+	case linNext, linPrev:
+		if in.kind == linNext {
+			out.ok = c.it.Next()
+		} else {
+			out.ok = c.it.Prev()
+		}
+		if out.ok {
+			out.key = c.it.Key()
+		}
+		c.valid, c.eof = out.ok, !out.ok && in.kind == linNext
+	case linValue:
+		out.value = c.it.Value()
+	case linPutAfter:
+		out.ok = c.m.PutAfter(c.it, in.key, in.value) == nil
+	}
+	return out
+}
+
+// Run concurrent operations on OMapSync, including iterations, and check if the history is
+// linearizable, which is most useful when executed with `-race`. Values are unique, so each read
+// can be tracked back to the write that produced it.
+func TestOMapSyncLinearizability(t *testing.T) {
+	const (
+		nRounds = 20
+		nOps    = 25
+		nKeys   = 4
+	)
+	for round := 0; round < nRounds; round++ {
+		m := omap.NewOMapSync[string, int]()
+		h := lin.NewHistory[linInput, linOutput](linClients)
+		var wg sync.WaitGroup
+		for c := 0; c < linClients; c++ {
+			wg.Add(1)
+			go func(c int) {
+				defer wg.Done()
+				client := &linClient{m: m}
+				for i := 0; i < nOps; i++ {
+					n := round*linClients*nOps + c*nOps + i
+					in := client.fix(linInput{
+						client: c,
+						kind:   linOpKind((n*7 + c) % int(linNumOperations)),
+						key:    strconv.Itoa((n * 3) % nKeys),
+						value:  n,
+					})
+					h.Record(c, in, func() linOutput { return client.run(t, in) })
+				}
+			}(c)
+		}
+		wg.Wait()
+		th.AssertErrNil(t, omap.CheckInvariants(m), "invariants broken after concurrent operations")
+		if err := lin.Check(omapModel, h.Operations()); err != nil {
+			t.Fatalf("round %d: %v", round, err)
+		}
+	}
+}
+
+// record the operations on a single client, returning the history
+func linSequential(t *testing.T, m omap.OMap[string, int], ops []linInput, between func(i int)) *lin.History[linInput, linOutput] {
+	h := lin.NewHistory[linInput, linOutput](linClients)
+	clients := make([]*linClient, linClients)
+	for i, in := range ops {
+		if clients[in.client] == nil {
+			clients[in.client] = &linClient{m: m}
+		}
+		h.Record(in.client, in, func() linOutput { return clients[in.client].run(t, in) })
+		if between != nil {
+			between(i)
+		}
+	}
+	return h
+}
+
+func TestOMapModelDetectsViolation(t *testing.T) {
+	m := omap.NewOMapLinked[string, int]()
+	h := linSequential(t, m, []linInput{
+		{kind: linPut, key: "a", value: 1},
+		{kind: linPut, key: "b", value: 2},
+		{kind: linIteratorAt, key: "b"},
+		{kind: linPutAfter, key: "a", value: 3},
+		{kind: linPutAfter, key: "b", value: 4},
+		{kind: linIteratorAt, key: "x"},
+		{kind: linPutAfter, key: "c", value: 5},
+		{kind: linGet, key: "a"},
+		{kind: linLen},
+		{kind: linSnapshot},
+		{kind: linDelete, key: "a"},
+		{kind: linGet, key: "a"},
+	}, func(i int) {
+		if i == 9 {
+			// a write not recorded in the history, so the last snapshot is invalid
+			m.Put("b", 42)
+		}
+	})
+	th.AssertErrNil(t, lin.Check(omapModel, h.Operations()[:10]), "expected sequential history to be linearizable")
+	h.Record(0, linInput{kind: linSnapshot}, func() linOutput { return linOutput{snapshot: snapshotKVs(t, m)} })
+	th.AssertErrIs(t, lin.Check(omapModel, h.Operations()), lin.ErrNotLinearizable, "expected unrecorded write to be detected")
+	// PutAfter results must match the position of the iterator
+	for _, out := range []bool{true, false} {
+		ops := []linOperation{
+			{Client: 0, Input: linInput{kind: linPut, key: "a", value: 1}, Call: 1, Return: 2},
+			{Client: 0, Input: linInput{kind: linIteratorAt, key: "a"}, Output: linOutput{ok: true}, Call: 3, Return: 4},
+			{Client: 0, Input: linInput{kind: linDelete, key: "a"}, Call: 5, Return: 6},
+			{Client: 0, Input: linInput{kind: linPutAfter, key: "b", value: 2}, Output: linOutput{ok: out}, Call: 7, Return: 8},
+		}
+		err := lin.Check(omapModel, ops)
+		if out {
+			th.AssertErrIs(t, err, lin.ErrNotLinearizable, "expected PutAfter at deleted entry to fail")
+		} else {
+			th.AssertErrNil(t, err, "expected PutAfter at deleted entry to fail")
+		}
+	}
+}
+
+type linOperation = lin.Operation[linInput, linOutput]
+
+// Iterations on OMapSync interleaved with changes must match the model, see OMapSyncIterator
+func TestOMapModelIteration(t *testing.T) {
+	m := omap.NewOMapSync[string, int]()
+	h := linSequential(t, m, []linInput{
+		{kind: linPut, key: "a", value: 1},
+		{kind: linPut, key: "b", value: 2},
+		{kind: linPut, key: "c", value: 3},
+		{client: 1, kind: linIterator},
+		{client: 1, kind: linPrev},
+		{kind: linIterator},
+		{kind: linDelete, key: "a"}, // the first entry changes after Iterator
+		{kind: linNext},
+		{kind: linDelete, key: "b"}, // deleted at the iterator
+		{kind: linPut, key: "b", value: 4},
+		{kind: linValue},
+		{kind: linPutAfter, key: "x", value: 5},
+		{kind: linNext},
+		{kind: linValue},
+		{kind: linPutAfter, key: "a", value: 6},
+		{kind: linNext},
+		{kind: linNext},
+		{kind: linNext},
+		{kind: linPrev},
+		{kind: linPrev},
+		{client: 1, kind: linIteratorAt, key: "x"},
+		{client: 1, kind: linPrev},
+		{client: 1, kind: linPutAfter, key: "d", value: 7},
+		{client: 1, kind: linValue},
+		{kind: linSnapshot},
+	}, nil)
+	th.AssertErrNil(t, lin.Check(omapModel, h.Operations()), "expected iteration to match the model")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["c",3],["a",6],["b",4],["d",7]]`))
+}
@@ -12,6 +12,7 @@ import (
 
 	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
 	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omap/omaptest"
 )
 
 const (
@@ -20,6 +21,7 @@ const (
 	implLinked     = "Linked"
 	implLinkedHash = "LinkedHash"
 	implSync       = "Sync"
+	implFolded     = "Folded"
 )
 
 type implDetail struct {
@@ -73,6 +75,14 @@ func init() {
 			func() omap.OMap[string, int] { return omap.NewOMapSync[string, int]() },
 			func() omap.OMap[LargeObject, int] { return omap.NewOMapSync[LargeObject, int]() },
 		},
+		{
+			implFolded,
+			true,
+			false,
+			// identity fold, so it must behave as any other implementation, see omapfolded_test.go
+			func() omap.OMap[string, int] { return omap.NewOMapFolded[int](func(s string) string { return s }) },
+			nil, // only string keys are supported
+		},
 	}
 }
 
@@ -204,13 +214,18 @@ func TestJSONFailurePaths(t *testing.T) {
 			case implSync:
 				mKeyInvalid = omap.NewOMapSync[failonly, string]()
 				mValInvalid = omap.NewOMapSync[string, failonly]()
+			case implFolded:
+				// only string keys are supported
+				mValInvalid = omap.NewOMapFolded[failonly](nil)
 			default:
 				t.Errorf("method not available for Unmarshal: %s", impl.name)
 			}
-			mKeyInvalid.Put(failonly{"hello"}, "hello")
 			mValInvalid.Put("world", failonly{"world"})
-			if _, err := json.Marshal(mKeyInvalid); err == nil {
-				t.Error("expected error with invalid key")
+			if mKeyInvalid != nil {
+				mKeyInvalid.Put(failonly{"hello"}, "hello")
+				if _, err := json.Marshal(mKeyInvalid); err == nil {
+					t.Error("expected error with invalid key")
+				}
 			}
 			if _, err := json.Marshal(mValInvalid); err == nil {
 				t.Error("expected error with invalid value")
@@ -257,6 +272,10 @@ func TestUnmarshalJSON(t *testing.T) {
 				p := make([]parent[*omap.OMapSync[string, []person]], 0)
 				errUnmarshal = json.Unmarshal(data, &p)
 				redec, errMarshal = json.Marshal(p)
+			case implFolded:
+				p := make([]parent[*omap.OMapFolded[[]person]], 0)
+				errUnmarshal = json.Unmarshal(data, &p)
+				redec, errMarshal = json.Marshal(p)
 			default:
 				t.Errorf("method not available for Unmarshal: %s", impl.name)
 			}
@@ -370,6 +389,7 @@ func TestDelete(t *testing.T) {
 			th.ValidateIterator(t, m.Iterator(), impl.isOrdered, th.JsonToKV[string, int](`[["d",3]]`))
 			m.Delete("d")
 			th.ValidateIterator(t, m.Iterator(), impl.isOrdered, th.JsonToKV[string, int](`[]`))
+			th.AssertErrNil(t, omap.CheckInvariants(m), "unexpected broken invariant")
 		})
 	}
 }
@@ -591,6 +611,7 @@ func TestPutAfter(t *testing.T) {
 				th.AssertErrNil(t, m.PutAfter(it, kUpper, 42), "")
 			}
 			th.ValidateIterator(t, m.Iterator(), impl.isOrdered, th.JsonToKV[string, int](`[["HEAD",42],["FOO",42],["foo",1],["BAR",42],["bar",2],["BAZ",42],["baz",3],["TAIL", 42]]`))
+			th.AssertErrNil(t, omap.CheckInvariants(m), "unexpected broken invariant")
 		})
 	}
 }
@@ -639,6 +660,18 @@ func TestMove(t *testing.T) {
 			th.AssertErrIs(t, omap.MoveAfter(m, "what", "a"), omap.ErrKeyNotFound, "expecing MoveAfter with invalid targetKey to fail with ErrKeyNotFound")
 			th.AssertErrIs(t, omap.MoveBefore(m, "a", "what"), omap.ErrKeyNotFound, "expecing MoveBefore with invalid refKey to fail with ErrKeyNotFound")
 			th.AssertErrIs(t, omap.MoveBefore(m, "what", "a"), omap.ErrKeyNotFound, "expecing MoveBefore with invalid targetKey to fail with ErrKeyNotFound")
+			th.AssertErrNil(t, omap.CheckInvariants(m), "unexpected broken invariant")
+		})
+	}
+}
+
+func TestConformance(t *testing.T) {
+	for _, impl := range implementations {
+		if !impl.isOrdered {
+			continue
+		}
+		t.Run(impl.name, func(t *testing.T) {
+			omaptest.RunConformance(t, impl.initializerStrInt)
 		})
 	}
 }
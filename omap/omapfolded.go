@@ -0,0 +1,171 @@
+package omap
+
+import (
+	"fmt"
+	"strings"
+)
+
+//// OMapFolded ////
+
+// Implements an OMap with string keys where lookups are done on the keys normalized by a fold
+// function (e.g. strings.ToLower for case-insensitive keys), while iteration and serialization
+// return the keys spelled as first inserted.
+// So, after Put("Content-Type", v), Get("content-type") finds the entry, a following
+// Put("CONTENT-TYPE", v2) updates it in place, and iterating still returns "Content-Type".
+// Internally it keeps an OMapLinked indexed by the folded keys.
+type OMapFolded[V any] struct {
+	fold func(string) string
+	om   OMap[string, foldedEntry[V]]
+}
+
+type foldedEntry[V any] struct {
+	key   string
+	value V
+}
+
+// Iterator over a OMapFolded, should be created through OMapFolded.Iterator() function.
+type OMapFoldedIterator[V any] struct {
+	it OMapIterator[string, foldedEntry[V]]
+	m  *OMapFolded[V]
+}
+
+// Create a new OMap using OMapFolded implementation, using the given fold function to normalize
+// the keys for lookups. If fold is nil, strings.ToLower is used.
+func NewOMapFolded[V any](fold func(string) string) OMap[string, V] {
+	m := &OMapFolded[V]{fold: fold}
+	m.init()
+	return m
+}
+
+func (m *OMapFolded[V]) init() {
+	if m.fold == nil {
+		m.fold = strings.ToLower
+	}
+	m.om = NewOMapLinked[string, foldedEntry[V]]()
+}
+
+// Return the spelling to be used for key: the one already in the map for the same folded key, if
+// any, or key itself otherwise.
+func (m *OMapFolded[V]) spelling(folded string, key string) string {
+	if old, ok := m.om.Get(folded); ok {
+		return old.key
+	}
+	return key
+}
+
+// Add/overwrite the value in the map on the given key. If an entry with the same folded key
+// exists, its value is updated in place and the original spelling of the key is kept.
+func (m *OMapFolded[V]) Put(key string, value V) {
+	folded := m.fold(key)
+	m.om.Put(folded, foldedEntry[V]{m.spelling(folded, key), value})
+}
+
+// Add a given key/value to the map, after the entry pointed by it. If an entry with the same
+// folded key exists, it is moved and the original spelling of the key is kept.
+func (m *OMapFolded[V]) PutAfter(interfaceIt OMapIterator[string, V], key string, value V) error {
+	if it, ok := interfaceIt.(*OMapFoldedIterator[V]); !ok {
+		return fmt.Errorf("%w - expected OMapFoldedIterator found %T", ErrInvalidIteratorType, interfaceIt)
+	} else if it.m != m {
+		return ErrInvalidIteratorMap
+	} else {
+		folded := m.fold(key)
+		return m.om.PutAfter(it.it, folded, foldedEntry[V]{m.spelling(folded, key), value})
+	}
+}
+
+// Get the value pointing to the given key, compared by the folded keys.
+func (m *OMapFolded[V]) Get(key string) (V, bool) {
+	entry, ok := m.om.Get(m.fold(key))
+	return entry.value, ok
+}
+
+func (m *OMapFolded[V]) GetIteratorAt(key string) OMapIterator[string, V] {
+	return &OMapFoldedIterator[V]{it: m.om.GetIteratorAt(m.fold(key)), m: m}
+}
+
+// Delete the value pointing to the given key, compared by the folded keys.
+func (m *OMapFolded[V]) Delete(key string) {
+	m.om.Delete(m.fold(key))
+}
+
+// Return an iterator to navigate the map.
+func (m *OMapFolded[V]) Iterator() OMapIterator[string, V] {
+	return &OMapFoldedIterator[V]{it: m.om.Iterator(), m: m}
+}
+
+// Implement Validator interface, checking the underlying map and that each key is indexed by its
+// folded form.
+func (m *OMapFolded[V]) Validate() error {
+	if err := CheckInvariants(m.om); err != nil {
+		return err
+	}
+	errs := &InvariantError{Type: "omap.OMapFolded"}
+	for it := m.om.Iterator(); it.Next(); {
+		if folded := m.fold(it.Value().key); folded != it.Key() {
+			errs.Addf("key %q is indexed as %q instead of %q", it.Value().key, it.Key(), folded)
+		}
+	}
+	return errs.Err()
+}
+
+func (m *OMapFolded[V]) Len() int {
+	return m.om.Len()
+}
+
+// Implement fmt.Stringer interface.
+func (m *OMapFolded[V]) String() string {
+	return IteratorToString[string, V]("omap.OMapFolded", m.Iterator())
+}
+
+// Implement json.Marshaler interface.
+func (m *OMapFolded[V]) MarshalJSON() ([]byte, error) {
+	return MarshalJSON[string, V](m.Iterator())
+}
+
+// Implement json.Unmarshaler interface. Keys that fold to the same value are merged, keeping the
+// spelling of the first one and the value of the last one. The fold function is kept, or
+// strings.ToLower is used for a zero value OMapFolded.
+func (m *OMapFolded[V]) UnmarshalJSON(b []byte) error {
+	m.init()
+	return UnmarshalJSON[string, V](m.Put, b)
+}
+
+// Implement JSONMerger interface, see UnmarshalJSONInto.
+func (m *OMapFolded[V]) MergeJSON(b []byte, opts MergeOptions) error {
+	return UnmarshalJSONInto[string, V](m, b, opts)
+}
+
+func (it *OMapFoldedIterator[V]) Next() bool {
+	return it.it.Next()
+}
+
+func (it *OMapFoldedIterator[V]) EOF() bool {
+	return it.it.EOF()
+}
+
+// Return the key at current record, spelled as first inserted.
+func (it *OMapFoldedIterator[V]) Key() string {
+	return it.it.Value().key
+}
+
+func (it *OMapFoldedIterator[V]) Value() V {
+	return it.it.Value().value
+}
+
+func (it *OMapFoldedIterator[V]) IsValid() bool {
+	return it.it.IsValid()
+}
+
+func (it *OMapFoldedIterator[V]) MoveFront() OMapIterator[string, V] {
+	it.it.MoveFront()
+	return it
+}
+
+func (it *OMapFoldedIterator[V]) MoveBack() OMapIterator[string, V] {
+	it.it.MoveBack()
+	return it
+}
+
+func (it *OMapFoldedIterator[V]) Prev() bool {
+	return it.it.Prev()
+}
@@ -0,0 +1,70 @@
+package omap_test
+
+import (
+	"encoding/json"
+	"net/textproto"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+func TestOMapFolded(t *testing.T) {
+	m := omap.NewOMapFolded[int](nil)
+	m.Put("Content-Type", 1)
+	m.Put("Accept", 2)
+	m.Put("CONTENT-TYPE", 3)
+	if v, ok := m.Get("content-type"); !ok || v != 3 {
+		t.Errorf("expected 3 for \"content-type\", found %d (%v)", v, ok)
+	}
+	if it := m.GetIteratorAt("ACCEPT"); !it.IsValid() || it.Key() != "Accept" || it.Value() != 2 {
+		t.Error("expected GetIteratorAt(\"ACCEPT\") to point to Accept")
+	}
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["Content-Type",3],["Accept",2]]`))
+	// PutAfter keeps the spelling, either moving or updating in place
+	th.AssertErrNil(t, m.PutAfter(m.GetIteratorAt("accept"), "content-type", 4), "unexpected error on PutAfter")
+	th.AssertErrNil(t, m.PutAfter(m.GetIteratorAt("accept"), "ACCEPT", 5), "unexpected error on PutAfter")
+	th.AssertErrNil(t, m.PutAfter(m.Iterator(), "Host", 6), "unexpected error on PutAfter")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["Host",6],["Accept",5],["Content-Type",4]]`))
+	// move helpers
+	th.AssertErrNil(t, omap.MoveFirst(m, "CONTENT-type"), "unexpected error on MoveFirst")
+	th.AssertErrNil(t, omap.MoveAfter(m, "host", "accept"), "unexpected error on MoveAfter")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["Content-Type",4],["Accept",5],["Host",6]]`))
+	// a new spelling is used after delete
+	m.Delete("HOST")
+	m.Put("HOST", 7)
+	if res, _ := json.Marshal(m); string(res) != `{"Content-Type":4,"Accept":5,"HOST":7}` {
+		t.Errorf("unexpected JSON: %s", res)
+	}
+	if m.Len() != 3 {
+		t.Errorf("expected len 3, found %d", m.Len())
+	}
+	if s := m.(*omap.OMapFolded[int]).String(); s != "omap.OMapFolded[Content-Type:4 Accept:5 HOST:7]" {
+		t.Errorf("unexpected String(): %s", s)
+	}
+	// iterator navigation
+	it := m.Iterator().MoveBack()
+	if !it.Prev() || it.Key() != "HOST" || it.EOF() {
+		t.Error("expected Prev to be at HOST")
+	}
+	if it.MoveFront(); !it.Next() || it.Key() != "Content-Type" {
+		t.Error("expected Next to be at Content-Type")
+	}
+}
+
+func TestOMapFoldedCustom(t *testing.T) {
+	m := omap.NewOMapFolded[string](textproto.CanonicalMIMEHeaderKey)
+	th.AssertErrNil(t, json.Unmarshal([]byte(`{"x-request-id":"a","X-REQUEST-ID":"b","etag":"c"}`), m), "unexpected error on Unmarshal")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, string](`[["x-request-id","b"],["etag","c"]]`))
+	th.AssertErrNil(t, m.(omap.JSONMerger).MergeJSON([]byte(`{"ETag":"d"}`), omap.MergeOptions{}), "unexpected error on MergeJSON")
+	if v, _ := m.Get("ETAG"); v != "d" {
+		t.Errorf("expected \"d\", found %q", v)
+	}
+	// zero value can be used for unmarshal, with the default fold
+	var zero omap.OMapFolded[int]
+	th.AssertErrNil(t, json.Unmarshal([]byte(`{"A":1,"a":2}`), &zero), "unexpected error on Unmarshal")
+	th.ValidateIterator(t, zero.Iterator(), true, th.JsonToKV[string, int](`[["A",2]]`))
+	// PutAfter errors
+	th.AssertErrIs(t, zero.PutAfter(omap.New[string, int]().Iterator(), "x", 1), omap.ErrInvalidIteratorType, "expected error with invalid iterator")
+	th.AssertErrIs(t, zero.PutAfter(omap.NewOMapFolded[int](nil).Iterator(), "x", 1), omap.ErrInvalidIteratorMap, "expected error with iterator of another map")
+}
@@ -1,6 +1,7 @@
 package omap
 
 import (
+	"encoding/xml"
 	"fmt"
 )
 
@@ -159,6 +160,26 @@ func (m *OMapLinked[K, V]) Len() int {
 	return len(m.m)
 }
 
+// Implement Validator interface, checking that the linked list is consistent in both directions
+// and that every entry is in the lookup map and vice versa.
+// Complexity: O(n).
+func (m *OMapLinked[K, V]) Validate() error {
+	errs := &InvariantError{Type: "omap.OMapLinked"}
+	validateList(errs, m.head, m.tail, len(m.m), func(i int, e *mapEntry[K, V]) {
+		if m.m[e.key] != e {
+			errs.Addf("entry %d of key %v is not the one in the lookup map", i, e.key)
+		}
+	})
+	for key, e := range m.m {
+		if e == nil {
+			errs.Addf("lookup map has a nil entry for key %v", key)
+		} else if e.key != key {
+			errs.Addf("lookup map has key %v pointing to entry of key %v", key, e.key)
+		}
+	}
+	return errs.Err()
+}
+
 // Implement fmt.Stringer
 func (m *OMapLinked[K, V]) String() string {
 	return IteratorToString[K, V]("omap.OMapLinked", m.Iterator())
@@ -176,6 +197,45 @@ func (m *OMapLinked[K, V]) UnmarshalJSON(b []byte) error {
 	return UnmarshalJSON[K, V](m.Put, b)
 }
 
+// Implement JSONMerger interface, see UnmarshalJSONInto.
+func (m *OMapLinked[K, V]) MergeJSON(b []byte, opts MergeOptions) error {
+	return UnmarshalJSONInto[K, V](m, b, opts)
+}
+
+// Implement encoding.BinaryMarshaler interface, see MarshalBinary. Keys and values are encoded
+// with BuiltinCodec.
+func (m OMapLinked[K, V]) MarshalBinary() ([]byte, error) {
+	return MarshalBinary(m.Iterator(), BuiltinCodec{})
+}
+
+// Implement encoding.BinaryUnmarshaler interface, see UnmarshalBinary.
+func (m *OMapLinked[K, V]) UnmarshalBinary(b []byte) error {
+	m.init()
+	return UnmarshalBinary[K, V](m.Put, b, BuiltinCodec{})
+}
+
+// Implement gob.GobEncoder interface, using same format as MarshalBinary.
+func (m OMapLinked[K, V]) GobEncode() ([]byte, error) {
+	return m.MarshalBinary()
+}
+
+// Implement gob.GobDecoder interface, using same format as UnmarshalBinary.
+func (m *OMapLinked[K, V]) GobDecode(b []byte) error {
+	return m.UnmarshalBinary(b)
+}
+
+// Implement xml.Marshaler interface, each key/value is encoded as a child element, in order.
+// See MarshalXML, and XMLMap to use other XMLOptions.
+func (m OMapLinked[K, V]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return MarshalXML(e, start, m.Iterator(), XMLOptions{})
+}
+
+// Implement xml.Unmarshaler interface, see UnmarshalXML.
+func (m *OMapLinked[K, V]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	m.init()
+	return UnmarshalXML[K, V](m.Put, d, start, XMLOptions{})
+}
+
 func (it *OMapLinkedIterator[K, V]) Next() bool {
 	if !it.bof {
 		it.cursor = it.cursor.next
@@ -261,6 +261,47 @@ func (m *OMapLinkedHash[K, V]) Len() int {
 	return m.length
 }
 
+// Implement Validator interface, checking that the linked list is consistent in both directions,
+// that every entry is in the bucket of its hash, without duplicated keys, and that length agrees
+// with both.
+// Complexity: O(n), plus O(c²) for each bucket of c colliding keys.
+func (m *OMapLinkedHash[K, V]) Validate() error {
+	errs := &InvariantError{Type: "omap.OMapLinkedHash"}
+	inBuckets := make(map[*mapEntry[*K, V]]bool, m.length)
+	count := 0
+	for hashedKey, elems := range m.m {
+		for i, e := range elems {
+			count++
+			if e == nil {
+				errs.Addf("bucket %d has a nil entry at %d", hashedKey, i)
+				continue
+			}
+			if h := m.hasher(e.key); h != hashedKey {
+				errs.Addf("entry of key %v has hash %d, but is in bucket %d", *e.key, h, hashedKey)
+			}
+			for _, other := range elems[:i] {
+				if other != nil && *other.key == *e.key {
+					errs.Addf("bucket %d has key %v more than once", hashedKey, *e.key)
+				}
+			}
+			inBuckets[e] = true
+		}
+	}
+	if count != m.length {
+		errs.Addf("length is %d, but buckets have %d entries", m.length, count)
+	}
+	validateList(errs, m.head, m.tail, m.length, func(i int, e *mapEntry[*K, V]) {
+		if !inBuckets[e] {
+			errs.Addf("entry %d of key %v is not in the lookup map", i, *e.key)
+		}
+		delete(inBuckets, e)
+	})
+	if len(inBuckets) > 0 {
+		errs.Addf("%d entries of the lookup map are not in the list", len(inBuckets))
+	}
+	return errs.Err()
+}
+
 // Implement fmt.Stringer
 func (m *OMapLinkedHash[K, V]) String() string {
 	return IteratorToString[K, V]("omap.OMapLinkedHash", m.Iterator())
@@ -278,6 +319,33 @@ func (m *OMapLinkedHash[K, V]) UnmarshalJSON(b []byte) error {
 	return UnmarshalJSON[K, V](m.Put, b)
 }
 
+// Implement JSONMerger interface, see UnmarshalJSONInto.
+func (m *OMapLinkedHash[K, V]) MergeJSON(b []byte, opts MergeOptions) error {
+	return UnmarshalJSONInto[K, V](m, b, opts)
+}
+
+// Implement encoding.BinaryMarshaler interface, see MarshalBinary. Keys and values are encoded
+// with BuiltinCodec.
+func (m OMapLinkedHash[K, V]) MarshalBinary() ([]byte, error) {
+	return MarshalBinary(m.Iterator(), BuiltinCodec{})
+}
+
+// Implement encoding.BinaryUnmarshaler interface, see UnmarshalBinary.
+func (m *OMapLinkedHash[K, V]) UnmarshalBinary(b []byte) error {
+	m.init()
+	return UnmarshalBinary[K, V](m.Put, b, BuiltinCodec{})
+}
+
+// Implement gob.GobEncoder interface, using same format as MarshalBinary.
+func (m OMapLinkedHash[K, V]) GobEncode() ([]byte, error) {
+	return m.MarshalBinary()
+}
+
+// Implement gob.GobDecoder interface, using same format as UnmarshalBinary.
+func (m *OMapLinkedHash[K, V]) GobDecode(b []byte) error {
+	return m.UnmarshalBinary(b)
+}
+
 func (it *OMapLinkedHashIterator[K, V]) Next() bool {
 	if !it.bof {
 		it.cursor = it.cursor.next
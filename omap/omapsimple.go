@@ -144,6 +144,11 @@ func (m *OMapSimple[K, V]) UnmarshalJSON(b []byte) error {
 	return UnmarshalJSON[K, V](m.Put, b)
 }
 
+// Implement JSONMerger interface, see UnmarshalJSONInto.
+func (m *OMapSimple[K, V]) MergeJSON(b []byte, opts MergeOptions) error {
+	return UnmarshalJSONInto[K, V](m, b, opts)
+}
+
 // Move iterator to the next record, returning true if there is a next value and false otherwise.
 // Complexity: in general should be O(1), but it needs to skip deleted keys, so if there M deleted
 // keys on the current position, it will be O(M). It is a trade-off to avoid making Delete O(N).
@@ -17,6 +17,19 @@ type OMapSync[K comparable, V any] struct {
 }
 
 // Iterator over a OMapSync, should be created through OMapSync.Iterator() function.
+//
+// Each call is atomic, holding the read lock of the map, but the iteration as a whole is not, so
+// when the map is changed concurrently:
+//   - Next from BOF starts at the first entry at the time of the call, as Prev from EOF starts at
+//     the last one.
+//   - Every entry returned was in the map at some point during the iteration. If the entry at
+//     the iterator is deleted, the iteration continues from the entries that followed (or, for
+//     Prev, preceded) it when it was deleted, and Value returns the last value it had.
+//   - Entries that stay in the map during the whole iteration, without being moved by PutAfter,
+//     are returned exactly once and in order.
+//   - PutAfter fails with ErrInvalidIteratorPos if the entry at the iterator was deleted.
+//
+// Use MarshalJSON to read the whole map atomically.
 type OMapSyncIterator[K comparable, V any] struct {
 	it OMapIterator[K, V]
 	m  *OMapSync[K, V]
@@ -92,13 +105,24 @@ func (m *OMapSync[K, V]) Iterator() OMapIterator[K, V] {
 	return &OMapSyncIterator[K, V]{it: m.om.Iterator(), m: m}
 }
 
+// Implement Validator interface, checking the underlying map while holding the read lock.
+func (m *OMapSync[K, V]) Validate() error {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return CheckInvariants(m.om)
+}
+
 func (m *OMapSync[K, V]) Len() int {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
 	return m.om.Len()
 }
 
 // Implement fmt.Stringer interface.
 func (m *OMapSync[K, V]) String() string {
-	return IteratorToString[K, V]("omap.OMapSync", m.Iterator())
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return IteratorToString[K, V]("omap.OMapSync", m.om.Iterator())
 }
 
 // Implement json.Marshaler interface.
@@ -118,33 +142,80 @@ func (m *OMapSync[K, V]) UnmarshalJSON(b []byte) error {
 	return err
 }
 
+// Implement JSONMerger interface, see UnmarshalJSONInto. The whole merge is done holding the
+// write lock, so concurrent readers never see a partially merged map.
+func (m *OMapSync[K, V]) MergeJSON(b []byte, opts MergeOptions) error {
+	m.init()
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return UnmarshalJSONInto(m.om, b, opts)
+}
+
+// Implement encoding.BinaryMarshaler interface, see MarshalBinary. Keys and values are encoded
+// with BuiltinCodec.
+func (m *OMapSync[K, V]) MarshalBinary() ([]byte, error) {
+	m.init()
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return MarshalBinary(m.om.Iterator(), BuiltinCodec{})
+}
+
+// Implement encoding.BinaryUnmarshaler interface, see UnmarshalBinary.
+func (m *OMapSync[K, V]) UnmarshalBinary(b []byte) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.om = New[K, V]()
+	return UnmarshalBinary[K, V](m.om.Put, b, BuiltinCodec{})
+}
+
+// Implement gob.GobEncoder interface, using same format as MarshalBinary.
+func (m *OMapSync[K, V]) GobEncode() ([]byte, error) {
+	return m.MarshalBinary()
+}
+
+// Implement gob.GobDecoder interface, using same format as UnmarshalBinary.
+func (m *OMapSync[K, V]) GobDecode(b []byte) error {
+	return m.UnmarshalBinary(b)
+}
+
 // Move iterator to the next record, returning true if there is a next value and false otherwise.
-// Complexity: in general should be O(1), but it needs to skip deleted keys, so if there M deleted
-// keys on the current position, it will be O(M). It is a trade-off to avoid making Delete O(N).
+// Complexity: O(1).
 func (it *OMapSyncIterator[K, V]) Next() bool {
 	it.m.mx.RLock()
 	defer it.m.mx.RUnlock()
+	if !it.it.IsValid() && !it.it.EOF() {
+		// at BOF, the first entry may have changed since the iterator was positioned
+		it.it.MoveFront()
+	}
 	return it.it.Next()
 }
 
 // Returns true if iterator has reached the end
 func (it OMapSyncIterator[K, V]) EOF() bool {
+	it.m.mx.RLock()
+	defer it.m.mx.RUnlock()
 	return it.it.EOF()
 }
 
 // Return the key at current record.
 // Calling this function when EOF() is true will cause a panic.
 func (it OMapSyncIterator[K, V]) Key() K {
+	it.m.mx.RLock()
+	defer it.m.mx.RUnlock()
 	return it.it.Key()
 }
 
 // Return the value at current record.
 // Calling this function when EOF() is true will cause a panic.
 func (it OMapSyncIterator[K, V]) Value() V {
+	it.m.mx.RLock()
+	defer it.m.mx.RUnlock()
 	return it.it.Value()
 }
 
 func (it OMapSyncIterator[K, V]) IsValid() bool {
+	it.m.mx.RLock()
+	defer it.m.mx.RUnlock()
 	return it.it.IsValid()
 }
 
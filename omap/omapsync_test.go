@@ -0,0 +1,43 @@
+package omap
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+// Value that checks, when formatted, that the map holding it is locked.
+type lockProbe struct {
+	t *testing.T
+	m *OMapSync[string, lockProbe]
+}
+
+func (p lockProbe) check() {
+	if p.m.mx.TryLock() {
+		p.m.mx.Unlock()
+		p.t.Error("expected map to be locked while formatting it")
+	}
+}
+
+func (p lockProbe) String() string {
+	p.check()
+	return "probe"
+}
+
+func (p lockProbe) GoString() string {
+	p.check()
+	return "probe"
+}
+
+// The read lock must be held for the whole walk, not only while moving the iterator, so the
+// output is a consistent snapshot of the map.
+func TestOMapSyncFormatHoldsLock(t *testing.T) {
+	m := &OMapSync[string, lockProbe]{om: New[string, lockProbe]()}
+	m.Put("a", lockProbe{t, m})
+	m.Put("b", lockProbe{t, m})
+	for _, format := range []string{"%v", "%#v", "%s"} {
+		fmt.Fprintf(io.Discard, format, m)
+	}
+	_ = m.String()
+	_ = m.GoString()
+}
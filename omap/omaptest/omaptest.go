@@ -0,0 +1,373 @@
+// omaptest package provides a conformance test suite for omap.OMap implementations, so the
+// behavior of an implementation written outside of this module (e.g. backed by a database or
+// cached on disk) can be validated with the same tests used by the implementations of omap:
+//
+//	func TestConformance(t *testing.T) {
+//		omaptest.RunConformance(t, func() omap.OMap[string, int] { return mypkg.NewMap[string, int]() })
+//	}
+//
+// The suite expects an ordered implementation, with iterators capable of moving backwards.
+package omaptest
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+// Run the conformance tests, each one as a subtest of t, using factory to create a new empty map
+// for each test. The tests cover Put/PutAfter/Get/Delete/Len, iterator semantics at BOF and EOF,
+// GetIteratorAt, the errors returned by PutAfter, JSON round-trip (skipped if the map does not
+// implement json.Marshaler and json.Unmarshaler) and the move helpers (omap.MoveFirst, etc.).
+// At the end of each test, every map created is checked with omap.CheckInvariants.
+func RunConformance(t *testing.T, factory func() omap.OMap[string, int]) {
+	t.Helper()
+	for _, test := range []struct {
+		name string
+		fct  func(t *testing.T, factory func() omap.OMap[string, int])
+	}{
+		{"PutGet", testPutGet},
+		{"Overwrite", testOverwrite},
+		{"Delete", testDelete},
+		{"Iterator", testIterator},
+		{"EmptyIterator", testEmptyIterator},
+		{"GetIteratorAt", testGetIteratorAt},
+		{"PutAfter", testPutAfter},
+		{"PutAfterErrors", testPutAfterErrors},
+		{"JSON", testJSON},
+		{"Move", testMove},
+		{"MoveErrors", testMoveErrors},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var created []omap.OMap[string, int]
+			test.fct(t, func() omap.OMap[string, int] {
+				m := factory()
+				created = append(created, m)
+				return m
+			})
+			for _, m := range created {
+				if err := omap.CheckInvariants(m); err != nil {
+					t.Errorf("%T: %v", m, err)
+				}
+			}
+		})
+	}
+}
+
+func validateGet(t *testing.T, m omap.OMap[string, int], key string, expected int) {
+	t.Helper()
+	if v, ok := m.Get(key); !ok {
+		t.Errorf("expected key %q to be found", key)
+	} else if v != expected {
+		t.Errorf("expected value %d for key %q, found %d", expected, key, v)
+	}
+}
+
+func validateLen(t *testing.T, m omap.OMap[string, int], expected int) {
+	t.Helper()
+	if m.Len() != expected {
+		t.Errorf("expected %T.Len() of %d, found %d", m, expected, m.Len())
+	}
+}
+
+func testPutGet(t *testing.T, factory func() omap.OMap[string, int]) {
+	m := factory()
+	validateLen(t, m, 0)
+	if _, ok := m.Get("foo"); ok {
+		t.Error("expected key \"foo\" to not be found in empty map")
+	}
+	m.Put("foo", 1)
+	m.Put("bar", 2)
+	m.Put("baz", 3)
+	validateLen(t, m, 3)
+	validateGet(t, m, "foo", 1)
+	validateGet(t, m, "bar", 2)
+	validateGet(t, m, "baz", 3)
+	if _, ok := m.Get("what"); ok {
+		t.Error("expected key \"what\" to not be found")
+	}
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["foo",1],["bar",2],["baz",3]]`))
+}
+
+func testOverwrite(t *testing.T, factory func() omap.OMap[string, int]) {
+	m := factory()
+	m.Put("C", 3)
+	m.Put("B", 2)
+	m.Put("A", 1)
+	// overwrite in different order (shouldn't change original order)
+	m.Put("A", 10)
+	m.Put("B", 20)
+	m.Put("C", 30)
+	validateLen(t, m, 3)
+	validateGet(t, m, "A", 10)
+	validateGet(t, m, "B", 20)
+	validateGet(t, m, "C", 30)
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["C",30],["B",20],["A",10]]`))
+}
+
+func testDelete(t *testing.T, factory func() omap.OMap[string, int]) {
+	m := factory()
+	m.Put("a", 0)
+	m.Put("b", 1)
+	m.Put("c", 2)
+	m.Put("d", 3)
+	m.Put("e", 4)
+	// no-op
+	m.Delete("what")
+	validateLen(t, m, 5)
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["a",0],["b",1],["c",2],["d",3],["e",4]]`))
+	// delete head
+	m.Delete("a")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["b",1],["c",2],["d",3],["e",4]]`))
+	// delete tail
+	m.Delete("e")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["b",1],["c",2],["d",3]]`))
+	// delete in the middle
+	m.Delete("c")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["b",1],["d",3]]`))
+	if _, ok := m.Get("c"); ok {
+		t.Error("expected \"c\" to be deleted")
+	}
+	validateLen(t, m, 2)
+	// deleted key is added back at the end
+	m.Put("c", 5)
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["b",1],["d",3],["c",5]]`))
+	// empty
+	m.Delete("b")
+	m.Delete("d")
+	m.Delete("c")
+	validateLen(t, m, 0)
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[]`))
+}
+
+func testIterator(t *testing.T, factory func() omap.OMap[string, int]) {
+	m := factory()
+	m.Put("a", 1)
+	m.Put("b", 2)
+	m.Put("c", 3)
+	expected := th.JsonToKV[string, int](`[["a",1],["b",2],["c",3]]`)
+	it := m.Iterator()
+	// BOF
+	if it.IsValid() {
+		t.Error("expected new iterator to not be valid (BOF)")
+	}
+	if it.EOF() {
+		t.Error("expected new iterator to not be at EOF")
+	}
+	th.ValidateIteratorForward(t, it, true, expected)
+	// EOF, moving backwards
+	if !it.Prev() || it.Key() != "c" || it.Value() != 3 {
+		t.Error("expected Prev at EOF to move to the last entry")
+	}
+	if it.EOF() {
+		t.Error("expected iterator to not be at EOF after Prev")
+	}
+	th.ValidateIteratorBackward(t, it.MoveBack(), true, expected)
+	if it.Prev() {
+		t.Error("expected Prev at BOF to return false")
+	}
+	if it.IsValid() {
+		t.Error("expected iterator at BOF to not be valid")
+	}
+	// can move forward again
+	th.ValidateIterator(t, it.MoveFront(), true, expected)
+	// MoveBack followed by Next stays at EOF
+	if it.MoveBack(); !it.EOF() || it.IsValid() {
+		t.Error("expected iterator to be at EOF after MoveBack")
+	}
+	// iterators are independent
+	it1 := m.Iterator()
+	it2 := m.Iterator()
+	it1.Next()
+	it1.Next()
+	if !it2.Next() || it2.Key() != "a" {
+		t.Error("expected iterators to be independent of each other")
+	}
+	if it1.Key() != "b" {
+		t.Errorf("expected key \"b\", found %q", it1.Key())
+	}
+}
+
+func testEmptyIterator(t *testing.T, factory func() omap.OMap[string, int]) {
+	m := factory()
+	it := m.Iterator()
+	if it.Next() {
+		t.Error("expected Next on empty map to return false")
+	}
+	if !it.EOF() || it.IsValid() {
+		t.Error("expected iterator to be at EOF after Next on empty map")
+	}
+	it.MoveBack()
+	if it.Prev() {
+		t.Error("expected Prev on empty map to return false")
+	}
+	if it.IsValid() {
+		t.Error("expected iterator to not be valid after Prev on empty map")
+	}
+	th.ValidateIterator(t, it.MoveFront(), true, th.JsonToKV[string, int](`[]`))
+}
+
+func testGetIteratorAt(t *testing.T, factory func() omap.OMap[string, int]) {
+	m := factory()
+	m.Put("a", 1)
+	m.Put("b", 2)
+	m.Put("deleted", 3)
+	m.Put("d", 4)
+	m.Delete("deleted")
+	for it1 := m.Iterator(); it1.Next(); {
+		it2 := m.GetIteratorAt(it1.Key())
+		if !it2.IsValid() {
+			t.Errorf("expected iterator to be valid for key %q", it1.Key())
+		} else if it1.Key() != it2.Key() || it1.Value() != it2.Value() {
+			t.Errorf("expected key/val = %q/%v, found %q/%v", it1.Key(), it1.Value(), it2.Key(), it2.Value())
+		}
+	}
+	if it := m.GetIteratorAt("foo"); it.IsValid() {
+		t.Error("expected GetIteratorAt(\"foo\") to be not valid, found a valid one")
+	}
+	if it := m.GetIteratorAt("deleted"); it.IsValid() {
+		t.Error("expected GetIteratorAt(\"deleted\") to be not valid, found a valid one")
+	}
+	if it := m.GetIteratorAt("a"); !it.IsValid() {
+		t.Error("expected GetIteratorAt(\"a\") to be valid, found an invalid one")
+	} else {
+		th.ValidateIterator(t, it, true, th.JsonToKV[string, int](`[["b",2],["d",4]]`))
+	}
+	if it := m.GetIteratorAt("d"); !it.IsValid() {
+		t.Error("expected GetIteratorAt(\"d\") to be valid, found an invalid one")
+	} else {
+		th.ValidateIterator(t, it, true, th.JsonToKV[string, int](`[]`))
+	}
+	// moving backwards from the key
+	if it := m.GetIteratorAt("b"); !it.Prev() || it.Key() != "a" {
+		t.Error("expected Prev from GetIteratorAt(\"b\") to be at \"a\"")
+	}
+}
+
+func testPutAfter(t *testing.T, factory func() omap.OMap[string, int]) {
+	m := factory()
+	// Add bar at BOF of empty map, then baz after it
+	th.AssertErrNil(t, m.PutAfter(m.Iterator(), "bar", 2), "unexpected error on PutAfter at BOF of empty map")
+	th.AssertErrNil(t, m.PutAfter(m.GetIteratorAt("bar"), "baz", 3), "unexpected error on PutAfter at last entry")
+	// Add foo before bar (which is actually BOF)
+	itBeforeBar := m.GetIteratorAt("bar")
+	itBeforeBar.Prev()
+	th.AssertErrNil(t, m.PutAfter(itBeforeBar, "foo", 1), "unexpected error on PutAfter at BOF reached by Prev")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["foo",1],["bar",2],["baz",3]]`))
+	// PutAfter at head and tail
+	th.AssertErrNil(t, m.PutAfter(m.Iterator(), "HEAD", 0), "unexpected error on PutAfter at BOF")
+	itBack := m.Iterator().MoveBack()
+	itBack.Prev()
+	th.AssertErrNil(t, m.PutAfter(itBack, "TAIL", 0), "unexpected error on PutAfter at last entry")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["HEAD",0],["foo",1],["bar",2],["baz",3],["TAIL",0]]`))
+	// PutAfter in the middle
+	for _, k := range []string{"foo", "bar", "baz"} {
+		th.AssertErrNil(t, m.PutAfter(m.GetIteratorAt(k), strings.ToUpper(k), 0), "unexpected error on PutAfter in the middle")
+	}
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["HEAD",0],["foo",1],["FOO",0],["bar",2],["BAR",0],["baz",3],["BAZ",0],["TAIL",0]]`))
+	// PutAfter of existing keys updates the value and moves them after the iterator
+	itBack = m.Iterator().MoveBack()
+	itBack.Prev()
+	th.AssertErrNil(t, m.PutAfter(itBack, "TAIL", 42), "unexpected error on PutAfter of the same key")
+	th.AssertErrNil(t, m.PutAfter(m.Iterator(), "HEAD", 42), "unexpected error on PutAfter of the same key")
+	for _, k := range []string{"foo", "bar", "baz"} {
+		it := m.GetIteratorAt(k)
+		it.Prev()
+		th.AssertErrNil(t, m.PutAfter(it, strings.ToUpper(k), 42), "unexpected error on PutAfter of existing key")
+	}
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["HEAD",42],["FOO",42],["foo",1],["BAR",42],["bar",2],["BAZ",42],["baz",3],["TAIL",42]]`))
+	validateLen(t, m, 8)
+}
+
+func testPutAfterErrors(t *testing.T, factory func() omap.OMap[string, int]) {
+	var invalidIt omap.OMapIterator[string, int]
+	m := factory()
+	th.AssertErrIs(t, m.PutAfter(invalidIt, "x", 0), omap.ErrInvalidIteratorType, "expected PutAfter with invalid iterator to fail with ErrInvalidIteratorType")
+	th.AssertErrIs(t, m.PutAfter(factory().Iterator(), "x", 0), omap.ErrInvalidIteratorMap, "expected PutAfter with iterator of a different map to fail with ErrInvalidIteratorMap")
+	th.AssertErrIs(t, m.PutAfter(m.Iterator().MoveBack(), "x", 0), omap.ErrInvalidIteratorPos, "expected PutAfter with iterator at EOF to fail with ErrInvalidIteratorPos")
+	m.Put("x", 0)
+	deletedRefIt := m.GetIteratorAt("x")
+	m.Delete("x")
+	th.AssertErrIs(t, m.PutAfter(deletedRefIt, "y", 0), omap.ErrInvalidIteratorPos, "expected PutAfter with iterator at deleted key to fail with ErrInvalidIteratorPos")
+	validateLen(t, m, 0)
+}
+
+func testJSON(t *testing.T, factory func() omap.OMap[string, int]) {
+	m := factory()
+	if _, ok := m.(json.Marshaler); !ok {
+		t.Skipf("%T does not implement json.Marshaler", m)
+	}
+	if _, ok := m.(json.Unmarshaler); !ok {
+		t.Skipf("%T does not implement json.Unmarshaler", m)
+	}
+	m.Put("c", 1)
+	m.Put("b", 2)
+	m.Put("a", 3)
+	m.Put("x", 4)
+	m.Delete("b")
+	const expected = `{"c":1,"a":3,"x":4}`
+	if data, err := json.Marshal(m); err != nil {
+		t.Errorf("json.Marshal failed with error: %v", err)
+	} else if string(data) != expected {
+		t.Errorf("expected %s, found %s", expected, data)
+	}
+	m2 := factory()
+	th.AssertErrNil(t, json.Unmarshal([]byte(`{"c":1,"a":3,"x":0,"x":4}`), m2), "json.Unmarshal failed")
+	th.ValidateIterator(t, m2.Iterator(), true, th.JsonToKV[string, int](`[["c",1],["a",3],["x",4]]`))
+	if data, err := json.Marshal(m2); err != nil {
+		t.Errorf("json.Marshal failed with error: %v", err)
+	} else if string(data) != expected {
+		t.Errorf("expected %s, found %s", expected, data)
+	}
+	// empty map
+	if data, err := json.Marshal(factory()); err != nil {
+		t.Errorf("json.Marshal failed with error: %v", err)
+	} else if string(data) != `{}` {
+		t.Errorf("expected {}, found %s", data)
+	}
+	// invalid input
+	th.AssertErrNotNil(t, json.Unmarshal([]byte(`{"ok": "123"}`), factory()), "expected an error on json.Unmarshal with invalid value")
+	th.AssertErrNotNil(t, json.Unmarshal([]byte(`["a"]`), factory()), "expected an error on json.Unmarshal with non-object input")
+}
+
+func testMove(t *testing.T, factory func() omap.OMap[string, int]) {
+	m := factory()
+	m.Put("a", 0)
+	m.Put("b", 1)
+	m.Put("c", 2)
+	m.Put("d", 3)
+	m.Put("e", 4)
+	th.AssertErrNil(t, omap.MoveAfter(m, "a", "b"), "unexpected error on MoveAfter")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["b",1],["a",0],["c",2],["d",3],["e",4]]`))
+	th.AssertErrNil(t, omap.MoveBefore(m, "a", "b"), "unexpected error on MoveBefore")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["a",0],["b",1],["c",2],["d",3],["e",4]]`))
+	th.AssertErrNil(t, omap.MoveAfter(m, "a", "e"), "unexpected error on MoveAfter")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["b",1],["c",2],["d",3],["e",4],["a",0]]`))
+	th.AssertErrNil(t, omap.MoveFirst(m, "c"), "unexpected error on MoveFirst")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["c",2],["b",1],["d",3],["e",4],["a",0]]`))
+	th.AssertErrNil(t, omap.MoveLast(m, "d"), "unexpected error on MoveLast")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["c",2],["b",1],["e",4],["a",0],["d",3]]`))
+	// moving to the current position is a no-op
+	th.AssertErrNil(t, omap.MoveFirst(m, "c"), "unexpected error on MoveFirst")
+	th.AssertErrNil(t, omap.MoveLast(m, "d"), "unexpected error on MoveLast")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["c",2],["b",1],["e",4],["a",0],["d",3]]`))
+	validateLen(t, m, 5)
+}
+
+func testMoveErrors(t *testing.T, factory func() omap.OMap[string, int]) {
+	m := factory()
+	m.Put("a", 0)
+	m.Put("b", 1)
+	th.AssertErrIs(t, omap.MoveFirst(m, "what"), omap.ErrKeyNotFound, "expected MoveFirst with invalid targetKey to fail with ErrKeyNotFound")
+	th.AssertErrIs(t, omap.MoveLast(m, "what"), omap.ErrKeyNotFound, "expected MoveLast with invalid targetKey to fail with ErrKeyNotFound")
+	th.AssertErrIs(t, omap.MoveAfter(m, "a", "what"), omap.ErrKeyNotFound, "expected MoveAfter with invalid refKey to fail with ErrKeyNotFound")
+	th.AssertErrIs(t, omap.MoveAfter(m, "what", "a"), omap.ErrKeyNotFound, "expected MoveAfter with invalid targetKey to fail with ErrKeyNotFound")
+	th.AssertErrIs(t, omap.MoveBefore(m, "a", "what"), omap.ErrKeyNotFound, "expected MoveBefore with invalid refKey to fail with ErrKeyNotFound")
+	th.AssertErrIs(t, omap.MoveBefore(m, "what", "a"), omap.ErrKeyNotFound, "expected MoveBefore with invalid targetKey to fail with ErrKeyNotFound")
+	// map is unchanged
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["a",0],["b",1]]`))
+}
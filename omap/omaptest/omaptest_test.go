@@ -0,0 +1,12 @@
+package omaptest_test
+
+import (
+	"testing"
+
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omap/omaptest"
+)
+
+func TestRunConformance(t *testing.T) {
+	omaptest.RunConformance(t, func() omap.OMap[string, int] { return omap.New[string, int]() })
+}
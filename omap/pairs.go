@@ -0,0 +1,138 @@
+package omap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Format of the JSON array produced by MarshalJSONPairs.
+type PairsFormat int
+
+const (
+	// Each entry is encoded as a two-elements array: `[[key1, value1], [key2, value2], ...]`
+	PairsAsArrays PairsFormat = iota
+	// Each entry is encoded as an object: `[{"key": key1, "value": value1}, ...]`
+	PairsAsObjects
+)
+
+type jsonPairObject[K comparable, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+// Iterate over the given iterator it, from the given position, and marshal the key/values into
+// a JSON array of pairs, in the given format.
+//
+// Different from MarshalJSON, keys are encoded as regular JSON values, so any key type can be used
+// and duplicated keys (e.g. from an omultimap) are kept, making the output round-trip exactly
+// with UnmarshalJSONPairs.
+// Note: the iterator will be at EOF after this function returns with success.
+func MarshalJSONPairs[K comparable, V any](it OMapIterator[K, V], format PairsFormat) ([]byte, error) {
+	var w bytes.Buffer
+	w.WriteString("[")
+	first := true
+	for it.Next() {
+		key, err := json.Marshal(it.Key())
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal key: %w", err)
+		}
+		val, err := json.Marshal(it.Value())
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal value: %w", err)
+		}
+		if first {
+			first = false
+		} else {
+			w.WriteString(",")
+		}
+		if format == PairsAsObjects {
+			w.WriteString(`{"key":`)
+			w.Write(key)
+			w.WriteString(`,"value":`)
+			w.Write(val)
+			w.WriteString("}")
+		} else {
+			w.WriteString("[")
+			w.Write(key)
+			w.WriteString(",")
+			w.Write(val)
+			w.WriteString("]")
+		}
+	}
+	w.WriteString("]")
+	return w.Bytes(), nil
+}
+
+// Process given JSON array of pairs at b and for each key/value found, call given putFunc
+// function with same definition of OMap.Put to add the given key/value into a map.
+//
+// Both formats of PairsFormat are accepted, and can even be mixed in the same array.
+func UnmarshalJSONPairs[K comparable, V any](putFunc func(K, V), b []byte) error {
+	var pairs []json.RawMessage
+	if err := json.Unmarshal(b, &pairs); err != nil {
+		return fmt.Errorf("failed to decode JSON array of pairs: %w", err)
+	}
+	for i, pair := range pairs {
+		if len(pair) > 0 && pair[0] == '{' {
+			var obj jsonPairObject[K, V]
+			if err := json.Unmarshal(pair, &obj); err != nil {
+				return fmt.Errorf("could not decode pair at position %d: %w", i, err)
+			}
+			putFunc(obj.Key, obj.Value)
+			continue
+		}
+		var arr []json.RawMessage
+		if err := json.Unmarshal(pair, &arr); err != nil {
+			return fmt.Errorf("could not decode pair at position %d: %w", i, err)
+		}
+		if len(arr) != 2 {
+			return fmt.Errorf("expected pair of len=2 at position %d, found len %d", i, len(arr))
+		}
+		var key K
+		var value V
+		if err := json.Unmarshal(arr[0], &key); err != nil {
+			return fmt.Errorf("could not decode key at position %d: %w", i, err)
+		}
+		if err := json.Unmarshal(arr[1], &value); err != nil {
+			return fmt.Errorf("could not decode value at position %d: %w", i, err)
+		}
+		putFunc(key, value)
+	}
+	return nil
+}
+
+//// JSONPairs ////
+
+// JSONPairs wraps an OMap so it is marshaled to/from JSON as an array of pairs, see
+// MarshalJSONPairs. It can be used as a field of a struct or given directly to json.Marshal and
+// json.Unmarshal.
+type JSONPairs[K comparable, V any] struct {
+	OMap[K, V]
+	// Format used when marshaling, unmarshal accepts any format.
+	Format PairsFormat
+}
+
+// Implement json.Marshaler interface.
+func (p JSONPairs[K, V]) MarshalJSON() ([]byte, error) {
+	if p.OMap == nil {
+		return []byte("null"), nil
+	}
+	return MarshalJSONPairs(p.Iterator(), p.Format)
+}
+
+// Implement json.Unmarshaler interface. If OMap is nil, a new map is created using New,
+// otherwise the given map is emptied and reused (so one can choose the implementation).
+func (p *JSONPairs[K, V]) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(bytes.TrimSpace(b), jsonNull) {
+		return nil
+	}
+	if p.OMap == nil {
+		p.OMap = New[K, V]()
+	} else {
+		for _, key := range IteratorKeysToSlice(p.Iterator()) {
+			p.Delete(key)
+		}
+	}
+	return UnmarshalJSONPairs(p.Put, b)
+}
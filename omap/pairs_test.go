@@ -0,0 +1,110 @@
+package omap_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+func TestMarshalJSONPairs(t *testing.T) {
+	for _, impl := range implementations {
+		if !impl.isOrdered {
+			continue
+		}
+		t.Run(impl.name, func(t *testing.T) {
+			m := impl.initializerStrInt()
+			m.Put("foo", 1)
+			m.Put("bar", 2)
+			m.Put("baz", 3)
+			js, err := omap.MarshalJSONPairs(m.Iterator(), omap.PairsAsArrays)
+			th.AssertErrNil(t, err, "unexpected error on MarshalJSONPairs")
+			if exp := `[["foo",1],["bar",2],["baz",3]]`; string(js) != exp {
+				t.Errorf("expected %s, found %s", exp, string(js))
+			}
+			js, err = omap.MarshalJSONPairs(m.Iterator(), omap.PairsAsObjects)
+			th.AssertErrNil(t, err, "unexpected error on MarshalJSONPairs")
+			if exp := `[{"key":"foo","value":1},{"key":"bar","value":2},{"key":"baz","value":3}]`; string(js) != exp {
+				t.Errorf("expected %s, found %s", exp, string(js))
+			}
+			m2 := impl.initializerStrInt()
+			th.AssertErrNil(t, omap.UnmarshalJSONPairs(m2.Put, js), "unexpected error on UnmarshalJSONPairs")
+			th.ValidateIterator(t, m2.Iterator(), impl.isOrdered, th.JsonToKV[string, int](`[["foo",1],["bar",2],["baz",3]]`))
+		})
+	}
+}
+
+func TestJSONPairsNonStringKeys(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+	m := omap.New[point, string]()
+	m.Put(point{1, 2}, "a")
+	m.Put(point{0, 0}, "origin")
+	m.Put(point{-1, 5}, "b")
+	js, err := json.Marshal(omap.JSONPairs[point, string]{OMap: m})
+	th.AssertErrNil(t, err, "unexpected error on json.Marshal")
+	exp := `[[{"X":1,"Y":2},"a"],[{"X":0,"Y":0},"origin"],[{"X":-1,"Y":5},"b"]]`
+	if string(js) != exp {
+		t.Errorf("expected %s, found %s", exp, string(js))
+	}
+	var res omap.JSONPairs[point, string]
+	th.AssertErrNil(t, json.Unmarshal(js, &res), "unexpected error on json.Unmarshal")
+	js2, err := json.Marshal(res)
+	th.AssertErrNil(t, err, "unexpected error on json.Marshal")
+	if string(js2) != exp {
+		t.Errorf("round-trip failed, expected %s, found %s", exp, string(js2))
+	}
+}
+
+func TestJSONPairsUnmarshal(t *testing.T) {
+	// mixed formats and an existing map that must be reset
+	p := omap.JSONPairs[int, string]{OMap: omap.NewOMapSync[int, string](), Format: omap.PairsAsObjects}
+	p.Put(42, "to be removed")
+	th.AssertErrNil(t, json.Unmarshal([]byte(`[[3,"c"], {"key":1,"value":"a"}, [2, "b"]]`), &p), "unexpected error on json.Unmarshal")
+	th.ValidateIterator(t, p.Iterator(), true, th.JsonToKV[int, string](`[[3,"c"],[1,"a"],[2,"b"]]`))
+	if _, ok := p.OMap.(*omap.OMapSync[int, string]); !ok {
+		t.Errorf("expected map implementation to be kept, found %T", p.OMap)
+	}
+	js, err := json.Marshal(p)
+	th.AssertErrNil(t, err, "unexpected error on json.Marshal")
+	if exp := `[{"key":3,"value":"c"},{"key":1,"value":"a"},{"key":2,"value":"b"}]`; string(js) != exp {
+		t.Errorf("expected %s, found %s", exp, string(js))
+	}
+	// null
+	type wrapper struct {
+		Data omap.JSONPairs[string, int] `json:"data"`
+	}
+	var w wrapper
+	th.AssertErrNil(t, json.Unmarshal([]byte(`{"data":null}`), &w), "unexpected error on null")
+	if w.Data.OMap != nil {
+		t.Error("expected nil map on null input")
+	}
+	if js, _ := json.Marshal(w); string(js) != `{"data":null}` {
+		t.Errorf("expected null data, found %s", string(js))
+	}
+}
+
+func TestJSONPairsErrors(t *testing.T) {
+	invalidJsons := []string{
+		`{"foo":1}`,                   // not an array
+		`[["foo",1],"bar"]`,           // not a pair
+		`[["foo",1,2]]`,               // wrong len
+		`[[1,1]]`,                     // wrong key type
+		`[["foo","1"]]`,               // wrong value type
+		`[{"key":"foo","value":"1"}]`, // wrong value type at object
+	}
+	for _, invalidJson := range invalidJsons {
+		var p omap.JSONPairs[string, int]
+		th.AssertErrNotNil(t, json.Unmarshal([]byte(invalidJson), &p), "expected error with invalid JSON: "+invalidJson)
+	}
+	mKeyInvalid := omap.New[failonly, string]()
+	mKeyInvalid.Put(failonly{"hello"}, "hello")
+	_, err := omap.MarshalJSONPairs(mKeyInvalid.Iterator(), omap.PairsAsArrays)
+	th.AssertErrNotNil(t, err, "expected error with invalid key")
+	mValInvalid := omap.New[string, failonly]()
+	mValInvalid.Put("world", failonly{"world"})
+	_, err = omap.MarshalJSONPairs(mValInvalid.Iterator(), omap.PairsAsArrays)
+	th.AssertErrNotNil(t, err, "expected error with invalid value")
+}
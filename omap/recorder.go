@@ -0,0 +1,387 @@
+package omap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+//// OMapRecorder ////
+
+// Implements an OMap that records every call made to an inner OMap, including the calls to its
+// iterators, into an io.Writer, so they can be re-executed later with Replay. Along with the
+// arguments, the result of each call is recorded, so Replay can report the first call where
+// another implementation (or a fixed version of the same one) behaves differently, which makes a
+// trace of a production bug easy to reproduce:
+//
+//	var trace bytes.Buffer
+//	m := omap.NewRecorder(omap.New[string, int](), &trace)
+//	// ... use m as any other map ...
+//	err := omap.Replay(&trace, omap.NewOMapLinkedHash[string, int])
+//
+// A trace can also be used as a seed for FuzzOMapReplay, see omap_fuzz_test.go.
+//
+// The log has one JSON object per line, each one a call of the form:
+//
+//	{"op":"Put","k":"a","v":1}
+//	{"op":"Iterator","it":1}
+//	{"op":"Next","it":1,"ok":true}
+//	{"op":"Key","it":1,"k":"a"}
+//	{"op":"PutAfter","it":1,"k":"b","v":2,"ok":true}
+//
+// Where "it" identifies the iterator, in the order they were created, "k" and "v" are the key
+// and value given to or returned by the call, "ok" the bool returned (or, for PutAfter, whether
+// no error was returned) and "n" the result of Len.
+//
+// Calls are serialized by the recorder, so it can be used concurrently (even if inner can't) and
+// the log reflects the order in which the calls were executed. Writing stops at the first error,
+// which is then returned by Err, so a truncated log is never left with gaps.
+type OMapRecorder[K comparable, V any] struct {
+	inner    OMap[K, V]
+	enc      *json.Encoder
+	err      error
+	lastItID int
+	mx       sync.Mutex
+}
+
+// Iterator over a OMapRecorder, recording its calls along with the ones of the map.
+type OMapRecorderIterator[K comparable, V any] struct {
+	it OMapIterator[K, V]
+	m  *OMapRecorder[K, V]
+	id int
+}
+
+// A call in the log of OMapRecorder.
+type record struct {
+	Op string          `json:"op"`
+	It int             `json:"it,omitempty"`
+	K  json.RawMessage `json:"k,omitempty"`
+	V  json.RawMessage `json:"v,omitempty"`
+	N  int             `json:"n,omitempty"`
+	OK bool            `json:"ok,omitempty"`
+}
+
+// Create a new OMap that records all calls made to inner into w, see OMapRecorder. Keys and
+// values are encoded with encoding/json.
+func NewRecorder[K comparable, V any](inner OMap[K, V], w io.Writer) OMap[K, V] {
+	return &OMapRecorder[K, V]{inner: inner, enc: json.NewEncoder(w)}
+}
+
+// Return the first error found while encoding or writing the log, if any. Once an error is
+// found, the recorder keeps forwarding calls to the inner map, but stops recording them.
+func (m *OMapRecorder[K, V]) Err() error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.err
+}
+
+// encode x as JSON, saving the error if failed
+func (m *OMapRecorder[K, V]) marshal(x any) json.RawMessage {
+	b, err := json.Marshal(x)
+	if err != nil && m.err == nil {
+		m.err = err
+	}
+	return b
+}
+
+// write rec to the log, unless a previous error was found
+func (m *OMapRecorder[K, V]) record(rec record) {
+	if m.err == nil {
+		m.err = m.enc.Encode(rec)
+	}
+}
+
+func (m *OMapRecorder[K, V]) newIterator(op string, it OMapIterator[K, V], key json.RawMessage) OMapIterator[K, V] {
+	m.lastItID++
+	m.record(record{Op: op, It: m.lastItID, K: key})
+	return &OMapRecorderIterator[K, V]{it: it, m: m, id: m.lastItID}
+}
+
+func (m *OMapRecorder[K, V]) Put(key K, value V) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.inner.Put(key, value)
+	m.record(record{Op: "Put", K: m.marshal(key), V: m.marshal(value)})
+}
+
+// Add the key/value after the entry pointed by the iterator, which is recorded by its id. An
+// iterator not created by this recorder is given to the inner map as is, so the error returned
+// is the same as of the inner map, and recorded with id 0.
+func (m *OMapRecorder[K, V]) PutAfter(interfaceIt OMapIterator[K, V], key K, value V) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	itID := 0
+	if it, ok := interfaceIt.(*OMapRecorderIterator[K, V]); ok && it.m == m {
+		itID = it.id
+		interfaceIt = it.it
+	}
+	err := m.inner.PutAfter(interfaceIt, key, value)
+	m.record(record{Op: "PutAfter", It: itID, K: m.marshal(key), V: m.marshal(value), OK: err == nil})
+	return err
+}
+
+func (m *OMapRecorder[K, V]) Get(key K) (V, bool) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	v, ok := m.inner.Get(key)
+	rec := record{Op: "Get", K: m.marshal(key), OK: ok}
+	if ok {
+		rec.V = m.marshal(v)
+	}
+	m.record(rec)
+	return v, ok
+}
+
+func (m *OMapRecorder[K, V]) GetIteratorAt(key K) OMapIterator[K, V] {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.newIterator("GetIteratorAt", m.inner.GetIteratorAt(key), m.marshal(key))
+}
+
+func (m *OMapRecorder[K, V]) Delete(key K) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.inner.Delete(key)
+	m.record(record{Op: "Delete", K: m.marshal(key)})
+}
+
+func (m *OMapRecorder[K, V]) Iterator() OMapIterator[K, V] {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.newIterator("Iterator", m.inner.Iterator(), nil)
+}
+
+func (m *OMapRecorder[K, V]) Len() int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	n := m.inner.Len()
+	m.record(record{Op: "Len", N: n})
+	return n
+}
+
+// Implement fmt.Stringer interface, the iteration over inner map is not recorded.
+func (m *OMapRecorder[K, V]) String() string {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return IteratorToString[K, V]("omap.OMapRecorder", m.inner.Iterator())
+}
+
+// Implement json.Marshaler interface, the iteration over inner map is not recorded.
+func (m *OMapRecorder[K, V]) MarshalJSON() ([]byte, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return MarshalJSON(m.inner.Iterator())
+}
+
+func (it *OMapRecorderIterator[K, V]) Next() bool {
+	it.m.mx.Lock()
+	defer it.m.mx.Unlock()
+	ok := it.it.Next()
+	it.m.record(record{Op: "Next", It: it.id, OK: ok})
+	return ok
+}
+
+func (it *OMapRecorderIterator[K, V]) EOF() bool {
+	it.m.mx.Lock()
+	defer it.m.mx.Unlock()
+	ok := it.it.EOF()
+	it.m.record(record{Op: "EOF", It: it.id, OK: ok})
+	return ok
+}
+
+func (it *OMapRecorderIterator[K, V]) Key() K {
+	it.m.mx.Lock()
+	defer it.m.mx.Unlock()
+	key := it.it.Key()
+	it.m.record(record{Op: "Key", It: it.id, K: it.m.marshal(key)})
+	return key
+}
+
+func (it *OMapRecorderIterator[K, V]) Value() V {
+	it.m.mx.Lock()
+	defer it.m.mx.Unlock()
+	value := it.it.Value()
+	it.m.record(record{Op: "Value", It: it.id, V: it.m.marshal(value)})
+	return value
+}
+
+func (it *OMapRecorderIterator[K, V]) IsValid() bool {
+	it.m.mx.Lock()
+	defer it.m.mx.Unlock()
+	ok := it.it.IsValid()
+	it.m.record(record{Op: "IsValid", It: it.id, OK: ok})
+	return ok
+}
+
+func (it *OMapRecorderIterator[K, V]) MoveFront() OMapIterator[K, V] {
+	it.m.mx.Lock()
+	defer it.m.mx.Unlock()
+	it.it.MoveFront()
+	it.m.record(record{Op: "MoveFront", It: it.id})
+	return it
+}
+
+func (it *OMapRecorderIterator[K, V]) MoveBack() OMapIterator[K, V] {
+	it.m.mx.Lock()
+	defer it.m.mx.Unlock()
+	it.it.MoveBack()
+	it.m.record(record{Op: "MoveBack", It: it.id})
+	return it
+}
+
+func (it *OMapRecorderIterator[K, V]) Prev() bool {
+	it.m.mx.Lock()
+	defer it.m.mx.Unlock()
+	ok := it.it.Prev()
+	it.m.record(record{Op: "Prev", It: it.id, OK: ok})
+	return ok
+}
+
+//// Replay ////
+
+// Re-execute the calls recorded by OMapRecorder from r on a new map created by factory, comparing
+// the result of each call with the recorded one. Returns nil if all calls give the same results,
+// an error wrapping ErrReplayDivergence describing the first call that diverged, including calls
+// that panic on the new map, or an error wrapping ErrInvalidRecord if the log can't be decoded.
+func Replay[K comparable, V any](r io.Reader, factory func() OMap[K, V]) error {
+	rp := &replayer[K, V]{m: factory(), its: make(map[int]OMapIterator[K, V])}
+	dec := json.NewDecoder(r)
+	for rp.line = 1; ; rp.line++ {
+		rp.rec = record{}
+		if err := dec.Decode(&rp.rec); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%w - line %d: %v", ErrInvalidRecord, rp.line, err)
+		}
+		if err := rp.step(); err != nil {
+			return err
+		}
+	}
+}
+
+// state of Replay, rec being the call at line
+type replayer[K comparable, V any] struct {
+	m    OMap[K, V]
+	its  map[int]OMapIterator[K, V]
+	line int
+	rec  record
+}
+
+func (rp *replayer[K, V]) fail(sentinel error, format string, args ...any) error {
+	return fmt.Errorf("%w - line %d, %s: %s", sentinel, rp.line, rp.rec.Op, fmt.Sprintf(format, args...))
+}
+
+func (rp *replayer[K, V]) diverged(recorded any, replayed any) error {
+	return rp.fail(ErrReplayDivergence, "recorded %v, replayed %v", recorded, replayed)
+}
+
+func (rp *replayer[K, V]) compareBool(recorded bool, replayed bool) error {
+	if recorded != replayed {
+		return rp.diverged(recorded, replayed)
+	}
+	return nil
+}
+
+// compare the JSON encoding of replayed with the recorded one
+func (rp *replayer[K, V]) compareJSON(recorded json.RawMessage, replayed any) error {
+	if b, err := json.Marshal(replayed); err != nil {
+		return rp.fail(ErrReplayDivergence, "%v", err)
+	} else if !bytes.Equal(recorded, b) {
+		return rp.diverged(string(recorded), string(b))
+	}
+	return nil
+}
+
+// execute the current call, returning an error if the result diverged or the call is invalid
+func (rp *replayer[K, V]) step() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = rp.fail(ErrReplayDivergence, "panic: %v", r)
+		}
+	}()
+	rec := rp.rec
+	// decode arguments, k and v of Key, Value and Get are results instead
+	var key K
+	var value V
+	if rec.K != nil && rec.Op != "Key" {
+		if err := json.Unmarshal(rec.K, &key); err != nil {
+			return rp.fail(ErrInvalidRecord, "key: %v", err)
+		}
+	}
+	if rec.V != nil && rec.Op != "Value" && rec.Op != "Get" {
+		if err := json.Unmarshal(rec.V, &value); err != nil {
+			return rp.fail(ErrInvalidRecord, "value: %v", err)
+		}
+	}
+	it, found := rp.its[rec.It]
+	switch rec.Op {
+	case "Put":
+		rp.m.Put(key, value)
+		return nil
+	case "Delete":
+		rp.m.Delete(key)
+		return nil
+	case "Get":
+		if v, ok := rp.m.Get(key); ok != rec.OK {
+			return rp.diverged(rec.OK, ok)
+		} else if ok {
+			return rp.compareJSON(rec.V, v)
+		}
+		return nil
+	case "Len":
+		if n := rp.m.Len(); n != rec.N {
+			return rp.diverged(rec.N, n)
+		}
+		return nil
+	case "Iterator", "GetIteratorAt":
+		if found || rec.It <= 0 {
+			return rp.fail(ErrInvalidRecord, "invalid iterator id %d", rec.It)
+		}
+		if rec.Op == "Iterator" {
+			rp.its[rec.It] = rp.m.Iterator()
+		} else {
+			rp.its[rec.It] = rp.m.GetIteratorAt(key)
+		}
+		return nil
+	case "PutAfter":
+		// iterator 0 is one not created by the recorder, a nil one gives the same error
+		if !found && rec.It != 0 {
+			return rp.fail(ErrInvalidRecord, "unknown iterator id %d", rec.It)
+		}
+		if err := rp.m.PutAfter(it, key, value); err != nil && rec.OK {
+			return rp.diverged("success", err)
+		} else if err == nil && !rec.OK {
+			return rp.diverged("an error", "success")
+		}
+		return nil
+	}
+	// all other calls are of iterators
+	if !found {
+		return rp.fail(ErrInvalidRecord, "unknown iterator id %d", rec.It)
+	}
+	switch rec.Op {
+	case "Next":
+		return rp.compareBool(rec.OK, it.Next())
+	case "Prev":
+		return rp.compareBool(rec.OK, it.Prev())
+	case "EOF":
+		return rp.compareBool(rec.OK, it.EOF())
+	case "IsValid":
+		return rp.compareBool(rec.OK, it.IsValid())
+	case "Key":
+		return rp.compareJSON(rec.K, it.Key())
+	case "Value":
+		return rp.compareJSON(rec.V, it.Value())
+	case "MoveFront":
+		it.MoveFront()
+		return nil
+	case "MoveBack":
+		it.MoveBack()
+		return nil
+	default:
+		return rp.fail(ErrInvalidRecord, "unknown operation")
+	}
+}
@@ -0,0 +1,186 @@
+package omap_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+func TestRecorder(t *testing.T) {
+	var log bytes.Buffer
+	m := omap.NewRecorder(omap.NewOMapLinked[string, int](), &log)
+	m.Put("a", 1)
+	m.Put("b", 2)
+	it := m.Iterator()
+	it.Next()
+	th.AssertErrNil(t, m.PutAfter(it, "c", 3), "unexpected error on PutAfter")
+	th.AssertErrIs(t, m.PutAfter(omap.New[string, int]().Iterator(), "x", 0), omap.ErrInvalidIteratorMap, "expected PutAfter with iterator of another map to fail")
+	m.Get("c")
+	m.Get("x")
+	th.ValidateIterator(t, m.GetIteratorAt("c"), true, th.JsonToKV[string, int](`[["b",2]]`))
+	expected := `{"op":"Put","k":"a","v":1}
+{"op":"Put","k":"b","v":2}
+{"op":"Iterator","it":1}
+{"op":"Next","it":1,"ok":true}
+{"op":"PutAfter","it":1,"k":"c","v":3,"ok":true}
+{"op":"PutAfter","k":"x","v":0}
+{"op":"Get","k":"c","v":3,"ok":true}
+{"op":"Get","k":"x"}
+{"op":"GetIteratorAt","it":2,"k":"c"}
+{"op":"IsValid","it":2,"ok":true}
+{"op":"Next","it":2,"ok":true}
+{"op":"Key","it":2,"k":"b"}
+{"op":"Value","it":2,"v":2}
+`
+	if !strings.HasPrefix(log.String(), expected) {
+		t.Errorf("expected log starting with:\n%s\nfound:\n%s", expected, log.String())
+	}
+	// helpers and all other calls
+	th.AssertErrNil(t, omap.MoveFirst(m, "b"), "unexpected error on MoveFirst")
+	m.Delete("a")
+	if m.Len() != 2 {
+		t.Errorf("expected Len() of 2, found %d", m.Len())
+	}
+	itBack := m.Iterator().MoveBack()
+	for itBack.Prev() {
+		itBack.IsValid()
+		itBack.EOF()
+	}
+	itBack.MoveFront().Next()
+	if exp, s := `omap.OMapRecorder[b:2 c:3]`, fmt.Sprint(m); s != exp {
+		t.Errorf("expected %q, found %q", exp, s)
+	}
+	if b, err := json.Marshal(m); err != nil || string(b) != `{"b":2,"c":3}` {
+		t.Errorf("unexpected JSON %q, error: %v", b, err)
+	}
+	th.AssertErrNil(t, m.(*omap.OMapRecorder[string, int]).Err(), "unexpected error on Err")
+	// must give the same results on all ordered implementations
+	for _, impl := range implementations {
+		if impl.isOrdered {
+			th.AssertErrNil(t, omap.Replay(bytes.NewReader(log.Bytes()), impl.initializerStrInt), "unexpected divergence on "+impl.name)
+		}
+	}
+}
+
+func TestRecorderConcurrent(t *testing.T) {
+	var log bytes.Buffer
+	m := omap.NewRecorder(omap.NewOMapLinked[string, int](), &log)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				key := strconv.Itoa(j % 5)
+				m.Put(key, i*100+j)
+				m.Get(key)
+				if j%3 == 0 {
+					m.Delete(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	m.Len()
+	th.AssertErrNil(t, omap.Replay(&log, omap.NewOMapSimple[string, int]), "unexpected divergence")
+}
+
+type failWriter struct {
+	writes int
+}
+
+func (w *failWriter) Write(b []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("write failed")
+}
+
+func TestRecorderErr(t *testing.T) {
+	w := &failWriter{}
+	m := omap.NewRecorder(omap.NewOMapLinked[string, int](), w)
+	m.Put("a", 1)
+	m.Put("b", 2)
+	if err := m.(*omap.OMapRecorder[string, int]).Err(); err == nil || w.writes != 1 {
+		t.Errorf("expected write error after a single write, found %v after %d writes", err, w.writes)
+	}
+	if m.Len() != 2 {
+		t.Errorf("expected calls to keep being forwarded to inner map")
+	}
+	// values that can't be encoded
+	var log bytes.Buffer
+	mf := omap.NewRecorder(omap.NewOMapLinked[string, func()](), &log)
+	mf.Put("a", func() {})
+	th.AssertErrNotNil(t, mf.(*omap.OMapRecorder[string, func()]).Err(), "expected error encoding func value")
+	if log.Len() != 0 {
+		t.Errorf("expected nothing written, found %q", log.String())
+	}
+}
+
+func TestReplayDivergence(t *testing.T) {
+	const put = `{"op":"Put","k":"a","v":1}` + "\n" + `{"op":"Iterator","it":1}` + "\n"
+	for _, tc := range []struct {
+		name     string
+		log      string
+		expected string
+	}{
+		{"Get ok", put + `{"op":"Get","k":"b","v":1,"ok":true}`, "line 3, Get: recorded true, replayed false"},
+		{"Get value", put + `{"op":"Get","k":"a","v":2,"ok":true}`, "line 3, Get: recorded 2, replayed 1"},
+		{"Len", put + `{"op":"Len","n":2}`, "line 3, Len: recorded 2, replayed 1"},
+		{"Next", put + `{"op":"Next","it":1}`, "line 3, Next: recorded false, replayed true"},
+		{"Prev", put + `{"op":"Prev","it":1,"ok":true}`, "line 3, Prev: recorded true, replayed false"},
+		{"EOF", put + `{"op":"EOF","it":1,"ok":true}`, "line 3, EOF: recorded true, replayed false"},
+		{"IsValid", put + `{"op":"IsValid","it":1,"ok":true}`, "line 3, IsValid: recorded true, replayed false"},
+		{"Key", put + `{"op":"Next","it":1,"ok":true}` + "\n" + `{"op":"Key","it":1,"k":"b"}`, `line 4, Key: recorded "b", replayed "a"`},
+		{"Value", put + `{"op":"MoveBack","it":1}` + "\n" + `{"op":"Prev","it":1,"ok":true}` + "\n" + `{"op":"Value","it":1,"v":2}`, "line 5, Value: recorded 2, replayed 1"},
+		{"PutAfter error", put + `{"op":"PutAfter","it":1,"k":"b","v":2}`, "line 3, PutAfter: recorded an error, replayed success"},
+		{"PutAfter success", put + `{"op":"MoveFront","it":1}` + "\n" + `{"op":"PutAfter","k":"b","v":2,"ok":true}`, "line 4, PutAfter: recorded success, replayed OMapError: invalid iterator type"},
+		{"panic", put + `{"op":"GetIteratorAt","it":2,"k":"x"}` + "\n" + `{"op":"Key","it":2,"k":"x"}`, "line 4, Key: panic: "},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := omap.Replay(strings.NewReader(tc.log), omap.NewOMapLinked[string, int])
+			th.AssertErrIs(t, err, omap.ErrReplayDivergence, "expected divergence")
+			if err != nil && !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("expected error containing %q, found %v", tc.expected, err)
+			}
+		})
+	}
+	// results that can't be encoded
+	err := omap.Replay(strings.NewReader(`{"op":"Iterator","it":1}`+"\n"+`{"op":"Next","it":1,"ok":true}`+"\n"+`{"op":"Value","it":1,"v":1}`), func() omap.OMap[string, func()] {
+		m := omap.NewOMapLinked[string, func()]()
+		m.Put("a", func() {})
+		return m
+	})
+	th.AssertErrIs(t, err, omap.ErrReplayDivergence, "expected divergence on value that can't be encoded")
+}
+
+func TestReplayInvalidRecord(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		log      string
+		expected string
+	}{
+		{"json", `{"op":`, "line 1: "},
+		{"key", `{"op":"Put","k":1,"v":1}`, "line 1, Put: key: "},
+		{"value", `{"op":"Put","k":"a","v":"x"}`, "line 1, Put: value: "},
+		{"unknown op", `{"op":"Iterator","it":1}` + "\n" + `{"op":"Foo","it":1}`, "line 2, Foo: unknown operation"},
+		{"unknown iterator", `{"op":"Next","it":1}`, "line 1, Next: unknown iterator id 1"},
+		{"unknown PutAfter iterator", `{"op":"PutAfter","it":1,"k":"a","v":1}`, "line 1, PutAfter: unknown iterator id 1"},
+		{"duplicated iterator", `{"op":"Iterator","it":1}` + "\n" + `{"op":"GetIteratorAt","it":1,"k":"a"}`, "line 2, GetIteratorAt: invalid iterator id 1"},
+		{"iterator 0", `{"op":"Iterator"}`, "line 1, Iterator: invalid iterator id 0"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := omap.Replay(strings.NewReader(tc.log), omap.NewOMapLinked[string, int])
+			th.AssertErrIs(t, err, omap.ErrInvalidRecord, "expected invalid record")
+			if err != nil && !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("expected error containing %q, found %v", tc.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+//go:build go1.21
+
+package omap
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Return the key/values of the given iterator as slog attributes, in the same order. Keys that are
+// not strings are formatted with fmt.Sprint, values are used as given (so nested ordered maps are
+// also logged as groups).
+// Note: the iterator will be at EOF after this function returns.
+func IteratorAttrs[K comparable, V any](it OMapIterator[K, V]) []slog.Attr {
+	attrs := make([]slog.Attr, 0)
+	for it.Next() {
+		attrs = append(attrs, slog.Any(attrKey(it.Key()), it.Value()))
+	}
+	return attrs
+}
+
+// Return the key/values of the map m as slog attributes, in insertion order, see IteratorAttrs.
+func AttrsOf[K comparable, V any](m OMap[K, V]) []slog.Attr {
+	return IteratorAttrs(m.Iterator())
+}
+
+func attrKey(key any) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	return fmt.Sprint(key)
+}
+
+// Implement slog.LogValuer interface, as a group with the key/values in insertion order.
+func (m *OMapLinked[K, V]) LogValue() slog.Value {
+	return slog.GroupValue(AttrsOf[K, V](m)...)
+}
+
+// Implement slog.LogValuer interface, as a group with the key/values in insertion order.
+func (m *OMapLinkedHash[K, V]) LogValue() slog.Value {
+	return slog.GroupValue(AttrsOf[K, V](m)...)
+}
+
+// Implement slog.LogValuer interface, as a group with the key/values in insertion order.
+func (m *OMapSimple[K, V]) LogValue() slog.Value {
+	return slog.GroupValue(AttrsOf[K, V](m)...)
+}
+
+// Implement slog.LogValuer interface, as a group with the key/values in iteration order.
+func (m *OMapBuiltin[K, V]) LogValue() slog.Value {
+	return slog.GroupValue(AttrsOf[K, V](m)...)
+}
+
+// Implement slog.LogValuer interface, as a group with the key/values in insertion order.
+func (m *OMapSync[K, V]) LogValue() slog.Value {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return slog.GroupValue(AttrsOf(m.om)...)
+}
+
+// Implement slog.LogValuer interface, as a group with the key/values in insertion order.
+func (m *OMapFolded[V]) LogValue() slog.Value {
+	return slog.GroupValue(AttrsOf[string, V](m)...)
+}
+
+//// AttrsHandler ////
+
+// AttrsHandler is a slog.Handler that collects the attributes of each record logged into an
+// ordered map, mostly useful for testing. Groups are collected as nested OMap[string, any], and
+// the attributes added with Logger.With come first, as done by the slog handlers.
+//
+// Records are also passed to the wrapped handler, if any. Handlers returned by WithAttrs and
+// WithGroup share the collected records with the one they were derived from.
+type AttrsHandler struct {
+	next  slog.Handler
+	store *attrsStore
+	// attributes and groups added by WithAttrs and WithGroup, in order
+	items []attrsItem
+}
+
+type attrsStore struct {
+	mx      sync.Mutex
+	records []OMap[string, any]
+}
+
+// either a group name or a list of attributes
+type attrsItem struct {
+	group string
+	attrs []slog.Attr
+}
+
+// Create a new AttrsHandler wrapping the given handler next, that can be nil to only collect the
+// attributes (all levels are enabled in such case).
+func NewAttrsHandler(next slog.Handler) *AttrsHandler {
+	return &AttrsHandler{next: next, store: &attrsStore{}}
+}
+
+// Return the attributes of the records handled so far, one map per record, in logging order.
+func (h *AttrsHandler) Records() []OMap[string, any] {
+	h.store.mx.Lock()
+	defer h.store.mx.Unlock()
+	return append([]OMap[string, any](nil), h.store.records...)
+}
+
+// Discard the records collected so far.
+func (h *AttrsHandler) Reset() {
+	h.store.mx.Lock()
+	defer h.store.mx.Unlock()
+	h.store.records = nil
+}
+
+// Implement slog.Handler interface.
+func (h *AttrsHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.next != nil {
+		return h.next.Enabled(ctx, level)
+	}
+	return true
+}
+
+// Implement slog.Handler interface.
+func (h *AttrsHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	m := New[string, any]()
+	collectItems(m, h.items, attrs)
+	h.store.mx.Lock()
+	h.store.records = append(h.store.records, m)
+	h.store.mx.Unlock()
+	if h.next != nil {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+// Put into m the given items followed by the record attributes, nesting the ones after a group.
+func collectItems(m OMap[string, any], items []attrsItem, attrs []slog.Attr) {
+	for i, item := range items {
+		if item.group != "" {
+			group := New[string, any]()
+			collectItems(group, items[i+1:], attrs)
+			// as slog handlers, empty groups are omitted
+			if group.Len() > 0 {
+				m.Put(item.group, group)
+			}
+			return
+		}
+		collectAttrs(m, item.attrs)
+	}
+	collectAttrs(m, attrs)
+}
+
+func collectAttrs(m OMap[string, any], attrs []slog.Attr) {
+	for _, a := range attrs {
+		value := a.Value.Resolve()
+		if value.Kind() != slog.KindGroup {
+			if a.Key != "" || value.Any() != nil {
+				m.Put(a.Key, value.Any())
+			}
+			continue
+		}
+		if a.Key == "" {
+			// inline group
+			collectAttrs(m, value.Group())
+		} else if len(value.Group()) > 0 {
+			group := New[string, any]()
+			collectAttrs(group, value.Group())
+			m.Put(a.Key, group)
+		}
+	}
+}
+
+// Implement slog.Handler interface.
+func (h *AttrsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	ret := h.with(attrsItem{attrs: attrs})
+	if h.next != nil {
+		ret.next = h.next.WithAttrs(attrs)
+	}
+	return ret
+}
+
+// Implement slog.Handler interface.
+func (h *AttrsHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	ret := h.with(attrsItem{group: name})
+	if h.next != nil {
+		ret.next = h.next.WithGroup(name)
+	}
+	return ret
+}
+
+func (h *AttrsHandler) with(item attrsItem) *AttrsHandler {
+	items := make([]attrsItem, len(h.items), len(h.items)+1)
+	copy(items, h.items)
+	return &AttrsHandler{next: h.next, store: h.store, items: append(items, item)}
+}
@@ -0,0 +1,133 @@
+//go:build go1.21
+
+package omap_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+func TestLogValue(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			m := impl.initializerStrInt()
+			m.Put("z", 1)
+			m.Put("a", 2)
+			m.Put("m", 3)
+			valuer, ok := m.(slog.LogValuer)
+			if !ok {
+				t.Fatalf("%T does not implement slog.LogValuer", m)
+			}
+			value := valuer.LogValue()
+			if value.Kind() != slog.KindGroup {
+				t.Fatalf("expected group value, found %v", value.Kind())
+			}
+			if !impl.isOrdered {
+				if len(value.Group()) != 3 {
+					t.Errorf("expected 3 attributes, found %v", value.Group())
+				}
+				return
+			}
+			var buf bytes.Buffer
+			slog.New(slog.NewJSONHandler(&buf, nil)).Info("msg", "map", m)
+			if !strings.Contains(buf.String(), `"map":{"z":1,"a":2,"m":3}`) {
+				t.Errorf("unexpected log output: %s", buf.String())
+			}
+		})
+	}
+}
+
+func TestAttrsOf(t *testing.T) {
+	inner := omap.New[string, any]()
+	inner.Put("y", true)
+	m := omap.New[int, any]()
+	m.Put(2, "two")
+	m.Put(1, inner)
+	attrs := omap.AttrsOf(m)
+	if len(attrs) != 2 || attrs[0].Key != "2" || attrs[1].Key != "1" {
+		t.Fatalf("unexpected attributes %v", attrs)
+	}
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).LogAttrs(context.Background(), slog.LevelInfo, "msg", attrs...)
+	if !strings.HasSuffix(buf.String(), " 2=two 1.y=true\n") {
+		t.Errorf("unexpected log output: %s", buf.String())
+	}
+	if attrs := omap.AttrsOf(omap.New[string, int]()); len(attrs) != 0 {
+		t.Errorf("expected no attributes, found %v", attrs)
+	}
+}
+
+func recordsJSON(t *testing.T, h *omap.AttrsHandler) []string {
+	t.Helper()
+	ret := make([]string, 0)
+	for _, m := range h.Records() {
+		b, err := json.Marshal(m)
+		th.AssertErrNil(t, err, "unexpected error on Marshal")
+		ret = append(ret, string(b))
+	}
+	return ret
+}
+
+func TestAttrsHandler(t *testing.T) {
+	h := omap.NewAttrsHandler(nil)
+	logger := slog.New(h)
+	m := omap.New[string, int]()
+	m.Put("b", 1)
+	m.Put("a", 2)
+	logger.Debug("first", "z", 1, "map", m, slog.Group("g", "y", 2, "x", 3), slog.Group("", "inline", 4),
+		slog.Group("empty"), slog.Attr{})
+	child := logger.With("w", "with").WithGroup("grp").With("v", 5)
+	child.Info("second", "u", 6)
+	child.WithGroup("emptygrp").Info("third")
+	exp := []string{
+		`{"z":1,"map":{"b":1,"a":2},"g":{"y":2,"x":3},"inline":4}`,
+		`{"w":"with","grp":{"v":5,"u":6}}`,
+		`{"w":"with","grp":{"v":5}}`,
+	}
+	found := recordsJSON(t, h)
+	if strings.Join(found, "\n") != strings.Join(exp, "\n") {
+		t.Errorf("expected:\n%s\nfound:\n%s", strings.Join(exp, "\n"), strings.Join(found, "\n"))
+	}
+	if logger.With() != logger || logger.WithGroup("") != logger {
+		t.Errorf("expected same logger with no attributes or group")
+	}
+	h.Reset()
+	if len(h.Records()) != 0 {
+		t.Errorf("expected no records after Reset")
+	}
+}
+
+type failHandler struct {
+	slog.Handler
+}
+
+func (failHandler) Handle(context.Context, slog.Record) error {
+	return errors.New("handle failed")
+}
+
+func TestAttrsHandlerWrapped(t *testing.T) {
+	var buf bytes.Buffer
+	h := omap.NewAttrsHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	logger := slog.New(h).With("a", 1).WithGroup("g")
+	logger.Debug("disabled", "b", 2)
+	logger.Info("enabled", "b", 2)
+	if found := recordsJSON(t, h); len(found) != 1 || found[0] != `{"a":1,"g":{"b":2}}` {
+		t.Errorf("unexpected records %v", found)
+	}
+	if !strings.Contains(buf.String(), `"msg":"enabled","a":1,"g":{"b":2}}`) {
+		t.Errorf("unexpected log output: %s", buf.String())
+	}
+	fh := omap.NewAttrsHandler(failHandler{slog.NewJSONHandler(&buf, nil)})
+	th.AssertErrNotNil(t, fh.Handle(context.Background(), slog.Record{}), "expected error from wrapped handler")
+	if len(fh.Records()) != 1 {
+		t.Errorf("expected record collected even with error")
+	}
+}
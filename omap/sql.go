@@ -0,0 +1,149 @@
+package omap
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// JSONColumn wraps an OMap so it can be stored into and loaded from a database column through
+// database/sql, encoded as a JSON object that keeps the order of the keys (see MarshalJSON). The
+// value is sent as text, so it works with JSON/JSONB columns as well as regular text columns.
+//
+// SQL NULL is mapped to a nil OMap, in both directions.
+type JSONColumn[K comparable, V any] struct {
+	OMap[K, V]
+}
+
+var (
+	_ driver.Valuer = JSONColumn[string, any]{}
+	_ sql.Scanner   = (*JSONColumn[string, any])(nil)
+)
+
+// Implement driver.Valuer interface.
+func (c JSONColumn[K, V]) Value() (driver.Value, error) {
+	if c.OMap == nil {
+		return nil, nil
+	}
+	b, err := MarshalJSON(c.Iterator())
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Implement sql.Scanner interface. If OMap is nil, a new map is created using New, otherwise the
+// given map is emptied and reused (so one can choose the implementation). A NULL value sets OMap
+// to nil.
+func (c *JSONColumn[K, V]) Scan(src any) error {
+	var b []byte
+	switch s := src.(type) {
+	case nil:
+		c.OMap = nil
+		return nil
+	case []byte:
+		b = s
+	case string:
+		b = []byte(s)
+	default:
+		return fmt.Errorf("%w: cannot scan value of type %T into a JSONColumn", ErrOMap, src)
+	}
+	if c.OMap == nil {
+		c.OMap = New[K, V]()
+	} else {
+		for _, key := range IteratorKeysToSlice(c.Iterator()) {
+			c.Delete(key)
+		}
+	}
+	return UnmarshalJSON(c.Put, b)
+}
+
+//// Scan rows ////
+
+// Conversion hooks used by ScanRow and ScanAll, each one called with the column name and the value
+// returned by the driver, and must return the value to be put into the map.
+type ScanOptions struct {
+	// Called for []byte values (many drivers return text columns as []byte). If nil, the value is
+	// converted to string. Note the given slice is already a copy owned by the caller.
+	Bytes func(column string, b []byte) (any, error)
+	// Called for time.Time values. If nil, the value is kept as is.
+	Time func(column string, t time.Time) (any, error)
+	// Called for NULL values. If nil, NULL is put as a nil value.
+	Null func(column string) (any, error)
+}
+
+// Scan the current row of rows (rows.Next must have been called) and call given putFunc, with same
+// definition of OMap.Put, for each column in the order of the SELECT, with the values converted
+// by given opts (only the first one is used, if any).
+func ScanRowFunc(putFunc func(string, any), rows *sql.Rows, opts ...ScanOptions) error {
+	var opt ScanOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	values := make([]any, len(columns))
+	ptrs := make([]any, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return err
+	}
+	for i, col := range columns {
+		value := values[i]
+		switch v := value.(type) {
+		case nil:
+			if opt.Null != nil {
+				value, err = opt.Null(col)
+			}
+		case []byte:
+			if opt.Bytes != nil {
+				value, err = opt.Bytes(col, v)
+			} else {
+				value = string(v)
+			}
+		case time.Time:
+			if opt.Time != nil {
+				value, err = opt.Time(col, v)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("could not convert column %q: %w", col, err)
+		}
+		putFunc(col, value)
+	}
+	return nil
+}
+
+// Scan the current row of rows (rows.Next must have been called) into a new OMap, with keys in
+// the order of the columns of the SELECT. If the same column name appears more than once, the
+// last value is kept at the position of the first one, use omultimap.ScanRow to keep all of them.
+// See ScanRowFunc for the usage of opts.
+func ScanRow(rows *sql.Rows, opts ...ScanOptions) (OMap[string, any], error) {
+	m := New[string, any]()
+	if err := ScanRowFunc(m.Put, rows, opts...); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Scan all remaining rows into a slice of OMap, one per row, as ScanRow does. As it iterates
+// until rows.Next returns false, rows is closed when this function returns with success.
+func ScanAll(rows *sql.Rows, opts ...ScanOptions) ([]OMap[string, any], error) {
+	ret := make([]OMap[string, any], 0)
+	for rows.Next() {
+		m, err := ScanRow(rows, opts...)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,165 @@
+package omap_test
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+func TestJSONColumn(t *testing.T) {
+	db, table := th.NewFakeDB("id", "data")
+	defer db.Close()
+	m := omap.New[string, int]()
+	m.Put("z", 1)
+	m.Put("a", 2)
+	m.Put("m", 3)
+	_, err := db.Exec("INSERT INTO t VALUES (?, ?)", 1, omap.JSONColumn[string, int]{m})
+	th.AssertErrNil(t, err, "unexpected error on insert")
+	_, err = db.Exec("INSERT INTO t VALUES (?, ?)", 2, omap.JSONColumn[string, int]{})
+	th.AssertErrNil(t, err, "unexpected error on insert of NULL")
+	if rows := table.Rows(); rows[0][1] != `{"z":1,"a":2,"m":3}` || rows[1][1] != nil {
+		t.Errorf("unexpected stored values: %v", rows)
+	}
+	// drivers may return text as []byte
+	table.Convert = func(v driver.Value) driver.Value {
+		if s, ok := v.(string); ok {
+			return []byte(s)
+		}
+		return v
+	}
+	_, err = db.Exec("INSERT INTO t VALUES (?, ?)", 3, `{"b":1,"a":2}`)
+	th.AssertErrNil(t, err, "unexpected error on insert")
+	rows, err := db.Query("SELECT id, data FROM t")
+	th.AssertErrNil(t, err, "unexpected error on select")
+	defer rows.Close()
+	expected := map[int]string{
+		1: `[["z",1],["a",2],["m",3]]`,
+		2: "",
+		3: `[["b",1],["a",2]]`,
+	}
+	// reuse the same column, with a user chosen implementation
+	col := omap.JSONColumn[string, int]{omap.NewOMapLinkedHash[string, int]()}
+	for rows.Next() {
+		var id int
+		th.AssertErrNil(t, rows.Scan(&id, &col), "unexpected error on scan")
+		if expected[id] == "" {
+			if col.OMap != nil {
+				t.Errorf("expected nil map for NULL at id %d", id)
+			}
+			col.OMap = omap.NewOMapLinkedHash[string, int]()
+			continue
+		}
+		if _, ok := col.OMap.(*omap.OMapLinkedHash[string, int]); !ok {
+			t.Errorf("expected map to be reused, found %T", col.OMap)
+		}
+		th.ValidateIterator(t, col.Iterator(), true, th.JsonToKV[string, int](expected[id]))
+	}
+	th.AssertErrNil(t, rows.Err(), "unexpected error on rows")
+}
+
+func TestJSONColumnErrors(t *testing.T) {
+	var col omap.JSONColumn[string, int]
+	th.AssertErrIs(t, col.Scan(10), omap.ErrOMap, "expected error with unsupported type")
+	th.AssertErrNotNil(t, col.Scan("[1, 2]"), "expected error with invalid JSON")
+	th.AssertErrNotNil(t, col.Scan(`{"a": "b"}`), "expected error with invalid value type")
+	invalid := omap.New[string, any]()
+	invalid.Put("a", make(chan int))
+	_, err := omap.JSONColumn[string, any]{invalid}.Value()
+	th.AssertErrNotNil(t, err, "expected error with invalid value")
+}
+
+func TestScanRow(t *testing.T) {
+	db, table := th.NewFakeDB("z", "a", "when", "bin", "a")
+	defer db.Close()
+	when := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	_, err := db.Exec("INSERT INTO t VALUES (?, ?, ?, ?, ?)", 1, "text", when, []byte{0, 1}, nil)
+	th.AssertErrNil(t, err, "unexpected error on insert")
+	_, err = db.Exec("INSERT INTO t VALUES (?, ?, ?, ?, ?)", nil, nil, nil, nil, 2.5)
+	th.AssertErrNil(t, err, "unexpected error on insert")
+	table.Convert = func(v driver.Value) driver.Value {
+		if s, ok := v.(string); ok {
+			return []byte(s)
+		}
+		return v
+	}
+	_, err = db.Exec("INSERT INTO t VALUES (?, ?, ?, ?, ?)", 3, "bytes", nil, nil, nil)
+	th.AssertErrNil(t, err, "unexpected error on insert")
+	// default conversions
+	rows, err := db.Query("SELECT * FROM t")
+	th.AssertErrNil(t, err, "unexpected error on select")
+	res, err := omap.ScanAll(rows)
+	th.AssertErrNil(t, err, "unexpected error on ScanAll")
+	if len(res) != 3 {
+		t.Fatalf("expected 3 rows, found %d", len(res))
+	}
+	expected := [][]any{
+		{int64(1), nil, when, "\x00\x01"},
+		{nil, 2.5, nil, nil},
+		{int64(3), nil, nil, nil},
+	}
+	for i, row := range res {
+		if keys := omap.IteratorKeysToSlice(row.Iterator()); !reflect.DeepEqual(keys, []string{"z", "a", "when", "bin"}) {
+			t.Errorf("unexpected keys at row %d: %v", i, keys)
+		}
+		if values := omap.IteratorValuesToSlice(row.Iterator()); !reflect.DeepEqual(values, expected[i]) {
+			t.Errorf("unexpected values at row %d: %#v", i, values)
+		}
+	}
+	// hooks
+	opts := omap.ScanOptions{
+		Bytes: func(column string, b []byte) (any, error) {
+			if column == "bin" {
+				return b, nil
+			}
+			return string(b), nil
+		},
+		Time: func(_ string, t time.Time) (any, error) {
+			return t.Format(time.RFC3339), nil
+		},
+		Null: func(column string) (any, error) {
+			return "NULL(" + column + ")", nil
+		},
+	}
+	rows, err = db.Query("SELECT * FROM t")
+	th.AssertErrNil(t, err, "unexpected error on select")
+	defer rows.Close()
+	rows.Next()
+	row, err := omap.ScanRow(rows, opts)
+	th.AssertErrNil(t, err, "unexpected error on ScanRow")
+	exp := []any{int64(1), "NULL(a)", "2024-01-02T03:04:05Z", []byte{0, 1}}
+	if values := omap.IteratorValuesToSlice(row.Iterator()); !reflect.DeepEqual(values, exp) {
+		t.Errorf("unexpected values: %#v", values)
+	}
+}
+
+func TestScanRowErrors(t *testing.T) {
+	db, table := th.NewFakeDB("a")
+	defer db.Close()
+	_, err := db.Exec("INSERT INTO t VALUES (?)", nil)
+	th.AssertErrNil(t, err, "unexpected error on insert")
+	errHook := errors.New("hook failed")
+	rows, err := db.Query("SELECT a FROM t")
+	th.AssertErrNil(t, err, "unexpected error on select")
+	_, err = omap.ScanAll(rows, omap.ScanOptions{Null: func(string) (any, error) { return nil, errHook }})
+	th.AssertErrIs(t, err, errHook, "expected error from hook")
+	// without calling Next
+	rows, err = db.Query("SELECT a FROM t")
+	th.AssertErrNil(t, err, "unexpected error on select")
+	_, err = omap.ScanRow(rows)
+	th.AssertErrNotNil(t, err, "expected error without calling Next")
+	// closed rows
+	rows.Close()
+	_, err = omap.ScanRow(rows)
+	th.AssertErrNotNil(t, err, "expected error with closed rows")
+	// driver failure
+	table.RowsErr = th.ErrFakeTable
+	rows, err = db.Query("SELECT a FROM t")
+	th.AssertErrNil(t, err, "unexpected error on select")
+	_, err = omap.ScanAll(rows)
+	th.AssertErrIs(t, err, th.ErrFakeTable, "expected error from driver")
+}
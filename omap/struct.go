@@ -0,0 +1,417 @@
+package omap
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Options for FromStruct and ToStruct.
+type StructOptions struct {
+	// Name of the struct tag used for the names and options of the fields, "json" if empty. Tags
+	// follow the same rules as encoding/json: `name,omitempty`, "-" to skip a field, etc.
+	Tag string
+	// Add the fields tagged with omitempty even if they are empty (only used by FromStruct).
+	IgnoreOmitEmpty bool
+	// Convert nested structs (or pointers to structs) into nested OMap[string, any], recursively,
+	// instead of keeping the struct itself as the value (only used by FromStruct). Structs that
+	// implement json.Marshaler or encoding.TextMarshaler (e.g. time.Time) are kept as is.
+	Nested bool
+}
+
+// Returned by ToStruct when keys of the map have no matching field in the struct. All the other
+// keys are still assigned, so it can be ignored by the caller if unknown keys are expected.
+type UnknownKeysError struct {
+	Keys []string
+}
+
+func (e *UnknownKeysError) Error() string {
+	return fmt.Sprintf("%v %q", ErrUnknownKeys, e.Keys)
+}
+
+// Make errors.Is(err, ErrUnknownKeys) work.
+func (e *UnknownKeysError) Unwrap() error {
+	return ErrUnknownKeys
+}
+
+type structField struct {
+	name      string
+	index     []int
+	tagged    bool
+	omitEmpty bool
+}
+
+// Return the fields of struct type t, in declaration order, with fields of embedded structs
+// promoted as done by encoding/json (including the resolution of conflicting names).
+func structFields(t reflect.Type, tag string) []structField {
+	var all []structField
+	collectFields(t, tag, nil, map[reflect.Type]bool{}, &all)
+	// resolve conflicts: the shallower field wins, then the tagged one, otherwise all are dropped
+	byName := make(map[string][]int)
+	for i, f := range all {
+		byName[f.name] = append(byName[f.name], i)
+	}
+	ret := make([]structField, 0, len(all))
+	for i, f := range all {
+		dominant := true
+		for _, j := range byName[f.name] {
+			if j == i {
+				continue
+			}
+			other := all[j]
+			if len(other.index) < len(f.index) ||
+				(len(other.index) == len(f.index) && (other.tagged || !f.tagged)) {
+				dominant = false
+				break
+			}
+		}
+		if dominant {
+			ret = append(ret, f)
+		}
+	}
+	return ret
+}
+
+func collectFields(t reflect.Type, tag string, index []int, visited map[reflect.Type]bool, out *[]structField) {
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+	defer delete(visited, t)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tagValue := sf.Tag.Get(tag)
+		if tagValue == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tagValue, ",")
+		fieldIndex := append(append(make([]int, 0, len(index)+1), index...), i)
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				collectFields(ft, tag, fieldIndex, visited, out)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		f := structField{name: name, index: fieldIndex, tagged: name != ""}
+		if name == "" {
+			f.name = sf.Name
+		}
+		for opts != "" {
+			var opt string
+			opt, opts, _ = strings.Cut(opts, ",")
+			if opt == "omitempty" {
+				f.omitEmpty = true
+			}
+		}
+		*out = append(*out, f)
+	}
+}
+
+// Return the field of v at the given index, or false if it is behind a nil embedded pointer.
+// If alloc is true, nil embedded pointers are allocated instead.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, nil
+				}
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct %v", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*interface{ MarshalJSON() ([]byte, error) })(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Return the struct to be nested for v, if it is a struct (or a non-nil pointer to one) that has
+// no custom marshaling.
+func nestedStruct(v reflect.Value) (reflect.Value, bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v, false
+		}
+		if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return v, false
+	}
+	pt := reflect.PtrTo(v.Type())
+	if pt.Implements(jsonMarshalerType) || pt.Implements(textMarshalerType) {
+		return v, false
+	}
+	return v, true
+}
+
+// Convert the given struct v (or pointer to struct) into an OMap, with the exported fields as
+// keys, in declaration order. Fields are named and filtered using the struct tags, following the
+// same rules of encoding/json (see StructOptions), and fields of embedded structs are promoted to
+// the top level.
+func FromStruct(v any, opts StructOptions) (OMap[string, any], error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: expected a struct, found %T", ErrOMap, v)
+	}
+	if opts.Tag == "" {
+		opts.Tag = "json"
+	}
+	return fromStruct(rv, opts), nil
+}
+
+func fromStruct(rv reflect.Value, opts StructOptions) OMap[string, any] {
+	m := New[string, any]()
+	for _, f := range structFields(rv.Type(), opts.Tag) {
+		fv, _ := fieldByIndex(rv, f.index, false)
+		if !fv.IsValid() || (f.omitEmpty && !opts.IgnoreOmitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+		if opts.Nested {
+			if sv, ok := nestedStruct(fv); ok {
+				m.Put(f.name, fromStruct(sv, opts))
+				continue
+			}
+		}
+		m.Put(f.name, fv.Interface())
+	}
+	return m
+}
+
+// Fill the struct pointed by dst with the key/values of m, matching the keys with the names of
+// the fields (see FromStruct), first exactly and then case-insensitive (as encoding/json does).
+// Values are converted to the type of the field when needed and possible: numbers of different
+// types (checking for overflow), strings parsed into numbers and booleans, strings into []byte or
+// types implementing encoding.TextUnmarshaler (e.g. time.Time), nested OMap[string, any] into
+// structs or maps, and slices element by element.
+//
+// Keys with no matching field (including the ones of nested structs, as "parent.child") are
+// reported, after all other keys were assigned, by returning an *UnknownKeysError. Only the first
+// given opts is used (and only its Tag), if any.
+func ToStruct[V any](m OMap[string, V], dst any, opts ...StructOptions) error {
+	d := structDecoder{tag: "json"}
+	if len(opts) > 0 && opts[0].Tag != "" {
+		d.tag = opts[0].Tag
+	}
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: expected a non-nil pointer to struct, found %T", ErrOMap, dst)
+	}
+	if err := toStruct(&d, m.Iterator(), rv.Elem(), ""); err != nil {
+		return err
+	}
+	if len(d.unknown) > 0 {
+		return &UnknownKeysError{Keys: d.unknown}
+	}
+	return nil
+}
+
+type structDecoder struct {
+	tag     string
+	unknown []string
+}
+
+func toStruct[V any](d *structDecoder, it OMapIterator[string, V], rv reflect.Value, prefix string) error {
+	fields := structFields(rv.Type(), d.tag)
+	for it.Next() {
+		key := it.Key()
+		var field *structField
+		for i := range fields {
+			if fields[i].name == key {
+				field = &fields[i]
+				break
+			} else if field == nil && strings.EqualFold(fields[i].name, key) {
+				field = &fields[i]
+			}
+		}
+		if field == nil {
+			d.unknown = append(d.unknown, prefix+key)
+			continue
+		}
+		fv, err := fieldByIndex(rv, field.index, true)
+		if err == nil {
+			err = d.assign(fv, it.Value(), prefix+key+".")
+		}
+		if err != nil {
+			return fmt.Errorf("%w: could not assign key %q: %v", ErrOMap, prefix+key, err)
+		}
+	}
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// Assign src into dst, converting it if needed (see ToStruct). The prefix is used to report
+// unknown keys of nested structs.
+func (d *structDecoder) assign(dst reflect.Value, src any, prefix string) error {
+	if src == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	sv := reflect.ValueOf(src)
+	if sv.Type().AssignableTo(dst.Type()) {
+		dst.Set(sv)
+		return nil
+	}
+	if s, ok := src.(string); ok && reflect.PtrTo(dst.Type()).Implements(textUnmarshalerType) {
+		return dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if om, ok := src.(OMap[string, any]); ok {
+		switch dst.Kind() {
+		case reflect.Struct:
+			return toStruct(d, om.Iterator(), dst, prefix)
+		case reflect.Map:
+			if dst.Type().Key().Kind() == reflect.String {
+				ret := reflect.MakeMapWithSize(dst.Type(), om.Len())
+				for it := om.Iterator(); it.Next(); {
+					value := reflect.New(dst.Type().Elem()).Elem()
+					if err := d.assign(value, it.Value(), prefix+it.Key()+"."); err != nil {
+						return fmt.Errorf("at key %q: %w", it.Key(), err)
+					}
+					ret.SetMapIndex(reflect.ValueOf(it.Key()).Convert(dst.Type().Key()), value)
+				}
+				dst.Set(ret)
+				return nil
+			}
+		}
+	}
+	switch dst.Kind() {
+	case reflect.Ptr:
+		ptr := reflect.New(dst.Type().Elem())
+		if err := d.assign(ptr.Elem(), src, prefix); err != nil {
+			return err
+		}
+		dst.Set(ptr)
+		return nil
+	case reflect.Slice:
+		if sv.Kind() == reflect.String && dst.Type().Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes([]byte(sv.String()))
+			return nil
+		} else if sv.Kind() == reflect.Slice || sv.Kind() == reflect.Array {
+			ret := reflect.MakeSlice(dst.Type(), sv.Len(), sv.Len())
+			for i := 0; i < sv.Len(); i++ {
+				if err := d.assign(ret.Index(i), sv.Index(i).Interface(), fmt.Sprintf("%s%d.", prefix, i)); err != nil {
+					return fmt.Errorf("at index %d: %w", i, err)
+				}
+			}
+			dst.Set(ret)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, ok := toInt64(sv); ok && !dst.OverflowInt(i) {
+			dst.SetInt(i)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if i, ok := toUint64(sv); ok && !dst.OverflowUint(i) {
+			dst.SetUint(i)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, ok := toFloat64(sv); ok && !dst.OverflowFloat(f) {
+			dst.SetFloat(f)
+			return nil
+		}
+	case reflect.Bool:
+		if sv.Kind() == reflect.String {
+			if b, err := strconv.ParseBool(sv.String()); err == nil {
+				dst.SetBool(b)
+				return nil
+			}
+		}
+	}
+	// same kind, e.g. named types
+	if sv.Kind() == dst.Kind() && sv.Type().ConvertibleTo(dst.Type()) {
+		dst.Set(sv.Convert(dst.Type()))
+		return nil
+	}
+	return fmt.Errorf("cannot convert value %v of type %T to %v", src, src, dst.Type())
+}
+
+func toInt64(v reflect.Value) (int64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(v.Uint()), v.Uint() <= math.MaxInt64
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		return int64(f), f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64
+	case reflect.String:
+		i, err := strconv.ParseInt(v.String(), 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func toUint64(v reflect.Value) (uint64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64(v.Int()), v.Int() >= 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint(), true
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		return uint64(f), f == math.Trunc(f) && f >= 0 && f < math.MaxUint64
+	case reflect.String:
+		i, err := strconv.ParseUint(v.String(), 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func toFloat64(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		f, err := strconv.ParseFloat(v.String(), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
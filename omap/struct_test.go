@@ -0,0 +1,377 @@
+package omap_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+type Audit struct {
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int       `json:"version,omitempty"`
+}
+
+type Address struct {
+	Street string `json:"street"`
+	City   string `json:"city,omitempty"`
+}
+
+type Base struct {
+	ID   int    `json:"id"`
+	Zeta string `json:"zeta"` // conflicts with Record.Zeta, which is shallower
+}
+
+type Record struct {
+	Zeta   string `json:"zeta"`
+	Name   string `json:"name"`
+	Base          // promoted, in place
+	*Audit        // promoted, through a pointer
+	Alpha  string `json:"alpha,omitempty"`
+	Skip   string `json:"-"`
+	Dash   string `json:"-,"`
+	NoTag  float64
+	Home   Address            `json:"home"`
+	Work   *Address           `json:"work,omitempty"`
+	Tags   []string           `json:"tags,omitempty"`
+	Extra  map[string]float32 `json:"extra,omitempty"`
+	hidden string
+}
+
+func TestFromStruct(t *testing.T) {
+	when := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := Record{
+		Zeta:   "z",
+		Name:   "record",
+		Base:   Base{ID: 1, Zeta: "base"},
+		Audit:  &Audit{CreatedBy: "me", CreatedAt: when},
+		Skip:   "skip",
+		Dash:   "dash",
+		NoTag:  1.5,
+		Home:   Address{Street: "main"},
+		hidden: "hidden",
+	}
+	m, err := omap.FromStruct(&r, omap.StructOptions{})
+	th.AssertErrNil(t, err, "unexpected error on FromStruct")
+	keys := []string{"zeta", "name", "id", "created_by", "created_at", "-", "NoTag", "home"}
+	if res := omap.IteratorKeysToSlice(m.Iterator()); !reflect.DeepEqual(res, keys) {
+		t.Errorf("expected keys %v, found %v", keys, res)
+	}
+	if v, _ := m.Get("home"); v != (Address{Street: "main"}) {
+		t.Errorf("expected home to be kept as struct, found %#v", v)
+	}
+	// same result as encoding/json
+	fromStruct, _ := json.Marshal(m)
+	fromJSON, _ := json.Marshal(r)
+	if string(fromStruct) != string(fromJSON) {
+		t.Errorf("expected %s, found %s", fromJSON, fromStruct)
+	}
+	// without omitempty and nested
+	r.Audit = nil
+	r.Work = &Address{Street: "second"}
+	m, err = omap.FromStruct(r, omap.StructOptions{IgnoreOmitEmpty: true, Nested: true})
+	th.AssertErrNil(t, err, "unexpected error on FromStruct")
+	keys = []string{"zeta", "name", "id", "alpha", "-", "NoTag", "home", "work", "tags", "extra"}
+	if res := omap.IteratorKeysToSlice(m.Iterator()); !reflect.DeepEqual(res, keys) {
+		t.Errorf("expected keys %v, found %v", keys, res)
+	}
+	if res, _ := json.Marshal(m); string(res) != `{"zeta":"z","name":"record","id":1,"alpha":"","-":"dash","NoTag":1.5,"home":{"street":"main","city":""},"work":{"street":"second","city":""},"tags":null,"extra":null}` {
+		t.Errorf("unexpected result: %s", res)
+	}
+	// custom tag, time.Time is not nested
+	type custom struct {
+		A    int `db:"a_col"`
+		B    int `db:"-"`
+		When time.Time
+		Ptr  *time.Time
+		Nil  *Address
+	}
+	m, err = omap.FromStruct(custom{A: 1, B: 2, When: when, Ptr: &when}, omap.StructOptions{Tag: "db", Nested: true})
+	th.AssertErrNil(t, err, "unexpected error on FromStruct")
+	if res, _ := json.Marshal(m); string(res) != `{"a_col":1,"When":"2024-01-02T03:04:05Z","Ptr":"2024-01-02T03:04:05Z","Nil":null}` {
+		t.Errorf("unexpected result: %s", res)
+	}
+	_, err = omap.FromStruct(10, omap.StructOptions{})
+	th.AssertErrIs(t, err, omap.ErrOMap, "expected error with non-struct")
+	_, err = omap.FromStruct((*Record)(nil), omap.StructOptions{})
+	th.AssertErrIs(t, err, omap.ErrOMap, "expected error with nil pointer")
+}
+
+func TestFromStructConflicts(t *testing.T) {
+	type A struct {
+		X int
+		Y int `json:"Y"`
+	}
+	type B struct {
+		X int
+		Y int
+	}
+	type C struct {
+		A
+		B
+		Z int
+	}
+	type Cycle struct {
+		*Cycle
+		V int
+	}
+	m, err := omap.FromStruct(C{A{1, 2}, B{3, 4}, 5}, omap.StructOptions{})
+	th.AssertErrNil(t, err, "unexpected error on FromStruct")
+	// X is ambiguous and dropped, tagged Y wins, same as encoding/json
+	if res, _ := json.Marshal(m); string(res) != `{"Y":2,"Z":5}` {
+		t.Errorf("unexpected result: %s", res)
+	}
+	if res, _ := json.Marshal(C{A{1, 2}, B{3, 4}, 5}); string(res) != `{"Y":2,"Z":5}` {
+		t.Errorf("unexpected encoding/json result: %s", res)
+	}
+	m, err = omap.FromStruct(Cycle{&Cycle{nil, 1}, 2}, omap.StructOptions{})
+	th.AssertErrNil(t, err, "unexpected error on FromStruct")
+	if res, _ := json.Marshal(m); string(res) != `{"V":2}` {
+		t.Errorf("unexpected result: %s", res)
+	}
+}
+
+type level string
+
+func (l *level) UnmarshalText(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("empty level")
+	}
+	*l = level("L:" + string(b))
+	return nil
+}
+
+func TestToStruct(t *testing.T) {
+	nested := omap.New[string, any]()
+	nested.Put("street", "main")
+	nested.Put("unknown", 1)
+	extra := omap.New[string, any]()
+	extra.Put("a", 1)
+	extra.Put("b", "2.5")
+	m := omap.New[string, any]()
+	m.Put("zeta", "z")
+	m.Put("ID", int64(10)) // case-insensitive
+	m.Put("created_by", "me")
+	m.Put("created_at", "2024-01-02T03:04:05Z")
+	m.Put("version", 2.0)
+	m.Put("notag", "1.25")
+	m.Put("home", nested)
+	m.Put("work", nested)
+	m.Put("tags", []any{"a", "b"})
+	m.Put("extra", extra)
+	m.Put("other", true)
+	var r Record
+	err := omap.ToStruct(m, &r)
+	var unknown *omap.UnknownKeysError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("expected UnknownKeysError, found %v", err)
+	}
+	th.AssertErrIs(t, err, omap.ErrUnknownKeys, "expected ErrUnknownKeys")
+	if !reflect.DeepEqual(unknown.Keys, []string{"home.unknown", "work.unknown", "other"}) {
+		t.Errorf("unexpected unknown keys: %v", unknown.Keys)
+	}
+	exp := Record{
+		Zeta:  "z",
+		Base:  Base{ID: 10},
+		Audit: &Audit{CreatedBy: "me", CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Version: 2},
+		NoTag: 1.25,
+		Home:  Address{Street: "main"},
+		Work:  &Address{Street: "main"},
+		Tags:  []string{"a", "b"},
+		Extra: map[string]float32{"a": 1, "b": 2.5},
+	}
+	if !reflect.DeepEqual(r, exp) {
+		t.Errorf("expected %+v, found %+v", exp, r)
+	}
+	// round-trip from FromStruct
+	m2, err := omap.FromStruct(exp, omap.StructOptions{})
+	th.AssertErrNil(t, err, "unexpected error on FromStruct")
+	var r2 Record
+	th.AssertErrNil(t, omap.ToStruct(m2, &r2), "unexpected error on ToStruct")
+	if !reflect.DeepEqual(r2, exp) {
+		t.Errorf("expected %+v, found %+v", exp, r2)
+	}
+}
+
+func TestToStructConversions(t *testing.T) {
+	type named int
+	type target struct {
+		I8    int8
+		U16   uint16
+		F32   float32
+		B     bool
+		S     string
+		N     named
+		L     level
+		P     *int
+		Any   any
+		Bytes []byte
+		Arr   []int
+		Nil   *int
+		MapI  map[string]int
+	}
+	m := omap.New[string, any]()
+	m.Put("I8", "-12")
+	m.Put("U16", 7.0)
+	m.Put("F32", uint8(3))
+	m.Put("B", "true")
+	m.Put("S", "s")
+	m.Put("N", 5)
+	m.Put("L", "info")
+	m.Put("P", "42")
+	m.Put("Any", []int{1})
+	m.Put("Bytes", "raw")
+	m.Put("Arr", [2]float64{1, 2})
+	m.Put("Nil", nil)
+	var tg target
+	tg.Nil = new(int)
+	th.AssertErrNil(t, omap.ToStruct(m, &tg, omap.StructOptions{}), "unexpected error on ToStruct")
+	fortyTwo := 42
+	exp := target{I8: -12, U16: 7, F32: 3, B: true, S: "s", N: 5, L: "L:info", P: &fortyTwo, Any: []int{1}, Bytes: []byte("raw"), Arr: []int{1, 2}}
+	if !reflect.DeepEqual(tg, exp) {
+		t.Errorf("expected %+v, found %+v", exp, tg)
+	}
+	// typed map
+	ms := omap.New[string, string]()
+	ms.Put("I8", "1")
+	ms.Put("F32", "0.5")
+	var tg2 target
+	th.AssertErrNil(t, omap.ToStruct(ms, &tg2), "unexpected error on ToStruct")
+	if tg2.I8 != 1 || tg2.F32 != 0.5 {
+		t.Errorf("unexpected result: %+v", tg2)
+	}
+	// custom tag
+	type tagged struct {
+		A int `db:"a"`
+	}
+	mt := omap.New[string, int]()
+	mt.Put("a", 1)
+	var tg3 tagged
+	th.AssertErrNil(t, omap.ToStruct(mt, &tg3, omap.StructOptions{Tag: "db"}), "unexpected error on ToStruct")
+	if tg3.A != 1 {
+		t.Errorf("expected 1, found %d", tg3.A)
+	}
+}
+
+func TestToStructErrors(t *testing.T) {
+	type target struct {
+		I8   int8
+		U8   uint8
+		F32  float32
+		B    bool
+		S    string
+		L    level
+		Arr  []int
+		MapI map[string]int
+		MapK map[int]int
+		Sub  Address
+	}
+	invalid := map[string]any{
+		"I8":   300,
+		"U8":   -1,
+		"F32":  1e300,
+		"B":    "maybe",
+		"S":    10,
+		"L":    "",
+		"Arr":  []any{1, "x"},
+		"MapI": func() omap.OMap[string, any] { m := omap.New[string, any](); m.Put("a", "x"); return m }(),
+		"MapK": omap.New[string, any](),
+		"Sub":  func() omap.OMap[string, any] { m := omap.New[string, any](); m.Put("street", 1); return m }(),
+	}
+	for key, value := range invalid {
+		m := omap.New[string, any]()
+		m.Put(key, value)
+		var tg target
+		err := omap.ToStruct(m, &tg)
+		if err == nil || errors.Is(err, omap.ErrUnknownKeys) {
+			t.Errorf("expected conversion error for %s, found %v", key, err)
+		}
+	}
+	for _, value := range []any{1.5, "1.5", uint64(1 << 63), -1.0} {
+		m := omap.New[string, any]()
+		m.Put("U8", value)
+		m.Put("I8", value)
+		var tg target
+		th.AssertErrNotNil(t, omap.ToStruct(m, &tg), "expected error with invalid integer")
+	}
+	var tg target
+	th.AssertErrIs(t, omap.ToStruct(omap.New[string, any](), tg), omap.ErrOMap, "expected error with non-pointer")
+	th.AssertErrIs(t, omap.ToStruct(omap.New[string, any](), (*target)(nil)), omap.ErrOMap, "expected error with nil pointer")
+	var i int
+	th.AssertErrIs(t, omap.ToStruct(omap.New[string, any](), &i), omap.ErrOMap, "expected error with non-struct")
+	// embedded pointer to unexported struct can't be allocated
+	type embedded struct {
+		X int
+	}
+	type outer struct {
+		*embedded
+	}
+	m := omap.New[string, any]()
+	m.Put("X", 1)
+	var o outer
+	th.AssertErrNotNil(t, omap.ToStruct(m, &o), "expected error with unexported embedded pointer")
+}
+
+func TestStructOmitEmpty(t *testing.T) {
+	type empty struct {
+		B   bool            `json:"b,omitempty"`
+		I   int             `json:"i,omitempty"`
+		U   uint            `json:"u,omitempty"`
+		F   float64         `json:"f,omitempty"`
+		S   string          `json:"s,omitempty"`
+		Sl  []int           `json:"sl,omitempty"`
+		M   map[string]int  `json:"m,omitempty"`
+		P   *int            `json:"p,omitempty"`
+		A   any             `json:"a,omitempty"`
+		Arr [0]int          `json:"arr,omitempty"`
+		St  struct{}        `json:"st,omitempty"`
+		Ch  chan int        `json:"-"`
+		Fn  func()          `json:"-"`
+		X   json.RawMessage `json:"x,omitempty"`
+	}
+	m, err := omap.FromStruct(empty{}, omap.StructOptions{})
+	th.AssertErrNil(t, err, "unexpected error on FromStruct")
+	if res, _ := json.Marshal(m); string(res) != `{"st":{}}` {
+		t.Errorf("unexpected result: %s", res)
+	}
+	one := 1
+	m, err = omap.FromStruct(empty{true, 1, 1, 1, "s", []int{1}, map[string]int{"a": 1}, &one, 1, [0]int{}, struct{}{}, nil, nil, nil}, omap.StructOptions{})
+	th.AssertErrNil(t, err, "unexpected error on FromStruct")
+	if res, _ := json.Marshal(m); string(res) != `{"b":true,"i":1,"u":1,"f":1,"s":"s","sl":[1],"m":{"a":1},"p":1,"a":1,"st":{}}` {
+		t.Errorf("unexpected result: %s", res)
+	}
+}
+
+func TestToStructNumbers(t *testing.T) {
+	type numbers struct {
+		I int64
+		U uint64
+		F float64
+	}
+	for _, value := range []any{int8(3), uint32(3), float32(3), "3"} {
+		m := omap.New[string, any]()
+		m.Put("I", value)
+		m.Put("U", value)
+		m.Put("F", value)
+		var n numbers
+		th.AssertErrNil(t, omap.ToStruct(m, &n), "unexpected error on ToStruct")
+		if n != (numbers{3, 3, 3}) {
+			t.Errorf("expected all 3 for %T, found %+v", value, n)
+		}
+	}
+	for _, key := range []string{"I", "U", "F"} {
+		m := omap.New[string, any]()
+		m.Put(key, true)
+		var n numbers
+		th.AssertErrNotNil(t, omap.ToStruct(m, &n), "expected error converting bool to number")
+	}
+	err := &omap.UnknownKeysError{Keys: []string{"a", "b"}}
+	if err.Error() != `OMapError: unknown keys ["a" "b"]` {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
@@ -0,0 +1,249 @@
+package omap
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Options used by MarshalXML and UnmarshalXML.
+type XMLOptions struct {
+	// If true, each key/value is encoded as an attribute of the enclosing element, instead of a
+	// child element. Values are formatted with encoding.TextMarshaler if implemented, or
+	// fmt.Sprint otherwise. As XML does not allow repeated attributes, encoding a multimap holding
+	// repeated keys fails with ErrDuplicateKey.
+	Attributes bool
+}
+
+// Iterate over the given iterator it, from the given position, and encode the key/values as XML
+// elements inside start element, in the same order. The key is used as the element name, and the
+// value is encoded with xml.Encoder.EncodeElement (so any value supported by encoding/xml can be
+// used, including nested maps). If opts.Attributes is true, the key/values are encoded as
+// attributes of start instead. Keys are formatted with fmt.Sprint, and an error wrapping
+// ErrInvalidXMLName is returned if the result is not a valid XML name (e.g. "1" or "bad key").
+//
+// This is a handy function to construct a xml.Marshaler implementation.
+// Note: the iterator will be at EOF after this function returns with success.
+func MarshalXML[K comparable, V any](e *xml.Encoder, start xml.StartElement, it OMapIterator[K, V], opts XMLOptions) error {
+	if i := strings.IndexByte(start.Name.Local, '['); i >= 0 {
+		// name derived from a generic type name (e.g. "OMapLinked[string,int]"), which is not a valid XML name
+		start.Name.Local = start.Name.Local[:i]
+	}
+	if opts.Attributes {
+		seen := make(map[string]bool)
+		for it.Next() {
+			name, err := xmlName(it.Key())
+			if err != nil {
+				return err
+			}
+			if seen[name] {
+				return fmt.Errorf("%w: repeated attribute %q", ErrDuplicateKey, name)
+			}
+			seen[name] = true
+			value, err := xmlAttrValue(it.Value())
+			if err != nil {
+				return fmt.Errorf("failed to marshal attribute %v: %w", it.Key(), err)
+			}
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+		}
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		return e.EncodeToken(start.End())
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for it.Next() {
+		name, err := xmlName(it.Key())
+		if err != nil {
+			return err
+		}
+		elem := xml.StartElement{Name: xml.Name{Local: name}}
+		if err := e.EncodeElement(it.Value(), elem); err != nil {
+			return fmt.Errorf("failed to marshal element %v: %w", it.Key(), err)
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// format key as an XML name, checking it follows the Name production of the XML specification.
+// Colons are rejected as well, since encoding/xml takes them as a namespace prefix.
+func xmlName(key any) (string, error) {
+	name := fmt.Sprint(key)
+	for i, r := range name {
+		if r == '_' || unicode.IsLetter(r) {
+			continue
+		}
+		if i > 0 && (r == '-' || r == '.' || r == '\u00B7' || unicode.IsDigit(r) || unicode.In(r, unicode.Mn, unicode.Mc)) {
+			continue
+		}
+		return "", fmt.Errorf("%w: %q", ErrInvalidXMLName, name)
+	}
+	if name == "" {
+		return "", fmt.Errorf("%w: empty key", ErrInvalidXMLName)
+	}
+	return name, nil
+}
+
+func xmlAttrValue(v any) (string, error) {
+	if tm, ok := v.(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	return fmt.Sprint(v), nil
+}
+
+// Process the content of the XML element start, and for each child element (or attribute, if
+// opts.Attributes is true) found, call given putFunc function with same definition of OMap.Put to
+// add the given key/value into a map. Repeated elements result in repeated calls to putFunc,
+// in the same order as found, so a multimap can hold all of them.
+//
+// If V is an interface type (e.g. any), elements holding only text are decoded as string, and
+// elements with child elements as an OMap[string, any] (created with New) decoded with this same
+// function, so nested elements keep their order. Attributes are decoded as string. An error is
+// returned if V can't hold these types.
+//
+// This is a handy function to construct a xml.Unmarshaler implementation.
+func UnmarshalXML[K comparable, V any](putFunc func(K, V), d *xml.Decoder, start xml.StartElement, opts XMLOptions) error {
+	if opts.Attributes {
+		for _, attr := range start.Attr {
+			key, ok := any(attr.Name.Local).(K)
+			if !ok {
+				return fmt.Errorf("could not use attribute name %q as key of type %T", attr.Name.Local, key)
+			}
+			var value V
+			if xmlIsInterface[V]() {
+				if value, ok = any(attr.Value).(V); !ok {
+					return fmt.Errorf("could not decode attribute %q: string can't be assigned to %v", attr.Name.Local, reflect.TypeOf(&value).Elem())
+				}
+			} else if err := xmlUnmarshalAttrValue(attr.Value, &value); err != nil {
+				return fmt.Errorf("could not decode attribute %q: %w", attr.Name.Local, err)
+			}
+			putFunc(key, value)
+		}
+		return d.Skip()
+	}
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return fmt.Errorf("failed to get token: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			key, ok := any(t.Name.Local).(K)
+			if !ok {
+				return fmt.Errorf("could not use element name %q as key of type %T", t.Name.Local, key)
+			}
+			var value V
+			if xmlIsInterface[V]() {
+				decoded, err := xmlDecodeAny(d)
+				if err != nil {
+					return fmt.Errorf("could not decode element %q: %w", t.Name.Local, err)
+				}
+				if value, ok = decoded.(V); !ok {
+					return fmt.Errorf("could not decode element %q: %T can't be assigned to %v", t.Name.Local, decoded, reflect.TypeOf(&value).Elem())
+				}
+			} else if err := d.DecodeElement(&value, &t); err != nil {
+				return fmt.Errorf("could not decode element %q: %w", t.Name.Local, err)
+			}
+			putFunc(key, value)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func xmlIsInterface[V any]() bool {
+	return reflect.TypeOf((*V)(nil)).Elem().Kind() == reflect.Interface
+}
+
+// decode the content of the element just started in d, up to its end, as a string if it only
+// holds text, or as an OMap[string, any] otherwise
+func xmlDecodeAny(d *xml.Decoder) (any, error) {
+	var text []byte
+	var m OMap[string, any]
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get token: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			text = append(text, t...)
+		case xml.StartElement:
+			if m == nil {
+				m = New[string, any]()
+			}
+			value, err := xmlDecodeAny(d)
+			if err != nil {
+				return nil, fmt.Errorf("could not decode element %q: %w", t.Name.Local, err)
+			}
+			m.Put(t.Name.Local, value)
+		case xml.EndElement:
+			if m != nil {
+				// text around child elements (usually indentation) is dropped
+				return m, nil
+			}
+			return string(text), nil
+		}
+	}
+}
+
+func xmlUnmarshalAttrValue(s string, v any) error {
+	switch x := v.(type) {
+	case *string:
+		*x = s
+		return nil
+	case encoding.TextUnmarshaler:
+		return x.UnmarshalText([]byte(s))
+	}
+	// let encoding/xml convert the text to the proper type
+	var b bytes.Buffer
+	b.WriteString("<v>")
+	if err := xml.EscapeText(&b, []byte(s)); err != nil {
+		return err
+	}
+	b.WriteString("</v>")
+	return xml.Unmarshal(b.Bytes(), v)
+}
+
+// Wraps an OMap to be encoded and decoded by encoding/xml with the given Options, since the
+// xml.Marshaler and xml.Unmarshaler methods of the maps always use the default XMLOptions:
+//
+//	type Box struct {
+//		Size omap.XMLMap[string, int] `xml:"size"`
+//	}
+//	box := Box{Size: omap.XMLMap[string, int]{Map: m, Options: omap.XMLOptions{Attributes: true}}}
+//	data, err := xml.Marshal(box) // <Box><size width="10" height="20"></size></Box>
+//
+// When decoding, the entries of Map are deleted before the decoded ones are put (or Map is created
+// with New if nil), so Options must be set before calling xml.Unmarshal.
+type XMLMap[K comparable, V any] struct {
+	Map     OMap[K, V]
+	Options XMLOptions
+}
+
+// Implement xml.Marshaler interface, see MarshalXML.
+func (x XMLMap[K, V]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if x.Map == nil {
+		x.Map = New[K, V]()
+	}
+	return MarshalXML(e, start, x.Map.Iterator(), x.Options)
+}
+
+// Implement xml.Unmarshaler interface, see UnmarshalXML.
+func (x *XMLMap[K, V]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if x.Map == nil {
+		x.Map = New[K, V]()
+	} else {
+		for _, key := range IteratorKeysToSlice(x.Map.Iterator()) {
+			x.Map.Delete(key)
+		}
+	}
+	return UnmarshalXML(x.Map.Put, d, start, x.Options)
+}
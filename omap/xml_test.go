@@ -0,0 +1,249 @@
+package omap_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+func TestXML(t *testing.T) {
+	type envelope struct {
+		XMLName xml.Name                                                `xml:"Envelope"`
+		Header  string                                                  `xml:"Header"`
+		Body    *omap.OMapLinked[string, string]                        `xml:"Body"`
+		Nested  *omap.OMapLinked[string, *omap.OMapLinked[string, int]] `xml:"Nested"`
+	}
+	body := omap.NewOMapLinked[string, string]()
+	body.Put("Zeta", "last letter")
+	body.Put("Alpha", "first <letter>")
+	body.Put("Mu", "")
+	inner := omap.NewOMapLinked[string, int]()
+	inner.Put("b", 2)
+	inner.Put("a", 1)
+	nested := omap.NewOMapLinked[string, *omap.OMapLinked[string, int]]()
+	nested.Put("inner", inner.(*omap.OMapLinked[string, int]))
+	in := envelope{
+		Header: "h",
+		Body:   body.(*omap.OMapLinked[string, string]),
+		Nested: nested.(*omap.OMapLinked[string, *omap.OMapLinked[string, int]]),
+	}
+	data, err := xml.Marshal(in)
+	th.AssertErrNil(t, err, "unexpected error on xml.Marshal")
+	exp := `<Envelope><Header>h</Header><Body><Zeta>last letter</Zeta><Alpha>first &lt;letter&gt;</Alpha><Mu></Mu></Body><Nested><inner><b>2</b><a>1</a></inner></Nested></Envelope>`
+	if string(data) != exp {
+		t.Errorf("expected %s, found %s", exp, string(data))
+	}
+	var out envelope
+	th.AssertErrNil(t, xml.Unmarshal(data, &out), "unexpected error on xml.Unmarshal")
+	th.ValidateIterator(t, out.Body.Iterator(), true, th.JsonToKV[string, string](`[["Zeta","last letter"],["Alpha","first <letter>"],["Mu",""]]`))
+	if innerOut, ok := out.Nested.Get("inner"); !ok {
+		t.Error("nested map not found")
+	} else {
+		th.ValidateIterator(t, innerOut.Iterator(), true, th.JsonToKV[string, int](`[["b",2],["a",1]]`))
+	}
+	// top level, name derived from the type
+	data, err = xml.Marshal(inner)
+	th.AssertErrNil(t, err, "unexpected error on xml.Marshal")
+	if exp := `<OMapLinked><b>2</b><a>1</a></OMapLinked>`; string(data) != exp {
+		t.Errorf("expected %s, found %s", exp, string(data))
+	}
+}
+
+type xmlAttrs struct {
+	m    omap.OMap[string, int]
+	opts omap.XMLOptions
+}
+
+func (x xmlAttrs) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return omap.MarshalXML(e, start, x.m.Iterator(), x.opts)
+}
+
+func (x *xmlAttrs) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	x.m = omap.New[string, int]()
+	return omap.UnmarshalXML(x.m.Put, d, start, x.opts)
+}
+
+type upperText string
+
+func (u upperText) MarshalText() ([]byte, error) {
+	if u == "" {
+		return nil, errors.New("empty")
+	}
+	return bytes.ToUpper([]byte(u)), nil
+}
+
+func (u *upperText) UnmarshalText(b []byte) error {
+	*u = upperText(bytes.ToLower(b))
+	return nil
+}
+
+func TestXMLAttributes(t *testing.T) {
+	m := omap.New[string, int]()
+	m.Put("width", 10)
+	m.Put("height", 20)
+	m.Put("depth", -1)
+	in := xmlAttrs{m: m, opts: omap.XMLOptions{Attributes: true}}
+	data, err := xml.Marshal(in)
+	th.AssertErrNil(t, err, "unexpected error on xml.Marshal")
+	exp := `<xmlAttrs width="10" height="20" depth="-1"></xmlAttrs>`
+	if string(data) != exp {
+		t.Errorf("expected %s, found %s", exp, string(data))
+	}
+	out := xmlAttrs{opts: omap.XMLOptions{Attributes: true}}
+	th.AssertErrNil(t, xml.Unmarshal([]byte(`<box width="10" height="20" depth="-1"><ignored>1</ignored></box>`), &out), "unexpected error on xml.Unmarshal")
+	th.ValidateIterator(t, out.m.Iterator(), true, th.JsonToKV[string, int](`[["width",10],["height",20],["depth",-1]]`))
+	th.AssertErrNotNil(t, xml.Unmarshal([]byte(`<box width="ten"/>`), &out), "expected error with invalid int attribute")
+	// text marshaler
+	mt := omap.New[string, upperText]()
+	mt.Put("name", "foo")
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	th.AssertErrNil(t, omap.MarshalXML(enc, xml.StartElement{Name: xml.Name{Local: "t"}}, mt.Iterator(), omap.XMLOptions{Attributes: true}), "unexpected error on MarshalXML")
+	th.AssertErrNil(t, enc.Flush(), "unexpected error on Flush")
+	if exp := `<t name="FOO"></t>`; buf.String() != exp {
+		t.Errorf("expected %s, found %s", exp, buf.String())
+	}
+	mt2 := omap.New[string, upperText]()
+	d := xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+	start, _ := d.Token()
+	th.AssertErrNil(t, omap.UnmarshalXML(mt2.Put, d, start.(xml.StartElement), omap.XMLOptions{Attributes: true}), "unexpected error on UnmarshalXML")
+	if v, _ := mt2.Get("name"); v != "foo" {
+		t.Errorf("expected \"foo\", found %q", v)
+	}
+	mt.Put("empty", "")
+	th.AssertErrNotNil(t, omap.MarshalXML(xml.NewEncoder(&buf), xml.StartElement{Name: xml.Name{Local: "t"}}, mt.Iterator(), omap.XMLOptions{Attributes: true}), "expected error from MarshalText")
+	// string attributes
+	ms := omap.New[string, string]()
+	d = xml.NewDecoder(bytes.NewReader([]byte(`<t a="x &amp; y"/>`)))
+	start, _ = d.Token()
+	th.AssertErrNil(t, omap.UnmarshalXML(ms.Put, d, start.(xml.StartElement), omap.XMLOptions{Attributes: true}), "unexpected error on UnmarshalXML")
+	if v, _ := ms.Get("a"); v != "x & y" {
+		t.Errorf("expected \"x & y\", found %q", v)
+	}
+}
+
+func TestXMLErrors(t *testing.T) {
+	m := omap.NewOMapLinked[string, int]()
+	th.AssertErrNotNil(t, xml.Unmarshal([]byte(`<m><a>not int</a></m>`), m), "expected error with invalid value")
+	th.AssertErrNotNil(t, xml.Unmarshal([]byte(`<m><a>1</a>`), m), "expected error with unclosed element")
+	mInt := omap.NewOMapLinked[int, int]()
+	th.AssertErrNotNil(t, xml.Unmarshal([]byte(`<m><a>1</a></m>`), mInt), "expected error with non-string key")
+	mIntAttr := omap.New[int, int]()
+	d := xml.NewDecoder(bytes.NewReader([]byte(`<t a="1"/>`)))
+	start, _ := d.Token()
+	th.AssertErrNotNil(t, omap.UnmarshalXML(mIntAttr.Put, d, start.(xml.StartElement), omap.XMLOptions{Attributes: true}), "expected error with non-string attribute key")
+	// invalid element name
+	mInvalid := omap.NewOMapLinked[string, int]()
+	mInvalid.Put("", 1)
+	_, err := xml.Marshal(mInvalid)
+	th.AssertErrNotNil(t, err, "expected error with empty element name")
+	mInvalidAttr := omap.New[string, int]()
+	mInvalidAttr.Put("", 1)
+	_, err = xml.Marshal(xmlAttrs{m: mInvalidAttr})
+	th.AssertErrNotNil(t, err, "expected error with empty start element")
+}
+
+func TestXMLInvalidNames(t *testing.T) {
+	mInt := omap.NewOMapLinked[int, string]()
+	mInt.Put(1, "a")
+	_, err := xml.Marshal(mInt)
+	th.AssertErrIs(t, err, omap.ErrInvalidXMLName, "expected error with numeric element name")
+	for _, key := range []string{"bad key", "1a", "-a", "a:b", "a<b"} {
+		m := omap.New[string, int]()
+		m.Put("ok", 1)
+		m.Put(key, 2)
+		_, err = xml.Marshal(m)
+		th.AssertErrIs(t, err, omap.ErrInvalidXMLName, "expected error with element name "+key)
+		_, err = xml.Marshal(omap.XMLMap[string, int]{Map: m, Options: omap.XMLOptions{Attributes: true}})
+		th.AssertErrIs(t, err, omap.ErrInvalidXMLName, "expected error with attribute name "+key)
+	}
+	m := omap.New[string, int]()
+	for _, key := range []string{"a", "_a", "a-1.b_c", "ação", "Ωmega·x"} {
+		m.Put(key, 1)
+	}
+	data, err := xml.Marshal(m)
+	th.AssertErrNil(t, err, "unexpected error with valid names")
+	if exp := `<OMapLinked><a>1</a><_a>1</_a><a-1.b_c>1</a-1.b_c><ação>1</ação><Ωmega·x>1</Ωmega·x></OMapLinked>`; string(data) != exp {
+		t.Errorf("expected %s, found %s", exp, string(data))
+	}
+}
+
+func TestXMLInterface(t *testing.T) {
+	m := omap.NewOMapLinked[string, any]()
+	input := `<m><a>1</a><b><y>2</y><x><z>3</z></x></b><c></c></m>`
+	th.AssertErrNil(t, xml.Unmarshal([]byte(input), m), "unexpected error on xml.Unmarshal")
+	if s := fmt.Sprint(m); s != "omap.OMapLinked[a:1 b:omap.OMapLinked[y:2 x:omap.OMapLinked[z:3]] c:]" {
+		t.Errorf("unexpected map %s", s)
+	}
+	b, _ := m.Get("b")
+	if _, ok := b.(omap.OMap[string, any]); !ok {
+		t.Errorf("expected nested element to be decoded as omap.OMap[string, any], found %T", b)
+	}
+	// round trip
+	data, err := xml.Marshal(m)
+	th.AssertErrNil(t, err, "unexpected error on xml.Marshal")
+	if exp := `<OMapLinked><a>1</a><b><y>2</y><x><z>3</z></x></b><c></c></OMapLinked>`; string(data) != exp {
+		t.Errorf("expected %s, found %s", exp, string(data))
+	}
+	// attributes
+	attrs := omap.XMLMap[string, any]{Options: omap.XMLOptions{Attributes: true}}
+	th.AssertErrNil(t, xml.Unmarshal([]byte(`<t a="1" b="x"/>`), &attrs), "unexpected error on xml.Unmarshal")
+	if s := fmt.Sprint(attrs.Map); s != "omap.OMapLinked[a:1 b:x]" {
+		t.Errorf("unexpected map %s", s)
+	}
+	if a, _ := attrs.Map.Get("a"); a != "1" {
+		t.Errorf("expected attribute decoded as string \"1\", found %#v", a)
+	}
+	// interfaces that can't hold the decoded values
+	mErr := omap.NewOMapLinked[string, error]()
+	th.AssertErrNotNil(t, xml.Unmarshal([]byte(`<m><a>1</a></m>`), mErr), "expected error with error value")
+	th.AssertErrNotNil(t, xml.Unmarshal([]byte(`<m><a><b>1</b></a></m>`), mErr), "expected error with error value")
+	th.AssertErrNotNil(t, xml.Unmarshal([]byte(`<t a="1"/>`), &omap.XMLMap[string, error]{Options: omap.XMLOptions{Attributes: true}}), "expected error with error attribute")
+	// nested maps implement fmt.Stringer
+	mStringer := omap.NewOMapLinked[string, fmt.Stringer]()
+	th.AssertErrNil(t, xml.Unmarshal([]byte(`<m><a><b>1</b></a></m>`), mStringer), "unexpected error with fmt.Stringer value")
+	th.AssertErrNotNil(t, xml.Unmarshal([]byte(`<m><a>1</a></m>`), mStringer), "expected error with fmt.Stringer value")
+	th.AssertErrNotNil(t, xml.Unmarshal([]byte(`<m><a><b>1</a></m>`), m), "expected error with invalid nested element")
+	th.AssertErrNotNil(t, xml.Unmarshal([]byte(`<m><a><b>1</b>`), m), "expected error with unclosed nested element")
+}
+
+func TestXMLMap(t *testing.T) {
+	type box struct {
+		XMLName xml.Name                 `xml:"Box"`
+		Size    omap.XMLMap[string, int] `xml:"size"`
+	}
+	m := omap.New[string, int]()
+	m.Put("width", 10)
+	m.Put("height", 20)
+	data, err := xml.Marshal(box{Size: omap.XMLMap[string, int]{Map: m, Options: omap.XMLOptions{Attributes: true}}})
+	th.AssertErrNil(t, err, "unexpected error on xml.Marshal")
+	if exp := `<Box><size width="10" height="20"></size></Box>`; string(data) != exp {
+		t.Errorf("expected %s, found %s", exp, string(data))
+	}
+	out := box{Size: omap.XMLMap[string, int]{Options: omap.XMLOptions{Attributes: true}}}
+	th.AssertErrNil(t, xml.Unmarshal(data, &out), "unexpected error on xml.Unmarshal")
+	th.ValidateIterator(t, out.Size.Map.Iterator(), true, th.JsonToKV[string, int](`[["width",10],["height",20]]`))
+	// default options and nil map
+	data, err = xml.Marshal(omap.XMLMap[string, int]{})
+	th.AssertErrNil(t, err, "unexpected error on xml.Marshal")
+	if exp := `<XMLMap></XMLMap>`; string(data) != exp {
+		t.Errorf("expected %s, found %s", exp, string(data))
+	}
+	var elems omap.XMLMap[string, int]
+	th.AssertErrNil(t, xml.Unmarshal([]byte(`<m><a>1</a><b>2</b></m>`), &elems), "unexpected error on xml.Unmarshal")
+	th.ValidateIterator(t, elems.Map.Iterator(), true, th.JsonToKV[string, int](`[["a",1],["b",2]]`))
+	// reused, the previous entries are deleted, keeping the map implementation
+	sync := omap.NewOMapSync[string, int]()
+	sync.Put("old", 0)
+	elems.Map = sync
+	th.AssertErrNil(t, xml.Unmarshal([]byte(`<m><c>3</c><a>4</a></m>`), &elems), "unexpected error on xml.Unmarshal")
+	if elems.Map != sync {
+		t.Errorf("expected map to be kept, found %T", elems.Map)
+	}
+	th.ValidateIterator(t, elems.Map.Iterator(), true, th.JsonToKV[string, int](`[["c",3],["a",4]]`))
+}
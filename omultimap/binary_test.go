@@ -0,0 +1,28 @@
+package omultimap_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+)
+
+func TestGob(t *testing.T) {
+	mm := omultimap.NewOMultiMapLinked[string, int]()
+	mm.Put("foo", 1, 2)
+	mm.Put("bar", 3)
+	mm.Put("foo", 4)
+	var buf bytes.Buffer
+	th.AssertErrNil(t, gob.NewEncoder(&buf).Encode(mm), "unexpected error on gob encode")
+	res := omultimap.NewOMultiMapLinked[string, int]()
+	res.Put("to be removed", 0)
+	th.AssertErrNil(t, gob.NewDecoder(&buf).Decode(res), "unexpected error on gob decode")
+	th.ValidateIterator(t, res.Iterator(), true, th.JsonToKV[string, int](`[["foo",1],["foo",2],["bar",3],["foo",4]]`))
+	th.ValidateIterator(t, res.GetValuesOf("foo"), true, th.JsonToKV[string, int](`[["foo",1],["foo",2],["foo",4]]`))
+	if res.Len() != 4 {
+		t.Errorf("expected len of 4, found %d", res.Len())
+	}
+	th.AssertErrNotNil(t, res.(*omultimap.OMultiMapLinked[string, int]).UnmarshalBinary([]byte{}), "expected error with empty data")
+}
@@ -0,0 +1,37 @@
+package omultimap
+
+import (
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+// FlagValue is a flag.Value that parses each occurrence of a command-line flag as key/values
+// (e.g. "-header 'Accept: a' -header 'Accept: b'") into an ordered multimap, keeping all values
+// in the order given (the Duplicate option is ignored). The zero value is ready to use (with
+// default options), see also NewFlagValue.
+type FlagValue struct {
+	// The multimap holding the key/values, created by the first Set if nil.
+	OMultiMap[string, string]
+	Options omap.FlagOptions
+}
+
+// Create a new FlagValue with the given options.
+func NewFlagValue(opts omap.FlagOptions) *FlagValue {
+	return &FlagValue{OMultiMap: New[string, string](), Options: opts}
+}
+
+// Implement flag.Value interface, see omap.FlagArgString.
+func (v *FlagValue) String() string {
+	if v == nil || v.OMultiMap == nil {
+		return ""
+	}
+	return omap.FlagArgString(v.Iterator(), v.Options)
+}
+
+// Implement flag.Value interface, parsing s with omap.ParseFlagArg. In case of error the multimap
+// is not changed.
+func (v *FlagValue) Set(s string) error {
+	if v.OMultiMap == nil {
+		v.OMultiMap = New[string, string]()
+	}
+	return omap.ParseFlagArg(func(key, value string) { v.OMultiMap.Put(key, value) }, s, v.Options)
+}
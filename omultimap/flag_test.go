@@ -0,0 +1,35 @@
+package omultimap_test
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+)
+
+func TestFlagValue(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var labels omultimap.FlagValue
+	headers := omultimap.NewFlagValue(omap.FlagOptions{Separator: ":", TrimSpace: true, Duplicate: omap.DuplicateError})
+	fs.Var(&labels, "label", "labels")
+	fs.Var(headers, "header", "headers")
+	err := fs.Parse([]string{"-label", "z=1", "-header", "Accept: a", "-label", "a=2", "-header", "Accept: b", "-label", "z=3"})
+	th.AssertErrNil(t, err, "unexpected error on Parse")
+	th.ValidateIterator(t, labels.Iterator(), true, []th.KeyValue[string, string]{{Key: "z", Value: "1"}, {Key: "a", Value: "2"}, {Key: "z", Value: "3"}})
+	th.ValidateIterator(t, headers.Iterator(), true, []th.KeyValue[string, string]{{Key: "Accept", Value: "a"}, {Key: "Accept", Value: "b"}})
+	if s := labels.String(); s != "z=1,a=2,z=3" {
+		t.Errorf("unexpected String() %q", s)
+	}
+	th.AssertErrIs(t, labels.Set("invalid"), omap.ErrInvalidFlag, "expected ErrInvalidFlag")
+	if labels.Len() != 3 {
+		t.Errorf("expected multimap unchanged on error")
+	}
+	var zero *omultimap.FlagValue
+	if zero.String() != "" || (&omultimap.FlagValue{}).String() != "" {
+		t.Errorf("expected empty String() of zero values")
+	}
+}
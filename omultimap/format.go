@@ -0,0 +1,64 @@
+package omultimap
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/matheusoliveira/go-ordered-map/internal/gotype"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+// Return the type parameters list of K and V, as in Go source (e.g. "[string, int]"), see
+// gotype.String for the types of other packages.
+func typeArgs[K comparable, V any]() string {
+	return "[" + gotype.String(reflect.TypeOf((*K)(nil)).Elem()) + ", " + gotype.String(reflect.TypeOf((*V)(nil)).Elem()) + "]"
+}
+
+// Implement fmt.Formatter interface, see omap.IteratorFormat and GoString.
+func (m *OMultiMapLinked[K, V]) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		io.WriteString(f, m.GoString())
+	} else {
+		omap.IteratorFormat[K, V](f, verb, "omultimap.OMultiMapLinked", m.Iterator())
+	}
+}
+
+// Implement fmt.GoStringer interface, see omap.IteratorToGoString.
+func (m *OMultiMapLinked[K, V]) GoString() string {
+	return omap.IteratorToGoString[K, V]("omultimap.OMultiMap"+typeArgs[K, V](), "omultimap.NewOMultiMapLinked"+typeArgs[K, V]()+"()", m.Iterator())
+}
+
+// Implement fmt.Formatter interface, see omap.IteratorFormat and GoString.
+func (m *OMultiMapSync[K, V]) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		io.WriteString(f, m.GoString())
+		return
+	}
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	omap.IteratorFormat[K, V](f, verb, "omultimap.OMultiMapSync", m.omm.Iterator())
+}
+
+// Implement fmt.GoStringer interface, see omap.IteratorToGoString.
+func (m *OMultiMapSync[K, V]) GoString() string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return omap.IteratorToGoString[K, V]("omultimap.OMultiMap"+typeArgs[K, V](), "omultimap.NewOMultiMapSync"+typeArgs[K, V]()+"()", m.omm.Iterator())
+}
+
+// Implement fmt.Formatter interface, see omap.IteratorFormat and GoString.
+func (m *OMultiMapFolded[V]) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		io.WriteString(f, m.GoString())
+	} else {
+		omap.IteratorFormat[string, V](f, verb, "omultimap.OMultiMapFolded", m.Iterator())
+	}
+}
+
+// Implement fmt.GoStringer interface, see omap.IteratorToGoString. The fold function cannot be
+// represented, so the expression uses the default one (strings.ToLower).
+func (m *OMultiMapFolded[V]) GoString() string {
+	vType := gotype.String(reflect.TypeOf((*V)(nil)).Elem())
+	return omap.IteratorToGoString[string, V]("omultimap.OMultiMap"+typeArgs[string, V](), "omultimap.NewOMultiMapFolded["+vType+"](nil)", m.Iterator())
+}
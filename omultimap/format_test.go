@@ -0,0 +1,47 @@
+package omultimap_test
+
+import (
+	"fmt"
+	"go/parser"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	constructors := map[string]string{
+		implLinked: "omultimap.NewOMultiMapLinked[string, string]()",
+		implSync:   "omultimap.NewOMultiMapSync[string, string]()",
+		implFolded: "omultimap.NewOMultiMapFolded[string](nil)",
+	}
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			m := impl.initializerStrStr()
+			m.Put("z", "1", "2")
+			m.Put("a", "3")
+			str := m.(fmt.Stringer).String()
+			typeName := str[:strings.Index(str, "[")]
+			cases := []struct {
+				format string
+				exp    string
+			}{
+				{"%v", str},
+				{"%+v", typeName + `["z":1 "z":2 "a":3]`},
+				{"%q", typeName + `["z":"1" "z":"2" "a":"3"]`},
+				{"%.2s", typeName + "[z:1 z:2 ...]"},
+				{"%-3v|", str + "|"},
+				{"%#v", `func() omultimap.OMultiMap[string, string] { m := ` + constructors[impl.name] + `; m.Put("z", "1"); m.Put("z", "2"); m.Put("a", "3"); return m }()`},
+			}
+			for _, c := range cases {
+				if s := fmt.Sprintf(c.format, m); s != c.exp {
+					t.Errorf("%s: expected %q, found %q", c.format, c.exp, s)
+				}
+			}
+			if s := m.(fmt.GoStringer).GoString(); s != cases[len(cases)-1].exp {
+				t.Errorf("unexpected GoString() %q", s)
+			}
+			if _, err := parser.ParseExpr(fmt.Sprintf("%#v", m)); err != nil {
+				t.Errorf("%%#v is not a valid Go expression: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package omultimap
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+// OrderedHeader holds the fields of a MIME/HTTP header keeping them as found on the wire: in the
+// same order, with the original casing of the keys, and with repeated fields kept as separated
+// entries. Lookups (Get, Values, Set and Del) are case-insensitive, so they match the same fields
+// as the canonical keys of http.Header.
+//
+// The zero value is not usable, create one with NewOrderedHeader, ReadOrderedHeader or
+// NewOrderedHeaderFrom. Lookups are O(n), which is fine for the usual size of headers.
+type OrderedHeader struct {
+	OMultiMap[string, string]
+}
+
+var headerNewlineToSpace = strings.NewReplacer("\n", " ", "\r", " ")
+
+// Create a new empty OrderedHeader.
+func NewOrderedHeader() OrderedHeader {
+	return OrderedHeader{New[string, string]()}
+}
+
+// Create a new OrderedHeader from the given http.Header. As http.Header has no order, fields are
+// added sorted by key (values of each key are kept in order), using the canonical keys.
+func NewOrderedHeaderFrom(h http.Header) OrderedHeader {
+	ret := NewOrderedHeader()
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		ret.Put(key, h[key]...)
+	}
+	return ret
+}
+
+// Read a MIME-style header from r, as textproto.Reader.ReadMIMEHeader does (including continued
+// lines), up to and including the blank line that ends it. Different from ReadMIMEHeader, the
+// fields are kept in order, with the original casing of the keys.
+//
+// As ReadMIMEHeader, in case of error, the fields read so far are returned along with the error.
+func ReadOrderedHeader(r *bufio.Reader) (OrderedHeader, error) {
+	h := NewOrderedHeader()
+	tp := textproto.NewReader(r)
+	if buf, err := r.Peek(1); err == nil && (buf[0] == ' ' || buf[0] == '\t') {
+		line, _ := tp.ReadLine()
+		return h, textproto.ProtocolError("malformed MIME header initial line: " + line)
+	}
+	for {
+		line, err := tp.ReadContinuedLine()
+		if line == "" {
+			return h, err
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return h, textproto.ProtocolError("malformed MIME header line: " + line)
+		}
+		h.Put(key, strings.Trim(value, " \t"))
+		if err != nil {
+			return h, err
+		}
+	}
+}
+
+// Return true if the given keys are the same, ignoring case (same as comparing canonical keys).
+func headerKeyMatch(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
+
+// Add the key/value to the end of the header, keeping the casing of key.
+func (h OrderedHeader) Add(key, value string) {
+	h.Put(key, value)
+}
+
+// Return the first value associated with the given key, case-insensitive, or "" if not found.
+func (h OrderedHeader) Get(key string) string {
+	for it := h.Iterator(); it.Next(); {
+		if headerKeyMatch(it.Key(), key) {
+			return it.Value()
+		}
+	}
+	return ""
+}
+
+// Return all values associated with the given key, case-insensitive, in order.
+func (h OrderedHeader) Values(key string) []string {
+	var ret []string
+	for it := h.Iterator(); it.Next(); {
+		if headerKeyMatch(it.Key(), key) {
+			ret = append(ret, it.Value())
+		}
+	}
+	return ret
+}
+
+// Set the value of the given key, case-insensitive, replacing any existing values. If the key
+// already exists, the value is set at the position (and with the casing) of its first occurrence,
+// otherwise it is added at the end.
+func (h OrderedHeader) Set(key, value string) {
+	type field struct {
+		key, value string
+	}
+	// rebuild the header, dropping all other occurrences
+	fields := make([]field, 0, h.Len())
+	found := false
+	for it := h.Iterator(); it.Next(); {
+		if !headerKeyMatch(it.Key(), key) {
+			fields = append(fields, field{it.Key(), it.Value()})
+		} else if !found {
+			fields = append(fields, field{it.Key(), value})
+			found = true
+		}
+	}
+	if !found {
+		h.Put(key, value)
+		return
+	}
+	for _, k := range omap.IteratorKeysToSlice(h.Iterator()) {
+		h.DeleteAll(k)
+	}
+	for _, f := range fields {
+		h.Put(f.key, f.value)
+	}
+}
+
+// Delete all values associated with the given key, case-insensitive.
+func (h OrderedHeader) Del(key string) {
+	for _, k := range omap.IteratorKeysToSlice(h.Iterator()) {
+		if headerKeyMatch(k, key) {
+			h.DeleteAll(k)
+		}
+	}
+}
+
+// Write the header in wire format to w, in order and with the original casing of the keys. As
+// http.Header.Write, newlines in values are replaced by spaces, and the blank line that ends a
+// header is not written.
+func (h OrderedHeader) Write(w io.Writer) error {
+	var buf strings.Builder
+	for it := h.Iterator(); it.Next(); {
+		buf.WriteString(it.Key())
+		buf.WriteString(": ")
+		buf.WriteString(strings.TrimSpace(headerNewlineToSpace.Replace(it.Value())))
+		buf.WriteString("\r\n")
+	}
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// Convert into an http.Header, using canonical keys, values of the same key are kept in order.
+func (h OrderedHeader) Header() http.Header {
+	ret := make(http.Header)
+	for it := h.Iterator(); it.Next(); {
+		key := textproto.CanonicalMIMEHeaderKey(it.Key())
+		ret[key] = append(ret[key], it.Value())
+	}
+	return ret
+}
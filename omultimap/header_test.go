@@ -0,0 +1,121 @@
+package omultimap_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+)
+
+func TestOrderedHeader(t *testing.T) {
+	raw := "Host: example.com\r\n" +
+		"x-custom-ID: 1\r\n" +
+		"Accept: text/html,\r\n" +
+		"\tapplication/json\r\n" +
+		"Set-Cookie: a=1\r\n" +
+		"X-CUSTOM-ID:2  \r\n" +
+		"Set-Cookie: b=2\r\n" +
+		"\r\n" +
+		"body"
+	r := bufio.NewReader(strings.NewReader(raw))
+	h, err := omultimap.ReadOrderedHeader(r)
+	th.AssertErrNil(t, err, "unexpected error on ReadOrderedHeader")
+	exp := `[["Host","example.com"],["x-custom-ID","1"],["Accept","text/html, application/json"],["Set-Cookie","a=1"],["X-CUSTOM-ID","2"],["Set-Cookie","b=2"]]`
+	th.ValidateIterator(t, h.Iterator(), true, th.JsonToKV[string, string](exp))
+	if rest, _ := r.ReadString(0); rest != "body" {
+		t.Errorf("expected reader to be at body, found %q", rest)
+	}
+	// same result as textproto
+	mime, err := textproto.NewReader(bufio.NewReader(strings.NewReader(raw))).ReadMIMEHeader()
+	th.AssertErrNil(t, err, "unexpected error on ReadMIMEHeader")
+	if !reflect.DeepEqual(http.Header(mime), h.Header()) {
+		t.Errorf("expected %v, found %v", mime, h.Header())
+	}
+	// lookups
+	if v := h.Get("X-Custom-Id"); v != "1" {
+		t.Errorf("expected \"1\", found %q", v)
+	}
+	if v := h.Get("Missing"); v != "" {
+		t.Errorf("expected empty value, found %q", v)
+	}
+	if v := h.Values("x-custom-id"); !reflect.DeepEqual(v, []string{"1", "2"}) {
+		t.Errorf("expected [1 2], found %v", v)
+	}
+	// write back
+	var buf bytes.Buffer
+	th.AssertErrNil(t, h.Write(&buf), "unexpected error on Write")
+	expRaw := "Host: example.com\r\nx-custom-ID: 1\r\nAccept: text/html, application/json\r\nSet-Cookie: a=1\r\nX-CUSTOM-ID: 2\r\nSet-Cookie: b=2\r\n"
+	if buf.String() != expRaw {
+		t.Errorf("expected %q, found %q", expRaw, buf.String())
+	}
+	// changes
+	h.Set("X-Custom-Id", "3")
+	h.Set("New", "4")
+	h.Del("set-cookie")
+	h.Add("set-cookie", "c=3\r\ninjected: 1")
+	exp = `[["Host","example.com"],["x-custom-ID","3"],["Accept","text/html, application/json"],["New","4"],["set-cookie","c=3\r\ninjected: 1"]]`
+	th.ValidateIterator(t, h.Iterator(), true, th.JsonToKV[string, string](exp))
+	buf.Reset()
+	th.AssertErrNil(t, h.Write(&buf), "unexpected error on Write")
+	expRaw = "Host: example.com\r\nx-custom-ID: 3\r\nAccept: text/html, application/json\r\nNew: 4\r\nset-cookie: c=3  injected: 1\r\n"
+	if buf.String() != expRaw {
+		t.Errorf("expected %q, found %q", expRaw, buf.String())
+	}
+}
+
+func TestOrderedHeaderFromHTTP(t *testing.T) {
+	hh := http.Header{}
+	hh.Add("Zeta", "1")
+	hh.Add("Alpha", "2")
+	hh.Add("Zeta", "3")
+	h := omultimap.NewOrderedHeaderFrom(hh)
+	th.ValidateIterator(t, h.Iterator(), true, th.JsonToKV[string, string](`[["Alpha","2"],["Zeta","1"],["Zeta","3"]]`))
+	if !reflect.DeepEqual(hh, h.Header()) {
+		t.Errorf("expected %v, found %v", hh, h.Header())
+	}
+	if len(omultimap.NewOrderedHeader().Header()) != 0 {
+		t.Error("expected empty http.Header")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestOrderedHeaderErrors(t *testing.T) {
+	invalid := []string{
+		" Host: x\r\n\r\n",
+		"Host x\r\n\r\n",
+		": x\r\n\r\n",
+		"Bad Key: x\r\n\r\n",
+	}
+	for _, raw := range invalid {
+		_, err := omultimap.ReadOrderedHeader(bufio.NewReader(strings.NewReader(raw)))
+		var protoErr textproto.ProtocolError
+		if !errors.As(err, &protoErr) {
+			t.Errorf("expected ProtocolError for %q, found %v", raw, err)
+		}
+	}
+	// unexpected EOF, returning what was read so far
+	h, err := omultimap.ReadOrderedHeader(bufio.NewReader(strings.NewReader("A: 1\r\nB: 2")))
+	th.AssertErrNotNil(t, err, "expected error on EOF")
+	th.ValidateIterator(t, h.Iterator(), true, th.JsonToKV[string, string](`[["A","1"],["B","2"]]`))
+	_, err = omultimap.ReadOrderedHeader(bufio.NewReader(strings.NewReader(" ")))
+	th.AssertErrNotNil(t, err, "expected error on EOF")
+	h, err = omultimap.ReadOrderedHeader(bufio.NewReader(strings.NewReader("")))
+	th.AssertErrNotNil(t, err, "expected error on EOF")
+	if h.Len() != 0 {
+		t.Errorf("expected empty header, found %v", h)
+	}
+	h.Add("A", "1")
+	th.AssertErrNotNil(t, h.Write(failingWriter{}), "expected error from writer")
+}
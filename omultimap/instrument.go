@@ -0,0 +1,155 @@
+package omultimap
+
+import (
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+// Implements an OMultiMap that counts the calls made to an inner OMultiMap, and to its iterators,
+// into an omap.MetricsSink, see the omap.Metric* constants for the metrics counted. Iterators of
+// this map are unwrapped by PutAfter and DeleteAt before reaching the inner map, iterators of
+// other instrumented maps fail with omap.ErrInvalidIteratorMap (even if they share the same inner
+// map), and any other iterator is given to the inner map as is, so it returns the same errors it
+// would return for its own iterators.
+//
+// The instrumented map is safe for concurrent use if both inner and sink are.
+type OMultiMapInstrumented[K comparable, V any] struct {
+	inner OMultiMap[K, V]
+	sink  omap.MetricsSink
+}
+
+// Iterator for OMultiMapInstrumented, used for both Iterator and GetValuesOf, counting its steps.
+type OMultiMapInstrumentedIterator[K comparable, V any] struct {
+	it omap.OMapIterator[K, V]
+	m  *OMultiMapInstrumented[K, V]
+}
+
+// Return m instrumented to count its calls into sink, see OMultiMapInstrumented.
+func Instrument[K comparable, V any](m OMultiMap[K, V], sink omap.MetricsSink) OMultiMap[K, V] {
+	return &OMultiMapInstrumented[K, V]{inner: m, sink: sink}
+}
+
+func (m *OMultiMapInstrumented[K, V]) newIterator(it omap.OMapIterator[K, V]) omap.OMapIterator[K, V] {
+	m.sink.Add(omap.MetricIterator, 1)
+	return &OMultiMapInstrumentedIterator[K, V]{it: it, m: m}
+}
+
+// return the inner iterator if interfaceIt is an iterator of m, an error if it is of another
+// instrumented map, or interfaceIt itself otherwise
+func (m *OMultiMapInstrumented[K, V]) unwrap(interfaceIt omap.OMapIterator[K, V]) (omap.OMapIterator[K, V], error) {
+	it, ok := interfaceIt.(*OMultiMapInstrumentedIterator[K, V])
+	if !ok {
+		return interfaceIt, nil
+	}
+	if it.m != m {
+		return nil, omap.ErrInvalidIteratorMap
+	}
+	return it.it, nil
+}
+
+func (m *OMultiMapInstrumented[K, V]) Put(key K, values ...V) {
+	m.sink.Add(omap.MetricPut, 1)
+	m.inner.Put(key, values...)
+}
+
+func (m *OMultiMapInstrumented[K, V]) PutAfter(interfaceIt omap.OMapIterator[K, V], key K, value V) error {
+	m.sink.Add(omap.MetricPutAfter, 1)
+	it, err := m.unwrap(interfaceIt)
+	if err == nil {
+		err = m.inner.PutAfter(it, key, value)
+	}
+	if err != nil {
+		m.sink.Add(omap.ErrorMetric(omap.MetricPutAfterError, err), 1)
+	}
+	return err
+}
+
+func (m *OMultiMapInstrumented[K, V]) GetValuesOf(key K) omap.OMapIterator[K, V] {
+	m.sink.Add(omap.MetricGetValuesOf, 1)
+	return m.newIterator(m.inner.GetValuesOf(key))
+}
+
+func (m *OMultiMapInstrumented[K, V]) DeleteAll(key K) {
+	m.sink.Add(omap.MetricDeleteAll, 1)
+	m.inner.DeleteAll(key)
+}
+
+func (m *OMultiMapInstrumented[K, V]) DeleteAt(interfaceIt omap.OMapIterator[K, V]) error {
+	m.sink.Add(omap.MetricDeleteAt, 1)
+	it, err := m.unwrap(interfaceIt)
+	if err == nil {
+		err = m.inner.DeleteAt(it)
+	}
+	if err != nil {
+		m.sink.Add(omap.ErrorMetric(omap.MetricDeleteAtError, err), 1)
+	}
+	return err
+}
+
+func (m *OMultiMapInstrumented[K, V]) MustDeleteAt(interfaceIt omap.OMapIterator[K, V]) {
+	err := m.DeleteAt(interfaceIt)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (m *OMultiMapInstrumented[K, V]) Iterator() omap.OMapIterator[K, V] {
+	return m.newIterator(m.inner.Iterator())
+}
+
+func (m *OMultiMapInstrumented[K, V]) Len() int {
+	return m.inner.Len()
+}
+
+// Implement fmt.Stringer, the iteration over inner map is not counted.
+func (m *OMultiMapInstrumented[K, V]) String() string {
+	return omap.IteratorToString[K, V]("omultimap.OMultiMapInstrumented", m.inner.Iterator())
+}
+
+// Implement json.Marshaler interface, the iteration over inner map is not counted.
+func (m *OMultiMapInstrumented[K, V]) MarshalJSON() ([]byte, error) {
+	return omap.MarshalJSON(m.inner.Iterator())
+}
+
+//// Iterator ////
+
+func (it *OMultiMapInstrumentedIterator[K, V]) Next() bool {
+	ok := it.it.Next()
+	if ok {
+		it.m.sink.Add(omap.MetricIteratorStep, 1)
+	}
+	return ok
+}
+
+func (it *OMultiMapInstrumentedIterator[K, V]) EOF() bool {
+	return it.it.EOF()
+}
+
+func (it *OMultiMapInstrumentedIterator[K, V]) Key() K {
+	return it.it.Key()
+}
+
+func (it *OMultiMapInstrumentedIterator[K, V]) Value() V {
+	return it.it.Value()
+}
+
+func (it *OMultiMapInstrumentedIterator[K, V]) IsValid() bool {
+	return it.it.IsValid()
+}
+
+func (it *OMultiMapInstrumentedIterator[K, V]) MoveFront() omap.OMapIterator[K, V] {
+	it.it.MoveFront()
+	return it
+}
+
+func (it *OMultiMapInstrumentedIterator[K, V]) MoveBack() omap.OMapIterator[K, V] {
+	it.it.MoveBack()
+	return it
+}
+
+func (it *OMultiMapInstrumentedIterator[K, V]) Prev() bool {
+	ok := it.it.Prev()
+	if ok {
+		it.m.sink.Add(omap.MetricIteratorStep, 1)
+	}
+	return ok
+}
@@ -0,0 +1,98 @@
+package omultimap_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+	"github.com/matheusoliveira/go-ordered-map/omultimap/omultimaptest"
+)
+
+func TestInstrument(t *testing.T) {
+	sink := omap.NewMemorySink()
+	mm := omultimap.Instrument(omultimap.NewOMultiMapLinked[string, int](), sink)
+	mm.Put("foo", 1, 2)
+	mm.Put("bar", 3)
+	th.ValidateIterator(t, mm.GetValuesOf("foo"), true, th.JsonToKV[string, int](`[["foo",1],["foo",2]]`))
+	// iterators of the instrumented map must be accepted
+	it := mm.Iterator()
+	it.Next()
+	th.AssertErrNil(t, mm.PutAfter(it, "baz", 4), "unexpected error on PutAfter")
+	th.AssertErrNil(t, mm.DeleteAt(it), "unexpected error on DeleteAt")
+	mm.DeleteAll("bar")
+	th.ValidateIterator(t, mm.Iterator(), true, th.JsonToKV[string, int](`[["baz",4],["foo",2]]`))
+	// errors of the inner map
+	th.AssertErrIs(t, mm.PutAfter(omultimap.Instrument(omultimap.NewOMultiMapLinked[string, int](), sink).Iterator(), "x", 0), omap.ErrInvalidIteratorMap, "expected iterator of another map to fail")
+	th.AssertErrIs(t, mm.DeleteAt(omap.NewOMapLinked[string, int]().Iterator()), omap.ErrInvalidIteratorType, "expected iterator of another type to fail")
+	th.AssertErrIs(t, mm.DeleteAt(it), omap.ErrInvalidIteratorKey, "expected iterator of deleted entry to fail")
+	if mm.Len() != 2 {
+		t.Errorf("expected Len() of 2, found %d", mm.Len())
+	}
+	expected := map[omap.Metric]int64{
+		omap.MetricPut:         2,
+		omap.MetricGetValuesOf: 1,
+		omap.MetricPutAfter:    2,
+		omap.MetricDeleteAt:    3,
+		omap.MetricDeleteAll:   1,
+		// GetValuesOf x1, Iterator x3 (one of another map)
+		omap.MetricIterator: 4,
+		// ValidateIterator goes forward and back: 2+2 + 2+2, plus 1 before PutAfter
+		omap.MetricIteratorStep:                             9,
+		omap.MetricPutAfterError + ".invalid_iterator_map":  1,
+		omap.MetricDeleteAtError + ".invalid_iterator_type": 1,
+		omap.MetricDeleteAtError + ".invalid_iterator_key":  1,
+	}
+	if s := sink.Snapshot(); !reflect.DeepEqual(s, expected) {
+		t.Errorf("expected metrics %v, found %v", expected, s)
+	}
+	// not counted
+	if s := fmt.Sprint(mm); s != "omultimap.OMultiMapInstrumented[baz:4 foo:2]" {
+		t.Errorf("unexpected String() %q", s)
+	}
+	if b, err := json.Marshal(mm); err != nil || string(b) != `{"baz":4,"foo":2}` {
+		t.Errorf("unexpected JSON %q, error: %v", b, err)
+	}
+	if s := sink.Snapshot(); !reflect.DeepEqual(s, expected) {
+		t.Errorf("expected metrics %v, found %v", expected, s)
+	}
+}
+
+func TestInstrumentSharedInner(t *testing.T) {
+	inner := omultimap.NewOMultiMapLinked[string, int]()
+	inner.Put("a", 1, 2)
+	sink := omap.NewMemorySink()
+	mm1 := omultimap.Instrument(inner, sink)
+	mm2 := omultimap.Instrument(inner, sink)
+	it := mm1.Iterator()
+	it.Next()
+	// iterators are owned by the instrumented map, even if the inner map is the same
+	th.AssertErrIs(t, mm2.PutAfter(it, "x", 0), omap.ErrInvalidIteratorMap, "expected iterator of another instrumented map to fail")
+	th.AssertErrIs(t, mm2.DeleteAt(it), omap.ErrInvalidIteratorMap, "expected iterator of another instrumented map to fail")
+	th.AssertErrNil(t, mm1.PutAfter(it, "x", 0), "unexpected error on PutAfter")
+	// iterators of the inner map are given to it
+	innerIt := inner.Iterator()
+	innerIt.Next()
+	th.AssertErrNil(t, mm2.DeleteAt(innerIt), "unexpected error on DeleteAt with iterator of inner map")
+	th.ValidateIterator(t, inner.Iterator(), true, th.JsonToKV[string, int](`[["x",0],["a",2]]`))
+	expected := map[omap.Metric]int64{
+		omap.MetricIterator:     1,
+		omap.MetricIteratorStep: 1,
+		omap.MetricPutAfter:     2,
+		omap.MetricDeleteAt:     2,
+		omap.MetricPutAfterError + ".invalid_iterator_map": 1,
+		omap.MetricDeleteAtError + ".invalid_iterator_map": 1,
+	}
+	if s := sink.Snapshot(); !reflect.DeepEqual(s, expected) {
+		t.Errorf("expected metrics %v, found %v", expected, s)
+	}
+}
+
+func TestInstrumentConformance(t *testing.T) {
+	omultimaptest.RunConformance(t, func() omultimap.OMultiMap[string, int] {
+		return omultimap.Instrument(omultimap.NewOMultiMapLinked[string, int](), omap.NewMemorySink())
+	})
+}
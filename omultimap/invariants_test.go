@@ -0,0 +1,94 @@
+package omultimap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+func assertViolation(t *testing.T, err error, expected string) {
+	t.Helper()
+	var invErr *omap.InvariantError
+	if !errors.Is(err, omap.ErrBrokenInvariant) || !errors.As(err, &invErr) {
+		t.Errorf("expected an *omap.InvariantError wrapping omap.ErrBrokenInvariant, found %v", err)
+		return
+	}
+	for _, v := range invErr.Violations {
+		if strings.Contains(v, expected) {
+			return
+		}
+	}
+	t.Errorf("expected a violation containing %q, found %v", expected, err)
+}
+
+// a/0, b/1, a/2
+func newLinkedABA() *OMultiMapLinked[string, int] {
+	m, _ := NewOMultiMapLinked[string, int]().(*OMultiMapLinked[string, int])
+	m.Put("a", 0)
+	m.Put("b", 1)
+	m.Put("a", 2)
+	return m
+}
+
+func TestValidateLinked(t *testing.T) {
+	if err := omap.CheckInvariants(NewOMultiMapLinked[string, int]()); err != nil {
+		t.Errorf("unexpected error on empty map: %v", err)
+	}
+	if err := omap.CheckInvariants(newLinkedABA()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, tc := range []struct {
+		name     string
+		corrupt  func(m *OMultiMapLinked[string, int])
+		expected []string
+	}{
+		{"length", func(m *OMultiMapLinked[string, int]) { m.length = 4 }, []string{"length is 4, but lookup map has 3 entries", "walking forward found 3 entries, expected 4", "walking backward found 3 entries, expected 4"}},
+		{"empty values", func(m *OMultiMapLinked[string, int]) { m.m["x"] = nil }, []string{"lookup map has no values for key x"}},
+		{"nil value", func(m *OMultiMapLinked[string, int]) { m.m["b"] = append(m.m["b"], nil) }, []string{"lookup map has a nil entry at 1 for key b"}},
+		{"wrong key", func(m *OMultiMapLinked[string, int]) { m.m["b"] = m.m["a"][:1] }, []string{"lookup map has key b pointing to entry of key a", "entry 1 of key b is not in the lookup map"}},
+		{"repeated value", func(m *OMultiMapLinked[string, int]) { m.m["a"] = []*mapEntry[string, int]{m.m["a"][0], m.m["a"][0]} }, []string{"lookup map has a repeated entry at 1 for key a", "entry 2 of key a is not in the lookup map"}},
+		{"missing value", func(m *OMultiMapLinked[string, int]) { m.m["a"] = m.m["a"][:1] }, []string{"entry 2 of key a is not in the lookup map", "length is 3, but lookup map has 2 entries"}},
+		{"head prev", func(m *OMultiMapLinked[string, int]) { m.head.prev = m.tail }, []string{"head has a prev entry", "walking backward found more than the 3 expected entries"}},
+		{"tail next", func(m *OMultiMapLinked[string, int]) { m.tail.next = m.head }, []string{"tail has a next entry", "walking forward found more than the 3 expected entries", "entry 0 from the tail does not point forward"}},
+		{"wrong tail", func(m *OMultiMapLinked[string, int]) { m.tail = m.head.next }, []string{"walking forward ended at entry 2, which is not the tail", "walking backward found 2 entries, expected 3"}},
+		{"prev link", func(m *OMultiMapLinked[string, int]) { m.tail.prev = m.head }, []string{"entry 2 does not point back to entry 1", "walking backward found 2 entries, expected 3"}},
+		{"nil head", func(m *OMultiMapLinked[string, int]) { m.head = nil }, []string{"only one of head and tail is nil", "walking forward found 0 entries, expected 3", "walking backward ended at entry 2 from the tail, which is not the head"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newLinkedABA()
+			tc.corrupt(m)
+			err := m.Validate()
+			for _, exp := range tc.expected {
+				assertViolation(t, err, exp)
+			}
+		})
+	}
+}
+
+func TestValidateWrappers(t *testing.T) {
+	// sync
+	s, _ := NewOMultiMapSync[string, int]().(*OMultiMapSync[string, int])
+	s.Put("a", 0, 1)
+	if err := omap.CheckInvariants(s); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	s.omm = newLinkedABA()
+	s.omm.(*OMultiMapLinked[string, int]).length = 2
+	assertViolation(t, omap.CheckInvariants(s), "length is 2, but lookup map has 3 entries")
+	// folded
+	f, _ := NewOMultiMapFolded[int](nil).(*OMultiMapFolded[int])
+	f.Put("Accept", 0)
+	f.Put("ACCEPT", 1)
+	if err := omap.CheckInvariants(f); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	f.omm.Put("accept", foldedEntry[int]{"accepT", 2})
+	f.omm.Put("Host", foldedEntry[int]{"Host", 3})
+	err := omap.CheckInvariants(f)
+	assertViolation(t, err, `key "accept" is spelled as "accepT", but its first value has "Accept"`)
+	assertViolation(t, err, `key "Host" is indexed as "Host" instead of "host"`)
+	f.omm.(*OMultiMapLinked[string, foldedEntry[int]]).length = 0
+	assertViolation(t, omap.CheckInvariants(f), "length is 0, but lookup map has 4 entries")
+}
@@ -0,0 +1,298 @@
+package omultimap_test
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+)
+
+type modelEntry struct {
+	id int
+	th.KeyValue[string, int]
+}
+
+// Reference model of an omultimap: a plain slice of key/value pairs, in iteration order, along with
+// the order GetValuesOf returns the values of each key, as entries are tracked by id. Values are in
+// the order they were added, except that PutAfter an entry of the same key adds right after it.
+// Methods never change the model, but return a new one, so it can be used as state of lin.Model.
+type model struct {
+	entries []modelEntry
+	values  map[string][]int
+	lastID  int
+}
+
+func newModel() model {
+	return model{values: map[string][]int{}}
+}
+
+func (md model) kvs() []th.KeyValue[string, int] {
+	ret := make([]th.KeyValue[string, int], len(md.entries))
+	for i, e := range md.entries {
+		ret[i] = e.KeyValue
+	}
+	return ret
+}
+
+func (md model) valuesOf(key string) []th.KeyValue[string, int] {
+	ret := make([]th.KeyValue[string, int], 0, len(md.values[key]))
+	for _, id := range md.values[key] {
+		for _, e := range md.entries {
+			if e.id == id {
+				ret = append(ret, e.KeyValue)
+			}
+		}
+	}
+	return ret
+}
+
+// Return a copy of md with the values of key replaced, or removed if empty.
+func (md model) withValues(key string, ids []int) model {
+	values := make(map[string][]int, len(md.values)+1)
+	for k, v := range md.values {
+		values[k] = v
+	}
+	if len(ids) == 0 {
+		delete(values, key)
+	} else {
+		values[key] = ids
+	}
+	md.values = values
+	return md
+}
+
+// Insert ids into a copy of s at position i.
+func insertIds(s []int, i int, ids ...int) []int {
+	ret := make([]int, 0, len(s)+len(ids))
+	ret = append(ret, s[:i]...)
+	ret = append(ret, ids...)
+	return append(ret, s[i:]...)
+}
+
+func (md model) put(key string, vals ...int) model {
+	entries := make([]modelEntry, 0, len(md.entries)+len(vals))
+	entries = append(entries, md.entries...)
+	ids := make([]int, len(vals))
+	for i, val := range vals {
+		md.lastID++
+		ids[i] = md.lastID
+		entries = append(entries, modelEntry{md.lastID, th.KeyValue[string, int]{Key: key, Value: val}})
+	}
+	md.entries = entries
+	return md.withValues(key, insertIds(md.values[key], len(md.values[key]), ids...))
+}
+
+// Insert key/value after the pos-th entry, or at the beginning if pos is -1.
+func (md model) insertAfter(pos int, key string, val int) model {
+	md.lastID++
+	entries := make([]modelEntry, 0, len(md.entries)+1)
+	entries = append(entries, md.entries[:pos+1]...)
+	entries = append(entries, modelEntry{md.lastID, th.KeyValue[string, int]{Key: key, Value: val}})
+	entries = append(entries, md.entries[pos+1:]...)
+	ids := md.values[key]
+	i := len(ids)
+	if pos >= 0 && md.entries[pos].Key == key {
+		for i = 0; ids[i] != md.entries[pos].id; i++ {
+		}
+		i++
+	}
+	md.entries = entries
+	return md.withValues(key, insertIds(ids, i, md.lastID))
+}
+
+func (md model) deleteAt(pos int) model {
+	e := md.entries[pos]
+	md.entries = append(md.entries[:pos:pos], md.entries[pos+1:]...)
+	ids := []int{}
+	for _, id := range md.values[e.Key] {
+		if id != e.id {
+			ids = append(ids, id)
+		}
+	}
+	return md.withValues(e.Key, ids)
+}
+
+func (md model) without(key string) model {
+	entries := []modelEntry{}
+	for _, e := range md.entries {
+		if e.Key != key {
+			entries = append(entries, e)
+		}
+	}
+	md.entries = entries
+	return md.withValues(key, nil)
+}
+
+// Return an iterator of m at the pos-th entry, or at BOF if pos is -1.
+func iteratorAt(m omultimap.OMultiMap[string, int], pos int) omap.OMapIterator[string, int] {
+	it := m.Iterator()
+	for i := 0; i <= pos; i++ {
+		it.Next()
+	}
+	return it
+}
+
+type multiOperation func(t *testing.T, md model, maps []omultimap.OMultiMap[string, int], key string, val int) model
+
+func multiOpPut(t *testing.T, md model, maps []omultimap.OMultiMap[string, int], key string, val int) model {
+	for _, m := range maps {
+		m.Put(key, val)
+	}
+	return md.put(key, val)
+}
+
+func multiOpPutMany(t *testing.T, md model, maps []omultimap.OMultiMap[string, int], key string, val int) model {
+	for _, m := range maps {
+		m.Put(key, val, val+1, val+2)
+	}
+	return md.put(key, val, val+1, val+2)
+}
+
+// PutAfter at a position given by val, from BOF up to the last entry.
+func multiOpPutAfter(t *testing.T, md model, maps []omultimap.OMultiMap[string, int], key string, val int) model {
+	pos := val%(len(md.entries)+1) - 1
+	for _, m := range maps {
+		if err := m.PutAfter(iteratorAt(m, pos), key, val); err != nil {
+			t.Errorf("unexpected error on %T.PutAfter at position %d: %v", m, pos, err)
+		}
+	}
+	return md.insertAfter(pos, key, val)
+}
+
+// PutAfter at the last entry.
+func multiOpPutAfterTail(t *testing.T, md model, maps []omultimap.OMultiMap[string, int], key string, val int) model {
+	for _, m := range maps {
+		it := m.Iterator().MoveBack()
+		it.Prev()
+		if err := m.PutAfter(it, key, val); err != nil {
+			t.Errorf("unexpected error on %T.PutAfter at tail: %v", m, err)
+		}
+	}
+	return md.insertAfter(len(md.entries)-1, key, val)
+}
+
+// DeleteAt a position given by val, or check for ErrInvalidIteratorPos if empty.
+func multiOpDeleteAt(t *testing.T, md model, maps []omultimap.OMultiMap[string, int], key string, val int) model {
+	if len(md.entries) == 0 {
+		for _, m := range maps {
+			if err := m.DeleteAt(m.Iterator()); err == nil {
+				t.Errorf("expected error on %T.DeleteAt of empty map", m)
+			}
+		}
+		return md
+	}
+	pos := val % len(md.entries)
+	for _, m := range maps {
+		it := iteratorAt(m, pos)
+		if err := m.DeleteAt(it); err != nil {
+			t.Errorf("unexpected error on %T.DeleteAt at position %d: %v", m, pos, err)
+		}
+		// iterator must be able to continue from a deleted entry
+		th.ValidateIteratorForward(t, it, true, md.kvs()[pos+1:])
+	}
+	return md.deleteAt(pos)
+}
+
+func multiOpDeleteAll(t *testing.T, md model, maps []omultimap.OMultiMap[string, int], key string, val int) model {
+	for _, m := range maps {
+		m.DeleteAll(key)
+	}
+	return md.without(key)
+}
+
+func multiOpGetValuesOf(t *testing.T, md model, maps []omultimap.OMultiMap[string, int], key string, val int) model {
+	exp := md.valuesOf(key)
+	for _, m := range maps {
+		if !th.ValidateIterator(t, m.GetValuesOf(key), true, exp) {
+			t.Errorf("%T.GetValuesOf(%q) mismatch, expected %v, found %v", m, key, exp, omap.IteratorValuesToSlice(m.GetValuesOf(key)))
+		}
+	}
+	return md
+}
+
+func validateMultiMaps(t *testing.T, md model, maps []omultimap.OMultiMap[string, int]) bool {
+	for _, m := range maps {
+		if err := omap.CheckInvariants(m); err != nil {
+			t.Errorf("%T: %v", m, err)
+			return false
+		}
+		if m.Len() != len(md.entries) {
+			t.Errorf("expected %T.Len() of %d, found %d", m, len(md.entries), m.Len())
+			return false
+		}
+		// validate forward and backwards
+		if !th.ValidateIterator(t, m.Iterator(), true, md.kvs()) {
+			return false
+		}
+		if !th.ValidateIteratorBackward(t, m.Iterator().MoveBack(), true, md.kvs()) {
+			return false
+		}
+		for key := range md.values {
+			if !th.ValidateIterator(t, m.GetValuesOf(key), true, md.valuesOf(key)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func FuzzOMultiMapImpls(f *testing.F) {
+	f.Add([]byte("1234"), []byte("0123"))
+	f.Add([]byte("12341234"), []byte("01234567"))
+	f.Add([]byte("11223344"), []byte("22334455"))
+	f.Add([]byte("9876543210"), []byte("0404040606"))
+	// few keys, so most of them have many values
+	const nKeys = 5
+	// maps are validated after each operation, so limit the number of operations to keep it fast
+	const maxOps = 256
+	opMapping := []multiOperation{
+		multiOpPut,
+		multiOpPutMany,
+		multiOpPutAfter,
+		multiOpPutAfterTail,
+		multiOpDeleteAt,
+		multiOpDeleteAll,
+		multiOpGetValuesOf,
+	}
+	opDebugMapping := []string{
+		"Put",
+		"PutMany",
+		"PutAfter",
+		"PutAfterTail",
+		"DeleteAt",
+		"DeleteAll",
+		"GetValuesOf",
+	}
+	f.Fuzz(func(t *testing.T, keyValues []byte, byteOps []byte) {
+		if len(keyValues) == 0 || len(byteOps) == 0 {
+			return
+		}
+		if len(byteOps) > maxOps {
+			byteOps = byteOps[:maxOps]
+		}
+		maps := make([]omultimap.OMultiMap[string, int], len(implementations))
+		for i, impl := range implementations {
+			maps[i] = impl.initializerStrInt()
+		}
+		md := newModel()
+		opsDebug := make([]string, 0, len(byteOps))
+		for i, op := range byteOps {
+			opId := int(op) % len(opMapping)
+			kv := int(keyValues[i%len(keyValues)])
+			key := strconv.Itoa(kv % nKeys)
+			opsDebug = append(opsDebug, fmt.Sprintf("%s(%q,%d)", opDebugMapping[opId], key, kv))
+			md = opMapping[opId](t, md, maps, key, kv)
+			if t.Failed() || !validateMultiMaps(t, md, maps) {
+				t.Logf("failed at operation %d, operations: %v", i, opsDebug)
+				t.Logf("  - expected: %v", md.kvs())
+				for _, m := range maps {
+					t.Logf("  - %T content: %v", m, m)
+				}
+				t.FailNow()
+			}
+		}
+	})
+}
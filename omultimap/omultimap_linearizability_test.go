@@ -0,0 +1,253 @@
+package omultimap_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	lin "github.com/matheusoliveira/go-ordered-map/internal/linearizability"
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+)
+
+//// Sequential model of an OMultiMap, to check histories of concurrent calls on OMultiMapSync ////
+
+type linOpKind int
+
+const (
+	linPut         linOpKind = iota // Put(key, value, value+1)
+	linGetValuesOf                  // GetValuesOf(key)
+	linDeleteAll                    // DeleteAll(key)
+	linDeleteAt                     // DeleteAt of the first entry of key, found with Iterator()
+	linPutAfter                     // PutAfter the first entry of ref, found with Iterator()
+	linLen                          // Len()
+	linSnapshot                     // json.Marshal, the only way to iterate the whole map atomically
+)
+
+type linInput struct {
+	kind  linOpKind
+	key   string
+	ref   string
+	value int
+}
+
+type linOutput struct {
+	// value found at key/ref, for DeleteAt and PutAfter, or Len
+	value  int
+	ok     bool
+	values []int
+	// content of the map for snapshots
+	snapshot []th.KeyValue[string, int]
+}
+
+func equalInts(s1, s2 []int) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+	for i := range s1 {
+		if s1[i] != s2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalKeyValues(s1, s2 []th.KeyValue[string, int]) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+	for i := range s1 {
+		if s1[i] != s2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ids of entries are not compared, as they depend on the order operations were applied
+func equalModels(s1, s2 model) bool {
+	if !equalKeyValues(s1.kvs(), s2.kvs()) {
+		return false
+	}
+	for key := range s1.values {
+		if !equalKeyValues(s1.valuesOf(key), s2.valuesOf(key)) {
+			return false
+		}
+	}
+	return true
+}
+
+// position of the given key/value, or -1 if not found
+func (md model) find(key string, value int) int {
+	for i, e := range md.entries {
+		if e.Key == key && e.Value == value {
+			return i
+		}
+	}
+	return -1
+}
+
+var omultimapModel = lin.Model[model, linInput, linOutput]{
+	Init: newModel,
+	Step: func(s model, in linInput, out linOutput) (bool, model) {
+		switch in.kind {
+		case linPut:
+			return true, s.put(in.key, in.value, in.value+1)
+		case linGetValuesOf:
+			values := []int{}
+			for _, kv := range s.valuesOf(in.key) {
+				values = append(values, kv.Value)
+			}
+			return equalInts(values, out.values), s
+		case linDeleteAll:
+			return true, s.without(in.key)
+		case linDeleteAt:
+			// the entry may be deleted between finding and deleting it, so an error is always
+			// accepted, as long as the map is unchanged
+			if !out.ok {
+				return true, s
+			}
+			if i := s.find(in.key, out.value); i >= 0 {
+				return true, s.deleteAt(i)
+			}
+			return false, s
+		case linPutAfter:
+			if !out.ok {
+				return true, s
+			}
+			if i := s.find(in.ref, out.value); i >= 0 {
+				return true, s.insertAfter(i, in.key, in.value)
+			}
+			return false, s
+		case linLen:
+			return out.value == len(s.entries), s
+		default: // linSnapshot
+			return equalKeyValues(s.kvs(), out.snapshot), s
+		}
+	},
+	Equal: equalModels,
+	Describe: func(in linInput, out linOutput) string {
+		switch in.kind {
+		case linPut:
+			return fmt.Sprintf("Put(%q, %d, %d)", in.key, in.value, in.value+1)
+		case linGetValuesOf:
+			return fmt.Sprintf("GetValuesOf(%q) = %v", in.key, out.values)
+		case linDeleteAll:
+			return fmt.Sprintf("DeleteAll(%q)", in.key)
+		case linDeleteAt:
+			return fmt.Sprintf("DeleteAt(%q/%d) ok=%v", in.key, out.value, out.ok)
+		case linPutAfter:
+			return fmt.Sprintf("PutAfter(%q/%d, %q, %d) ok=%v", in.ref, out.value, in.key, in.value, out.ok)
+		case linLen:
+			return fmt.Sprintf("Len() = %d", out.value)
+		default:
+			return fmt.Sprintf("Snapshot() = %v", out.snapshot)
+		}
+	},
+}
+
+// iterator at the first entry of key, not valid if not found
+func findFirst(m omultimap.OMultiMap[string, int], key string) omap.OMapIterator[string, int] {
+	it := m.Iterator()
+	for it.Next() && it.Key() != key {
+	}
+	return it
+}
+
+func runOMultiMapOperation(t *testing.T, m omultimap.OMultiMap[string, int], in linInput) linOutput {
+	switch in.kind {
+	case linPut:
+		m.Put(in.key, in.value, in.value+1)
+	case linGetValuesOf:
+		return linOutput{values: omap.IteratorValuesToSlice(m.GetValuesOf(in.key))}
+	case linDeleteAll:
+		m.DeleteAll(in.key)
+	case linDeleteAt:
+		if it := findFirst(m, in.key); it.IsValid() {
+			return linOutput{value: it.Value(), ok: m.DeleteAt(it) == nil}
+		}
+	case linPutAfter:
+		if it := findFirst(m, in.ref); it.IsValid() {
+			return linOutput{value: it.Value(), ok: m.PutAfter(it, in.key, in.value) == nil}
+		}
+	case linLen:
+		return linOutput{value: m.Len()}
+	case linSnapshot:
+		snapshot := []th.KeyValue[string, int]{}
+		b, err := json.Marshal(m)
+		th.AssertErrNil(t, err, "unexpected error on json.Marshal")
+		th.AssertErrNil(t, omap.UnmarshalJSON(func(k string, v int) {
+			snapshot = append(snapshot, th.KeyValue[string, int]{Key: k, Value: v})
+		}, b), "unexpected error on omap.UnmarshalJSON")
+		return linOutput{snapshot: snapshot}
+	}
+	return linOutput{}
+}
+
+// Run concurrent operations on OMultiMapSync and check if the history is linearizable, which is
+// most useful when executed with `-race`. Values are unique, so each entry found can be tracked
+// back to the write that produced it.
+func TestOMultiMapSyncLinearizability(t *testing.T) {
+	const (
+		nRounds  = 20
+		nClients = 4
+		nOps     = 25
+		nKeys    = 3
+	)
+	for round := 0; round < nRounds; round++ {
+		m := omultimap.NewOMultiMapSync[string, int]()
+		h := lin.NewHistory[linInput, linOutput](nClients)
+		var wg sync.WaitGroup
+		for c := 0; c < nClients; c++ {
+			wg.Add(1)
+			go func(c int) {
+				defer wg.Done()
+				for i := 0; i < nOps; i++ {
+					n := round*nClients*nOps + c*nOps + i
+					in := linInput{
+						kind:  linOpKind((n*5 + c) % 7),
+						key:   strconv.Itoa((n * 3) % nKeys),
+						ref:   strconv.Itoa((n * 7) % nKeys),
+						value: n * 2,
+					}
+					h.Record(c, in, func() linOutput { return runOMultiMapOperation(t, m, in) })
+				}
+			}(c)
+		}
+		wg.Wait()
+		th.AssertErrNil(t, omap.CheckInvariants(m), "invariants broken after concurrent operations")
+		if err := lin.Check(omultimapModel, h.Operations()); err != nil {
+			t.Fatalf("round %d: %v", round, err)
+		}
+	}
+}
+
+func TestOMultiMapModelDetectsViolation(t *testing.T) {
+	h := lin.NewHistory[linInput, linOutput](1)
+	m := omultimap.NewOMultiMapLinked[string, int]()
+	for i, in := range []linInput{
+		{kind: linPut, key: "a", value: 0},
+		{kind: linPut, key: "b", value: 2},
+		{kind: linPutAfter, key: "b", ref: "a", value: 4},
+		{kind: linPutAfter, key: "c", ref: "x", value: 6},
+		{kind: linDeleteAt, key: "a"},
+		{kind: linDeleteAt, key: "x"},
+		{kind: linGetValuesOf, key: "b"},
+		{kind: linLen},
+		{kind: linSnapshot},
+		{kind: linDeleteAll, key: "b"},
+		{kind: linGetValuesOf, key: "b"},
+	} {
+		h.Record(0, in, func() linOutput { return runOMultiMapOperation(t, m, in) })
+		if i == 8 {
+			// a write not recorded in the history, so the last snapshot is invalid
+			m.Put("z", 42)
+		}
+	}
+	th.AssertErrNil(t, lin.Check(omultimapModel, h.Operations()), "expected sequential history to be linearizable")
+	h.Record(0, linInput{kind: linSnapshot}, func() linOutput { return runOMultiMapOperation(t, m, linInput{kind: linSnapshot}) })
+	th.AssertErrIs(t, lin.Check(omultimapModel, h.Operations()), lin.ErrNotLinearizable, "expected unrecorded write to be detected")
+}
@@ -10,11 +10,13 @@ import (
 	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
 	"github.com/matheusoliveira/go-ordered-map/omap"
 	"github.com/matheusoliveira/go-ordered-map/omultimap"
+	"github.com/matheusoliveira/go-ordered-map/omultimap/omultimaptest"
 )
 
 const (
 	implLinked = "Linked"
 	implSync   = "Sync"
+	implFolded = "Folded"
 )
 
 type implDetail struct {
@@ -22,6 +24,7 @@ type implDetail struct {
 	isOrdered         bool
 	isParallelSafe    bool
 	initializerStrStr func() omultimap.OMultiMap[string, string]
+	initializerStrInt func() omultimap.OMultiMap[string, int]
 }
 
 var implementations []implDetail
@@ -37,12 +40,26 @@ func init() {
 			true,
 			false,
 			func() omultimap.OMultiMap[string, string] { return omultimap.NewOMultiMapLinked[string, string]() },
+			func() omultimap.OMultiMap[string, int] { return omultimap.NewOMultiMapLinked[string, int]() },
 		},
 		{
 			implSync,
 			true,
 			true,
 			func() omultimap.OMultiMap[string, string] { return omultimap.NewOMultiMapSync[string, string]() },
+			func() omultimap.OMultiMap[string, int] { return omultimap.NewOMultiMapSync[string, int]() },
+		},
+		{
+			implFolded,
+			true,
+			false,
+			// identity fold, so it must behave as any other implementation, see omultimapfolded_test.go
+			func() omultimap.OMultiMap[string, string] {
+				return omultimap.NewOMultiMapFolded[string](func(s string) string { return s })
+			},
+			func() omultimap.OMultiMap[string, int] {
+				return omultimap.NewOMultiMapFolded[int](func(s string) string { return s })
+			},
 		},
 	}
 }
@@ -55,6 +72,14 @@ func TestNewIsOMultiMapLinked(t *testing.T) {
 	}
 }
 
+func TestConformance(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			omultimaptest.RunConformance(t, impl.initializerStrInt)
+		})
+	}
+}
+
 func TestBasicOperations(t *testing.T) {
 	keys := []string{"foo", "bar", "baz"}
 	values := []string{"1", "2", "3", "4"}
@@ -167,8 +192,36 @@ func TestDeleteAt(t *testing.T) {
 			} else if err := mm.DeleteAt(delLast); err != nil {
 				t.Errorf("unexpected error: %v", err)
 			} else {
-				th.ValidateIteratorForward(t, mm.Iterator(), true, th.JsonToKV[string, string](`[["foo","3"]]`))
+				th.ValidateIterator(t, mm.Iterator(), true, th.JsonToKV[string, string](`[["foo","3"]]`))
 			}
+			// put after deleting the last key
+			mm.Put("bar", "5")
+			th.ValidateIterator(t, mm.Iterator(), true, th.JsonToKV[string, string](`[["foo","3"],["bar","5"]]`))
+			th.AssertErrNil(t, omap.CheckInvariants(mm), "unexpected broken invariant")
+		})
+	}
+}
+
+// Deleting the last entry must move the tail to the previous one, so the map can still be iterated
+// backwards and extended, by both DeleteAt and DeleteAll
+func TestDeleteTail(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			mm := impl.initializerStrStr()
+			mm.Put("a", "1")
+			mm.Put("b", "2")
+			mm.Put("c", "3")
+			it := mm.Iterator().MoveBack()
+			it.Prev()
+			th.AssertErrNil(t, mm.DeleteAt(it), "unexpected error on DeleteAt of tail")
+			th.ValidateIteratorBackward(t, mm.Iterator().MoveBack(), true, th.JsonToKV[string, string](`[["a","1"],["b","2"]]`))
+			mm.Put("d", "4")
+			th.ValidateIterator(t, mm.Iterator(), true, th.JsonToKV[string, string](`[["a","1"],["b","2"],["d","4"]]`))
+			mm.DeleteAll("d")
+			th.ValidateIteratorBackward(t, mm.Iterator().MoveBack(), true, th.JsonToKV[string, string](`[["a","1"],["b","2"]]`))
+			mm.Put("e", "5")
+			th.ValidateIterator(t, mm.Iterator(), true, th.JsonToKV[string, string](`[["a","1"],["b","2"],["e","5"]]`))
+			th.AssertErrNil(t, omap.CheckInvariants(mm), "unexpected broken invariant")
 		})
 	}
 }
@@ -316,6 +369,9 @@ func TestPutAfter(t *testing.T) {
 			}
 			th.ValidateIterator(t, mm.Iterator(), true, th.JsonToKV[string, string](`[["foo","1"],["foo","2"],["foo","3"]]`))
 			th.ValidateIterator(t, mm.GetValuesOf("foo"), true, th.JsonToKV[string, string](`[["foo","1"],["foo","2"],["foo","3"]]`))
+			if mm.Len() != 3 {
+				t.Errorf("expected len 3, found %d", mm.Len())
+			}
 			// add different keys
 			its := []omap.OMapIterator[string, string]{mm.Iterator(), mm.Iterator(), mm.Iterator(), mm.Iterator()}
 			// its[0] stays at front, nothing to do
@@ -336,6 +392,108 @@ func TestPutAfter(t *testing.T) {
 			th.ValidateIterator(t, mm.Iterator(), true, th.JsonToKV[string, string](`[["bar","bar/0"],["foo","1"],["bar","bar/1"],["foo","2"],["bar","bar/2"],["foo","3"],["bar","bar/3"]]`))
 			th.ValidateIterator(t, mm.GetValuesOf("foo"), true, th.JsonToKV[string, string](`[["foo","1"],["foo","2"],["foo","3"]]`))
 			th.ValidateIterator(t, mm.GetValuesOf("bar"), true, th.JsonToKV[string, string](`[["bar","bar/0"],["bar","bar/1"],["bar","bar/2"],["bar","bar/3"]]`))
+			th.AssertErrNil(t, omap.CheckInvariants(mm), "unexpected broken invariant")
+		})
+	}
+}
+
+// Next returning false must leave the iterator at EOF, even on an empty multimap
+func TestNextEmptyAtEOF(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			mm := impl.initializerStrStr()
+			it := mm.Iterator()
+			if it.Next() || !it.EOF() || it.IsValid() {
+				t.Errorf("expected iterator of empty multimap at EOF after Next, found EOF()=%v IsValid()=%v", it.EOF(), it.IsValid())
+			}
+			if it.Next() || !it.EOF() {
+				t.Errorf("expected iterator to stay at EOF")
+			}
+			// as at EOF, Prev moves to the last entry
+			mm.Put("foo", "1")
+			if !it.Prev() || it.Key() != "foo" || it.Value() != "1" {
+				t.Errorf("expected Prev from EOF to move to the last entry")
+			}
+		})
+	}
+}
+
+// PutAfter must count the new value in Len, for new and existing keys, at any position
+func TestPutAfterLen(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			mm := impl.initializerStrStr()
+			th.AssertErrNil(t, mm.PutAfter(mm.Iterator(), "foo", "1"), "unexpected error on PutAfter at BOF")
+			it := mm.Iterator()
+			it.Next()
+			th.AssertErrNil(t, mm.PutAfter(it, "foo", "2"), "unexpected error on PutAfter of existing key")
+			th.AssertErrNil(t, mm.PutAfter(it, "bar", "3"), "unexpected error on PutAfter of new key")
+			th.AssertErrNotNil(t, mm.PutAfter(mm.Iterator().MoveBack(), "baz", "4"), "expected PutAfter at EOF to fail")
+			itTail := mm.Iterator().MoveBack()
+			itTail.Prev()
+			th.AssertErrNil(t, mm.PutAfter(itTail, "bar", "5"), "unexpected error on PutAfter at tail")
+			if mm.Len() != 4 {
+				t.Errorf("expected len 4, found %d", mm.Len())
+			}
+			th.AssertErrNil(t, omap.CheckInvariants(mm), "unexpected broken invariant")
+			mm.DeleteAll("foo")
+			if mm.Len() != 2 {
+				t.Errorf("expected len 2 after DeleteAll, found %d", mm.Len())
+			}
+			mm.DeleteAll("bar")
+			if mm.Len() != 0 {
+				t.Errorf("expected len 0 after deleting all keys, found %d", mm.Len())
+			}
+			th.AssertErrNil(t, omap.CheckInvariants(mm), "unexpected broken invariant")
+		})
+	}
+}
+
+func TestPutAfterValuesOrder(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			mm := impl.initializerStrStr()
+			mm.Put("a", "1")
+			mm.Put("b", "2")
+			mm.Put("a", "3")
+			mm.Put("c", "4")
+			// put "a" values after entries of other keys, they are added as the last values of "a"
+			th.AssertErrIs(t, mm.PutAfter(mm.GetValuesOf("b"), "a", "x"), omap.ErrInvalidIteratorType, "expected PutAfter with iterator of GetValuesOf to fail")
+			itB := mm.Iterator()
+			itB.Next()
+			itB.Next()
+			th.AssertErrNil(t, mm.PutAfter(itB, "a", "2.5"), "unexpected error on PutAfter")
+			th.AssertErrNil(t, mm.PutAfter(mm.Iterator(), "a", "0"), "unexpected error on PutAfter")
+			itC := mm.Iterator().MoveBack()
+			itC.Prev()
+			itC.Prev()
+			th.AssertErrNil(t, mm.PutAfter(itC, "c", "3.5"), "unexpected error on PutAfter")
+			// put after an entry of the same key, added right after it among the values too
+			itA := mm.Iterator()
+			itA.Next()
+			itA.Next()
+			th.AssertErrNil(t, mm.PutAfter(itA, "a", "1.5"), "unexpected error on PutAfter")
+			th.ValidateIterator(t, mm.Iterator(), true, th.JsonToKV[string, string](`[["a","0"],["a","1"],["a","1.5"],["b","2"],["a","2.5"],["a","3"],["c","3.5"],["c","4"]]`))
+			th.ValidateIterator(t, mm.GetValuesOf("a"), true, th.JsonToKV[string, string](`[["a","1"],["a","1.5"],["a","3"],["a","2.5"],["a","0"]]`))
+			th.ValidateIterator(t, mm.GetValuesOf("c"), true, th.JsonToKV[string, string](`[["c","4"],["c","3.5"]]`))
+			th.AssertErrNil(t, omap.CheckInvariants(mm), "unexpected broken invariant")
+		})
+	}
+}
+
+func TestGetValuesOfSnapshot(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			mm := impl.initializerStrStr()
+			mm.Put("a", "1", "2", "3")
+			itValues := mm.GetValuesOf("a")
+			// delete "a"/"1", values iterator must still see the values at the time it was created
+			it := mm.Iterator()
+			it.Next()
+			th.AssertErrNil(t, mm.DeleteAt(it), "unexpected error on DeleteAt")
+			mm.Put("a", "4")
+			th.ValidateIterator(t, itValues, true, th.JsonToKV[string, string](`[["a","1"],["a","2"],["a","3"]]`))
+			th.ValidateIterator(t, mm.GetValuesOf("a"), true, th.JsonToKV[string, string](`[["a","2"],["a","3"],["a","4"]]`))
 		})
 	}
 }
@@ -0,0 +1,185 @@
+package omultimap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+// Implements an OMultiMap with string keys where lookups are done on the keys normalized by a fold
+// function (e.g. strings.ToLower for case-insensitive keys), while iteration and serialization
+// return the keys spelled as first inserted: all values of the same folded key share the spelling
+// of the first one, until all of them are deleted.
+//
+// Uses an OMultiMapLinked underneath, indexed by the folded keys, and behavior of functions and
+// time complexity are the same.
+type OMultiMapFolded[V any] struct {
+	fold func(string) string
+	omm  OMultiMap[string, foldedEntry[V]]
+}
+
+type foldedEntry[V any] struct {
+	key   string
+	value V
+}
+
+// Iterator for OMultiMapFolded, used for both Iterator and GetValuesOf.
+type OMultiMapFoldedIterator[V any] struct {
+	m  *OMultiMapFolded[V]
+	it omap.OMapIterator[string, foldedEntry[V]]
+}
+
+// Create a new OMultiMapFolded, using the given fold function to normalize the keys for lookups.
+// If fold is nil, strings.ToLower is used.
+func NewOMultiMapFolded[V any](fold func(string) string) OMultiMap[string, V] {
+	ret := &OMultiMapFolded[V]{fold: fold}
+	ret.init()
+	return ret
+}
+
+func (m *OMultiMapFolded[V]) init() {
+	if m.fold == nil {
+		m.fold = strings.ToLower
+	}
+	m.omm = NewOMultiMapLinked[string, foldedEntry[V]]()
+}
+
+// Return the spelling to be used for key: the one already in the map for the same folded key, if
+// any, or key itself otherwise.
+func (m *OMultiMapFolded[V]) spelling(folded string, key string) string {
+	if it := m.omm.GetValuesOf(folded); it.Next() {
+		return it.Value().key
+	}
+	return key
+}
+
+func (m *OMultiMapFolded[V]) Put(key string, values ...V) {
+	folded := m.fold(key)
+	key = m.spelling(folded, key)
+	entries := make([]foldedEntry[V], len(values))
+	for i, value := range values {
+		entries[i] = foldedEntry[V]{key, value}
+	}
+	m.omm.Put(folded, entries...)
+}
+
+func (m *OMultiMapFolded[V]) PutAfter(interfaceIt omap.OMapIterator[string, V], key string, value V) error {
+	if it, ok := interfaceIt.(*OMultiMapFoldedIterator[V]); !ok {
+		return fmt.Errorf("%w - expected OMultiMapFoldedIterator found %T", omap.ErrInvalidIteratorType, interfaceIt)
+	} else if it.m != m {
+		return omap.ErrInvalidIteratorMap
+	} else {
+		folded := m.fold(key)
+		return m.omm.PutAfter(it.it, folded, foldedEntry[V]{m.spelling(folded, key), value})
+	}
+}
+
+func (m *OMultiMapFolded[V]) GetValuesOf(key string) omap.OMapIterator[string, V] {
+	return &OMultiMapFoldedIterator[V]{m: m, it: m.omm.GetValuesOf(m.fold(key))}
+}
+
+func (m *OMultiMapFolded[V]) DeleteAll(key string) {
+	m.omm.DeleteAll(m.fold(key))
+}
+
+func (m *OMultiMapFolded[V]) DeleteAt(interfaceIt omap.OMapIterator[string, V]) error {
+	if it, ok := interfaceIt.(*OMultiMapFoldedIterator[V]); !ok {
+		return fmt.Errorf("%w - expected OMultiMapFoldedIterator found %T", omap.ErrInvalidIteratorType, interfaceIt)
+	} else if it.m != m {
+		return omap.ErrInvalidIteratorMap
+	} else {
+		return m.omm.DeleteAt(it.it)
+	}
+}
+
+func (m *OMultiMapFolded[V]) MustDeleteAt(interfaceIt omap.OMapIterator[string, V]) {
+	err := m.DeleteAt(interfaceIt)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (m *OMultiMapFolded[V]) Iterator() omap.OMapIterator[string, V] {
+	return &OMultiMapFoldedIterator[V]{m: m, it: m.omm.Iterator()}
+}
+
+// Implement omap.Validator interface, checking the underlying map and that each key is indexed by
+// its folded form, with all values of the same key sharing the same spelling.
+func (m *OMultiMapFolded[V]) Validate() error {
+	if err := omap.CheckInvariants(m.omm); err != nil {
+		return err
+	}
+	errs := &omap.InvariantError{Type: "omultimap.OMultiMapFolded"}
+	spelling := make(map[string]string)
+	for it := m.omm.Iterator(); it.Next(); {
+		key := it.Value().key
+		if folded := m.fold(key); folded != it.Key() {
+			errs.Addf("key %q is indexed as %q instead of %q", key, it.Key(), folded)
+		}
+		if first, ok := spelling[it.Key()]; !ok {
+			spelling[it.Key()] = key
+		} else if first != key {
+			errs.Addf("key %q is spelled as %q, but its first value has %q", it.Key(), key, first)
+		}
+	}
+	return errs.Err()
+}
+
+func (m *OMultiMapFolded[V]) Len() int {
+	return m.omm.Len()
+}
+
+// Implement fmt.Stringer
+func (m *OMultiMapFolded[V]) String() string {
+	return omap.IteratorToString[string, V]("omultimap.OMultiMapFolded", m.Iterator())
+}
+
+// Implement json.Marshaler interface.
+func (m *OMultiMapFolded[V]) MarshalJSON() ([]byte, error) {
+	return omap.MarshalJSON(m.Iterator())
+}
+
+// Implement json.Unmarshaler interface. The fold function is kept, or strings.ToLower is used for
+// a zero value OMultiMapFolded.
+func (m *OMultiMapFolded[V]) UnmarshalJSON(b []byte) error {
+	m.init()
+	return omap.UnmarshalJSON[string, V](func(key string, val V) { m.Put(key, val) }, b)
+}
+
+//// OMultiMap Iterator ////
+
+func (it *OMultiMapFoldedIterator[V]) Next() bool {
+	return it.it.Next()
+}
+
+func (it *OMultiMapFoldedIterator[V]) EOF() bool {
+	return it.it.EOF()
+}
+
+// Return the key at current record, spelled as first inserted.
+func (it *OMultiMapFoldedIterator[V]) Key() string {
+	return it.it.Value().key
+}
+
+func (it *OMultiMapFoldedIterator[V]) Value() V {
+	return it.it.Value().value
+}
+
+func (it *OMultiMapFoldedIterator[V]) IsValid() bool {
+	return it.it.IsValid()
+}
+
+func (it *OMultiMapFoldedIterator[V]) MoveFront() omap.OMapIterator[string, V] {
+	it.it.MoveFront()
+	return it
+}
+
+func (it *OMultiMapFoldedIterator[V]) MoveBack() omap.OMapIterator[string, V] {
+	it.it.MoveBack()
+	return it
+}
+
+func (it *OMultiMapFoldedIterator[V]) Prev() bool {
+	return it.it.Prev()
+}
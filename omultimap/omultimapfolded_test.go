@@ -0,0 +1,62 @@
+package omultimap_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+)
+
+func TestOMultiMapFolded(t *testing.T) {
+	m := omultimap.NewOMultiMapFolded[string](nil)
+	m.Put("Set-Cookie", "a=1")
+	m.Put("Host", "example.com")
+	m.Put("SET-COOKIE", "b=2", "c=3")
+	th.ValidateIterator(t, m.GetValuesOf("set-cookie"), true, th.JsonToKV[string, string](`[["Set-Cookie","a=1"],["Set-Cookie","b=2"],["Set-Cookie","c=3"]]`))
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, string](`[["Set-Cookie","a=1"],["Host","example.com"],["Set-Cookie","b=2"],["Set-Cookie","c=3"]]`))
+	// PutAfter and DeleteAt
+	it := m.GetValuesOf("HOST")
+	it.Next()
+	th.AssertErrIs(t, m.PutAfter(it, "x", "y"), omap.ErrInvalidIteratorType, "expected error with values iterator")
+	it = m.Iterator()
+	it.Next()
+	th.AssertErrNil(t, m.PutAfter(it, "set-cookie", "d=4"), "unexpected error on PutAfter")
+	th.AssertErrNil(t, m.DeleteAt(it), "unexpected error on DeleteAt")
+	last := m.Iterator().MoveBack()
+	last.Prev()
+	m.MustDeleteAt(last)
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, string](`[["Set-Cookie","d=4"],["Host","example.com"],["Set-Cookie","b=2"]]`))
+	// new spelling after all values are deleted
+	m.DeleteAll("SET-cookie")
+	m.Put("set-cookie", "e=5")
+	if res, _ := json.Marshal(m); string(res) != `{"Host":"example.com","set-cookie":"e=5"}` {
+		t.Errorf("unexpected JSON: %s", res)
+	}
+	if m.Len() != 2 {
+		t.Errorf("expected len 2, found %d", m.Len())
+	}
+	if s := m.(*omultimap.OMultiMapFolded[string]).String(); s != "omultimap.OMultiMapFolded[Host:example.com set-cookie:e=5]" {
+		t.Errorf("unexpected String(): %s", s)
+	}
+	// iterator navigation
+	it = m.Iterator().MoveBack()
+	if !it.Prev() || it.Key() != "set-cookie" || it.EOF() {
+		t.Error("expected Prev to be at set-cookie")
+	}
+	if it.MoveFront(); !it.Next() || it.Key() != "Host" || !it.IsValid() {
+		t.Error("expected Next to be at Host")
+	}
+}
+
+func TestOMultiMapFoldedJSON(t *testing.T) {
+	var m omultimap.OMultiMapFolded[int]
+	th.AssertErrNil(t, json.Unmarshal([]byte(`{"A":1,"b":2,"a":3}`), &m), "unexpected error on Unmarshal")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, int](`[["A",1],["b",2],["A",3]]`))
+	other := omultimap.NewOMultiMapFolded[int](nil)
+	th.AssertErrIs(t, m.PutAfter(omultimap.New[string, int]().Iterator(), "x", 1), omap.ErrInvalidIteratorType, "expected error with invalid iterator")
+	th.AssertErrIs(t, m.PutAfter(other.Iterator(), "x", 1), omap.ErrInvalidIteratorMap, "expected error with iterator of another map")
+	th.AssertErrIs(t, m.DeleteAt(omultimap.New[string, int]().Iterator()), omap.ErrInvalidIteratorType, "expected error with invalid iterator")
+	th.AssertErrIs(t, m.DeleteAt(other.Iterator()), omap.ErrInvalidIteratorMap, "expected error with iterator of another map")
+}
@@ -1,9 +1,11 @@
 package omultimap
 
 import (
+	"encoding/xml"
 	"errors"
 	"fmt"
 
+	"github.com/matheusoliveira/go-ordered-map/internal/listcheck"
 	"github.com/matheusoliveira/go-ordered-map/omap"
 )
 
@@ -50,6 +52,9 @@ func NewOMultiMapLinked[K comparable, V any]() OMultiMap[K, V] {
 
 func (m *OMultiMapLinked[K, V]) init() {
 	m.m = make(map[K][]*mapEntry[K, V])
+	m.head = nil
+	m.tail = nil
+	m.length = 0
 }
 
 // Add a given key/value to the map.
@@ -107,7 +112,7 @@ func (m *OMultiMapLinked[K, V]) deleteEntryInList(entry *mapEntry[K, V]) {
 		m.head = entry.next
 	}
 	if m.tail == entry {
-		m.tail = entry.next
+		m.tail = entry.prev
 	}
 	if entry.prev != nil {
 		entry.prev.next = entry.next
@@ -165,7 +170,7 @@ func (m *OMultiMapLinked[K, V]) getIteratorEntry(interfaceIt omap.OMapIterator[K
 }
 
 // Delete the value currently pointed by the iterator, returning a non-nil error if failed.
-// Complexity: O(1).
+// Complexity: O(k), k being the number of values of the key.
 func (m *OMultiMapLinked[K, V]) DeleteAt(interfaceIt omap.OMapIterator[K, V]) error {
 	it, elems, pos, err := m.getIteratorEntry(interfaceIt)
 	if err != nil {
@@ -174,7 +179,10 @@ func (m *OMultiMapLinked[K, V]) DeleteAt(interfaceIt omap.OMapIterator[K, V]) er
 	if len(elems) == 1 {
 		delete(m.m, it.Key())
 	} else {
-		m.m[it.Key()] = append(elems[0:pos], elems[pos+1:]...)
+		// copy instead of shifting in place, as iterators of GetValuesOf share the slice
+		tmp := make([]*mapEntry[K, V], 0, len(elems)-1)
+		tmp = append(tmp, elems[0:pos]...)
+		m.m[it.Key()] = append(tmp, elems[pos+1:]...)
 	}
 	m.deleteEntryInList(it.cursor)
 	m.length--
@@ -182,6 +190,8 @@ func (m *OMultiMapLinked[K, V]) DeleteAt(interfaceIt omap.OMapIterator[K, V]) er
 }
 
 // Add a given key/value to the map, after the entry pointed by iterator.
+// The value is added right after the one pointed by iterator among the values of the key (as
+// returned by GetValuesOf) if both have the same key, otherwise it is added as their last value.
 // Complexity: O(1).
 func (m *OMultiMapLinked[K, V]) PutAfter(interfaceIt omap.OMapIterator[K, V], key K, value V) error {
 	it, elems, pos, err := m.getIteratorEntry(interfaceIt)
@@ -216,6 +226,7 @@ func (m *OMultiMapLinked[K, V]) PutAfter(interfaceIt omap.OMapIterator[K, V], ke
 	} else {
 		m.m[key] = []*mapEntry[K, V]{entry}
 	}
+	m.length++
 	// update map head and tail
 	if m.head == nil {
 		m.head = entry
@@ -249,6 +260,46 @@ func (m *OMultiMapLinked[K, V]) Len() int {
 	return m.length
 }
 
+// Implement omap.Validator interface, checking that the linked list is consistent in both
+// directions, that length agrees with it and with the lookup map, and that the values of each key
+// in the lookup map are the entries of the list with that key, each one exactly once.
+// Complexity: O(n).
+func (m *OMultiMapLinked[K, V]) Validate() error {
+	errs := &omap.InvariantError{Type: "omultimap.OMultiMapLinked"}
+	count := 0
+	// values are not necessarily in the list order (see PutAfter), so just track the entries
+	values := make(map[*mapEntry[K, V]]bool, m.length)
+	for key, elems := range m.m {
+		if len(elems) == 0 {
+			errs.Addf("lookup map has no values for key %v", key)
+		}
+		for i, e := range elems {
+			if e == nil {
+				errs.Addf("lookup map has a nil entry at %d for key %v", i, key)
+			} else if e.key != key {
+				errs.Addf("lookup map has key %v pointing to entry of key %v", key, e.key)
+			} else if values[e] {
+				errs.Addf("lookup map has a repeated entry at %d for key %v", i, key)
+			} else {
+				values[e] = true
+			}
+		}
+		count += len(elems)
+	}
+	if count != m.length {
+		errs.Addf("length is %d, but lookup map has %d entries", m.length, count)
+	}
+	listcheck.Validate(errs.Addf, m.head, m.tail, m.length,
+		func(e *mapEntry[K, V]) *mapEntry[K, V] { return e.next },
+		func(e *mapEntry[K, V]) *mapEntry[K, V] { return e.prev },
+		func(i int, e *mapEntry[K, V]) {
+			if !values[e] {
+				errs.Addf("entry %d of key %v is not in the lookup map", i, e.key)
+			}
+		})
+	return errs.Err()
+}
+
 // Implement fmt.Stringer
 func (m *OMultiMapLinked[K, V]) String() string {
 	return omap.IteratorToString[K, V]("omultimap.OMultiMapLinked", m.Iterator())
@@ -266,10 +317,47 @@ func (m *OMultiMapLinked[K, V]) UnmarshalJSON(b []byte) error {
 	return omap.UnmarshalJSON[K, V](func(key K, val V) { m.Put(key, val) }, b)
 }
 
+// Implement encoding.BinaryMarshaler interface, see omap.MarshalBinary. Keys and values are
+// encoded with omap.BuiltinCodec.
+func (m OMultiMapLinked[K, V]) MarshalBinary() ([]byte, error) {
+	return omap.MarshalBinary(m.Iterator(), omap.BuiltinCodec{})
+}
+
+// Implement encoding.BinaryUnmarshaler interface, see omap.UnmarshalBinary.
+func (m *OMultiMapLinked[K, V]) UnmarshalBinary(b []byte) error {
+	m.init()
+	return omap.UnmarshalBinary[K, V](func(key K, val V) { m.Put(key, val) }, b, omap.BuiltinCodec{})
+}
+
+// Implement gob.GobEncoder interface, using same format as MarshalBinary.
+func (m OMultiMapLinked[K, V]) GobEncode() ([]byte, error) {
+	return m.MarshalBinary()
+}
+
+// Implement gob.GobDecoder interface, using same format as UnmarshalBinary.
+func (m *OMultiMapLinked[K, V]) GobDecode(b []byte) error {
+	return m.UnmarshalBinary(b)
+}
+
+// Implement xml.Marshaler interface, each key/value is encoded as a child element, in order.
+// See omap.MarshalXML, and XMLMap to use other XMLOptions.
+func (m OMultiMapLinked[K, V]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return omap.MarshalXML(e, start, m.Iterator(), omap.XMLOptions{})
+}
+
+// Implement xml.Unmarshaler interface, repeated elements are kept as multiple values of the same
+// key, in the same sequence as found. See omap.UnmarshalXML.
+func (m *OMultiMapLinked[K, V]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	m.init()
+	return omap.UnmarshalXML[K, V](func(key K, val V) { m.Put(key, val) }, d, start, omap.XMLOptions{})
+}
+
 //// OMultiMap Iterator ////
 
 func (it *OMultiMapLinkedIterator[K, V]) Next() bool {
 	if it.cursor == nil {
+		// empty map or already at EOF
+		it.bof = false
 		return false
 	}
 	if !it.bof {
@@ -79,11 +79,22 @@ func (m *OMultiMapSync[K, V]) MustDeleteAt(interfaceIt omap.OMapIterator[K, V])
 }
 
 func (m *OMultiMapSync[K, V]) Iterator() omap.OMapIterator[K, V] {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	it := m.omm.Iterator()
 	return &OMultiMapSyncIterator[K, V]{it: it, m: m}
 }
 
+// Implement omap.Validator interface, checking the underlying map while holding the read lock.
+func (m *OMultiMapSync[K, V]) Validate() error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return omap.CheckInvariants(m.omm)
+}
+
 func (m *OMultiMapSync[K, V]) Len() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	return m.omm.Len()
 }
 
@@ -119,18 +130,26 @@ func (it *OMultiMapSyncIterator[K, V]) Next() bool {
 }
 
 func (it *OMultiMapSyncIterator[K, V]) EOF() bool {
+	it.m.lock.RLock()
+	defer it.m.lock.RUnlock()
 	return it.it.EOF()
 }
 
 func (it *OMultiMapSyncIterator[K, V]) Key() K {
+	it.m.lock.RLock()
+	defer it.m.lock.RUnlock()
 	return it.it.Key()
 }
 
 func (it *OMultiMapSyncIterator[K, V]) Value() V {
+	it.m.lock.RLock()
+	defer it.m.lock.RUnlock()
 	return it.it.Value()
 }
 
 func (it *OMultiMapSyncIterator[K, V]) IsValid() bool {
+	it.m.lock.RLock()
+	defer it.m.lock.RUnlock()
 	return it.it.IsValid()
 }
 
@@ -0,0 +1,315 @@
+// omultimaptest package provides a conformance test suite for omultimap.OMultiMap
+// implementations, the equivalent of omaptest for multimaps:
+//
+//	func TestConformance(t *testing.T) {
+//		omultimaptest.RunConformance(t, func() omultimap.OMultiMap[string, int] { return mypkg.NewMultiMap[string, int]() })
+//	}
+package omultimaptest
+
+import (
+	"encoding/json"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+)
+
+// Run the conformance tests, each one as a subtest of t, using factory to create a new empty
+// multimap for each test. The tests cover Put/PutAfter/GetValuesOf/DeleteAll/DeleteAt/Len,
+// iterator semantics at BOF and EOF, the errors returned by PutAfter and DeleteAt and JSON
+// round-trip (skipped if the multimap does not implement json.Marshaler and json.Unmarshaler).
+// At the end of each test, every multimap created is checked with omap.CheckInvariants.
+func RunConformance(t *testing.T, factory func() omultimap.OMultiMap[string, int]) {
+	t.Helper()
+	for _, test := range []struct {
+		name string
+		fct  func(t *testing.T, factory func() omultimap.OMultiMap[string, int])
+	}{
+		{"Put", testPut},
+		{"Iterator", testIterator},
+		{"EmptyIterator", testEmptyIterator},
+		{"DeleteAll", testDeleteAll},
+		{"DeleteAt", testDeleteAt},
+		{"DeleteAtErrors", testDeleteAtErrors},
+		{"PutAfter", testPutAfter},
+		{"PutAfterErrors", testPutAfterErrors},
+		{"JSON", testJSON},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var created []omultimap.OMultiMap[string, int]
+			test.fct(t, func() omultimap.OMultiMap[string, int] {
+				m := factory()
+				created = append(created, m)
+				return m
+			})
+			for _, m := range created {
+				if err := omap.CheckInvariants(m); err != nil {
+					t.Errorf("%T: %v", m, err)
+				}
+			}
+		})
+	}
+}
+
+func validateLen(t *testing.T, mm omultimap.OMultiMap[string, int], expected int) {
+	t.Helper()
+	if mm.Len() != expected {
+		t.Errorf("expected %T.Len() of %d, found %d", mm, expected, mm.Len())
+	}
+}
+
+func testPut(t *testing.T, factory func() omultimap.OMultiMap[string, int]) {
+	mm := factory()
+	validateLen(t, mm, 0)
+	// no-op
+	mm.Put("x")
+	validateLen(t, mm, 0)
+	mm.Put("x", 1, 2, 3)
+	mm.Put("y", 4, 5, 6)
+	mm.Put("x", 7)
+	mm.Put("x", 7)
+	validateLen(t, mm, 8)
+	th.ValidateIterator(t, mm.Iterator(), true, th.JsonToKV[string, int](`[["x",1],["x",2],["x",3],["y",4],["y",5],["y",6],["x",7],["x",7]]`))
+	th.ValidateIterator(t, mm.GetValuesOf("x"), true, th.JsonToKV[string, int](`[["x",1],["x",2],["x",3],["x",7],["x",7]]`))
+	th.ValidateIterator(t, mm.GetValuesOf("y"), true, th.JsonToKV[string, int](`[["y",4],["y",5],["y",6]]`))
+	th.ValidateIterator(t, mm.GetValuesOf("what"), true, th.JsonToKV[string, int](`[]`))
+}
+
+func testIterator(t *testing.T, factory func() omultimap.OMultiMap[string, int]) {
+	mm := factory()
+	mm.Put("a", 1)
+	mm.Put("b", 2)
+	mm.Put("a", 3)
+	expected := th.JsonToKV[string, int](`[["a",1],["b",2],["a",3]]`)
+	expectedA := th.JsonToKV[string, int](`[["a",1],["a",3]]`)
+	for _, tc := range []struct {
+		name     string
+		it       omap.OMapIterator[string, int]
+		expected []th.KeyValue[string, int]
+	}{
+		{"Iterator", mm.Iterator(), expected},
+		{"GetValuesOf", mm.GetValuesOf("a"), expectedA},
+	} {
+		it := tc.it
+		// BOF
+		if it.IsValid() {
+			t.Errorf("%s: expected new iterator to not be valid (BOF)", tc.name)
+		}
+		if it.EOF() {
+			t.Errorf("%s: expected new iterator to not be at EOF", tc.name)
+		}
+		th.ValidateIteratorForward(t, it, true, tc.expected)
+		// EOF, moving backwards
+		last := tc.expected[len(tc.expected)-1]
+		if !it.Prev() || it.Key() != last.Key || it.Value() != last.Value {
+			t.Errorf("%s: expected Prev at EOF to move to the last entry", tc.name)
+		}
+		th.ValidateIteratorBackward(t, it.MoveBack(), true, tc.expected)
+		if it.Prev() {
+			t.Errorf("%s: expected Prev at BOF to return false", tc.name)
+		}
+		// can move forward again
+		th.ValidateIterator(t, it.MoveFront(), true, tc.expected)
+		if it.MoveBack(); !it.EOF() || it.IsValid() {
+			t.Errorf("%s: expected iterator to be at EOF after MoveBack", tc.name)
+		}
+	}
+}
+
+func testEmptyIterator(t *testing.T, factory func() omultimap.OMultiMap[string, int]) {
+	mm := factory()
+	for name, it := range map[string]omap.OMapIterator[string, int]{
+		"Iterator":    mm.Iterator(),
+		"GetValuesOf": mm.GetValuesOf("a"),
+	} {
+		if it.Next() {
+			t.Errorf("%s: expected Next on empty multimap to return false", name)
+		}
+		if !it.EOF() || it.IsValid() {
+			t.Errorf("%s: expected iterator to be at EOF after Next on empty multimap", name)
+		}
+		it.MoveBack()
+		if it.Prev() {
+			t.Errorf("%s: expected Prev on empty multimap to return false", name)
+		}
+		th.ValidateIterator(t, it.MoveFront(), true, th.JsonToKV[string, int](`[]`))
+	}
+}
+
+func testDeleteAll(t *testing.T, factory func() omultimap.OMultiMap[string, int]) {
+	mm := factory()
+	mm.Put("a", 1, 2)
+	mm.Put("b", 3)
+	mm.Put("a", 4)
+	mm.Put("c", 5)
+	// no-op
+	mm.DeleteAll("what")
+	validateLen(t, mm, 5)
+	mm.DeleteAll("a")
+	validateLen(t, mm, 2)
+	th.ValidateIterator(t, mm.Iterator(), true, th.JsonToKV[string, int](`[["b",3],["c",5]]`))
+	th.ValidateIterator(t, mm.GetValuesOf("a"), true, th.JsonToKV[string, int](`[]`))
+	// deleted key is added back at the end
+	mm.Put("a", 6)
+	th.ValidateIterator(t, mm.Iterator(), true, th.JsonToKV[string, int](`[["b",3],["c",5],["a",6]]`))
+	mm.DeleteAll("c")
+	mm.DeleteAll("b")
+	mm.DeleteAll("a")
+	validateLen(t, mm, 0)
+	th.ValidateIterator(t, mm.Iterator(), true, th.JsonToKV[string, int](`[]`))
+}
+
+func testDeleteAt(t *testing.T, factory func() omultimap.OMultiMap[string, int]) {
+	mm := factory()
+	mm.Put("foo", 1, 2, 3)
+	mm.Put("bar", 4)
+	mm.Put("foo", 5)
+	delIt := mm.Iterator()
+	delIt.Next()
+	delIt.Next()
+	th.AssertErrNil(t, mm.DeleteAt(delIt), "unexpected error on DeleteAt in the middle")
+	// still points to deleted key/val, and can continue iterating
+	if delIt.Key() != "foo" || delIt.Value() != 2 {
+		t.Errorf("expected key/val = \"foo\"/2, found %q/%d", delIt.Key(), delIt.Value())
+	}
+	th.ValidateIteratorForward(t, delIt, true, th.JsonToKV[string, int](`[["foo",3],["bar",4],["foo",5]]`))
+	th.ValidateIterator(t, mm.GetValuesOf("foo"), true, th.JsonToKV[string, int](`[["foo",1],["foo",3],["foo",5]]`))
+	validateLen(t, mm, 4)
+	// delete head
+	delFirst := mm.Iterator()
+	delFirst.Next()
+	th.AssertErrNil(t, mm.DeleteAt(delFirst), "unexpected error on DeleteAt at head")
+	th.ValidateIterator(t, mm.Iterator(), true, th.JsonToKV[string, int](`[["foo",3],["bar",4],["foo",5]]`))
+	// delete tail
+	delLast := mm.Iterator().MoveBack()
+	delLast.Prev()
+	th.AssertErrNil(t, mm.DeleteAt(delLast), "unexpected error on DeleteAt at tail")
+	th.ValidateIterator(t, mm.Iterator(), true, th.JsonToKV[string, int](`[["foo",3],["bar",4]]`))
+	// delete the only value of a key
+	delBar := mm.Iterator().MoveBack()
+	delBar.Prev()
+	th.AssertErrNil(t, mm.DeleteAt(delBar), "unexpected error on DeleteAt of the only value of a key")
+	th.ValidateIterator(t, mm.GetValuesOf("bar"), true, th.JsonToKV[string, int](`[]`))
+	// put after deleting the last key
+	mm.Put("bar", 6)
+	th.ValidateIterator(t, mm.Iterator(), true, th.JsonToKV[string, int](`[["foo",3],["bar",6]]`))
+	validateLen(t, mm, 2)
+}
+
+func testDeleteAtErrors(t *testing.T, factory func() omultimap.OMultiMap[string, int]) {
+	var invalidIt omap.OMapIterator[string, int]
+	mm := factory()
+	mm.Put("foo", 1)
+	th.AssertErrIs(t, mm.DeleteAt(invalidIt), omap.ErrInvalidIteratorType, "expected DeleteAt with invalid iterator to fail with ErrInvalidIteratorType")
+	other := factory()
+	other.Put("foo", 1)
+	otherIt := other.Iterator()
+	otherIt.Next()
+	th.AssertErrIs(t, mm.DeleteAt(otherIt), omap.ErrInvalidIteratorMap, "expected DeleteAt with iterator of a different multimap to fail with ErrInvalidIteratorMap")
+	th.AssertErrIs(t, mm.DeleteAt(mm.Iterator()), omap.ErrInvalidIteratorPos, "expected DeleteAt with iterator at BOF to fail with ErrInvalidIteratorPos")
+	th.AssertErrIs(t, mm.DeleteAt(mm.Iterator().MoveBack()), omap.ErrInvalidIteratorPos, "expected DeleteAt with iterator at EOF to fail with ErrInvalidIteratorPos")
+	// delete twice
+	mm.Put("foo", 2)
+	it := mm.Iterator()
+	it.Next()
+	th.AssertErrNil(t, mm.DeleteAt(it), "unexpected error on first DeleteAt")
+	th.AssertErrIs(t, mm.DeleteAt(it), omap.ErrInvalidIteratorKey, "expected second DeleteAt at same entry to fail with ErrInvalidIteratorKey")
+	// delete after DeleteAll
+	it = mm.Iterator()
+	it.Next()
+	mm.DeleteAll("foo")
+	th.AssertErrIs(t, mm.DeleteAt(it), omap.ErrInvalidIteratorKey, "expected DeleteAt after DeleteAll to fail with ErrInvalidIteratorKey")
+	validateLen(t, mm, 0)
+	// MustDeleteAt panics on error
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected MustDeleteAt to panic")
+			}
+		}()
+		mm.MustDeleteAt(mm.Iterator())
+	}()
+}
+
+func testPutAfter(t *testing.T, factory func() omultimap.OMultiMap[string, int]) {
+	mm := factory()
+	// add at BOF of empty multimap
+	th.AssertErrNil(t, mm.PutAfter(mm.Iterator(), "foo", 1), "unexpected error on PutAfter at BOF of empty multimap")
+	// add at end
+	itEnd := mm.Iterator().MoveBack()
+	itEnd.Prev()
+	th.AssertErrNil(t, mm.PutAfter(itEnd, "foo", 3), "unexpected error on PutAfter at last entry")
+	// add in the middle, same key
+	itMiddle := mm.Iterator()
+	itMiddle.Next()
+	th.AssertErrNil(t, mm.PutAfter(itMiddle, "foo", 2), "unexpected error on PutAfter in the middle")
+	th.ValidateIterator(t, mm.Iterator(), true, th.JsonToKV[string, int](`[["foo",1],["foo",2],["foo",3]]`))
+	th.ValidateIterator(t, mm.GetValuesOf("foo"), true, th.JsonToKV[string, int](`[["foo",1],["foo",2],["foo",3]]`))
+	// add different keys, at BOF and after each foo
+	its := []omap.OMapIterator[string, int]{mm.Iterator(), mm.Iterator(), mm.Iterator(), mm.Iterator()}
+	for i, it := range its {
+		for j := 0; j < i; j++ {
+			it.Next()
+		}
+	}
+	for i, it := range its {
+		th.AssertErrNil(t, mm.PutAfter(it, "bar", 10+i), "unexpected error on PutAfter of different key")
+	}
+	th.ValidateIterator(t, mm.Iterator(), true, th.JsonToKV[string, int](`[["bar",10],["foo",1],["bar",11],["foo",2],["bar",12],["foo",3],["bar",13]]`))
+	th.ValidateIterator(t, mm.GetValuesOf("foo"), true, th.JsonToKV[string, int](`[["foo",1],["foo",2],["foo",3]]`))
+	th.ValidateIterator(t, mm.GetValuesOf("bar"), true, th.JsonToKV[string, int](`[["bar",10],["bar",11],["bar",12],["bar",13]]`))
+	validateLen(t, mm, 7)
+	// put after putting at BOF appends at the end
+	mm.Put("baz", 20)
+	th.ValidateIteratorBackward(t, mm.Iterator().MoveBack(), true, th.JsonToKV[string, int](`[["bar",10],["foo",1],["bar",11],["foo",2],["bar",12],["foo",3],["bar",13],["baz",20]]`))
+}
+
+func testPutAfterErrors(t *testing.T, factory func() omultimap.OMultiMap[string, int]) {
+	var invalidIt omap.OMapIterator[string, int]
+	mm := factory()
+	th.AssertErrIs(t, mm.PutAfter(invalidIt, "x", 0), omap.ErrInvalidIteratorType, "expected PutAfter with invalid iterator to fail with ErrInvalidIteratorType")
+	th.AssertErrIs(t, mm.PutAfter(factory().Iterator(), "x", 0), omap.ErrInvalidIteratorMap, "expected PutAfter with iterator of a different multimap to fail with ErrInvalidIteratorMap")
+	th.AssertErrIs(t, mm.PutAfter(mm.Iterator().MoveBack(), "x", 0), omap.ErrInvalidIteratorPos, "expected PutAfter with iterator at EOF to fail with ErrInvalidIteratorPos")
+	mm.Put("x", 0)
+	deletedRefIt := mm.Iterator()
+	deletedRefIt.Next()
+	mm.DeleteAll("x")
+	th.AssertErrIs(t, mm.PutAfter(deletedRefIt, "y", 0), omap.ErrInvalidIteratorKey, "expected PutAfter with iterator at deleted entry to fail with ErrInvalidIteratorKey")
+	validateLen(t, mm, 0)
+}
+
+func testJSON(t *testing.T, factory func() omultimap.OMultiMap[string, int]) {
+	mm := factory()
+	if _, ok := mm.(json.Marshaler); !ok {
+		t.Skipf("%T does not implement json.Marshaler", mm)
+	}
+	if _, ok := mm.(json.Unmarshaler); !ok {
+		t.Skipf("%T does not implement json.Unmarshaler", mm)
+	}
+	mm.Put("foo", 1)
+	mm.Put("bar", 2)
+	mm.Put("foo", 3)
+	const expected = `{"foo":1,"bar":2,"foo":3}`
+	if data, err := json.Marshal(mm); err != nil {
+		t.Errorf("json.Marshal failed with error: %v", err)
+	} else if string(data) != expected {
+		t.Errorf("expected %s, found %s", expected, data)
+	}
+	mm2 := factory()
+	th.AssertErrNil(t, json.Unmarshal([]byte(expected), mm2), "json.Unmarshal failed")
+	th.ValidateIterator(t, mm2.Iterator(), true, th.JsonToKV[string, int](`[["foo",1],["bar",2],["foo",3]]`))
+	th.ValidateIterator(t, mm2.GetValuesOf("foo"), true, th.JsonToKV[string, int](`[["foo",1],["foo",3]]`))
+	if data, err := json.Marshal(mm2); err != nil {
+		t.Errorf("json.Marshal failed with error: %v", err)
+	} else if string(data) != expected {
+		t.Errorf("expected %s, found %s", expected, data)
+	}
+	if data, err := json.Marshal(factory()); err != nil {
+		t.Errorf("json.Marshal failed with error: %v", err)
+	} else if string(data) != `{}` {
+		t.Errorf("expected {}, found %s", data)
+	}
+	th.AssertErrNotNil(t, json.Unmarshal([]byte(`{"ok": "123"}`), factory()), "expected an error on json.Unmarshal with invalid value")
+}
@@ -0,0 +1,12 @@
+package omultimaptest_test
+
+import (
+	"testing"
+
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+	"github.com/matheusoliveira/go-ordered-map/omultimap/omultimaptest"
+)
+
+func TestRunConformance(t *testing.T) {
+	omultimaptest.RunConformance(t, func() omultimap.OMultiMap[string, int] { return omultimap.New[string, int]() })
+}
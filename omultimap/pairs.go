@@ -0,0 +1,40 @@
+package omultimap
+
+import (
+	"bytes"
+
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+// JSONPairs wraps an OMultiMap so it is marshaled to/from JSON as an array of pairs, see
+// omap.MarshalJSONPairs. Different from the JSON object produced by OMultiMapLinked.MarshalJSON,
+// this format keeps duplicated keys in a way most JSON tools can handle, and supports any key type.
+type JSONPairs[K comparable, V any] struct {
+	OMultiMap[K, V]
+	// Format used when marshaling, unmarshal accepts any format.
+	Format omap.PairsFormat
+}
+
+// Implement json.Marshaler interface.
+func (p JSONPairs[K, V]) MarshalJSON() ([]byte, error) {
+	if p.OMultiMap == nil {
+		return []byte("null"), nil
+	}
+	return omap.MarshalJSONPairs(p.Iterator(), p.Format)
+}
+
+// Implement json.Unmarshaler interface. If OMultiMap is nil, a new multimap is created using New,
+// otherwise the given multimap is emptied and reused (so one can choose the implementation).
+func (p *JSONPairs[K, V]) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		return nil
+	}
+	if p.OMultiMap == nil {
+		p.OMultiMap = New[K, V]()
+	} else {
+		for _, key := range omap.IteratorKeysToSlice(p.Iterator()) {
+			p.DeleteAll(key)
+		}
+	}
+	return omap.UnmarshalJSONPairs(func(key K, value V) { p.Put(key, value) }, b)
+}
@@ -0,0 +1,51 @@
+package omultimap_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+)
+
+func TestJSONPairs(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			mm := impl.initializerStrStr()
+			mm.Put("foo", "1")
+			mm.Put("bar", "2")
+			mm.Put("foo", "3")
+			js, err := json.Marshal(omultimap.JSONPairs[string, string]{OMultiMap: mm})
+			th.AssertErrNil(t, err, "unexpected error on json.Marshal")
+			exp := `[["foo","1"],["bar","2"],["foo","3"]]`
+			if string(js) != exp {
+				t.Errorf("expected %s, found %s", exp, string(js))
+			}
+			// unmarshal into an existing multimap, resetting it
+			res := omultimap.JSONPairs[string, string]{OMultiMap: impl.initializerStrStr(), Format: omap.PairsAsObjects}
+			res.Put("old", "value")
+			th.AssertErrNil(t, json.Unmarshal(js, &res), "unexpected error on json.Unmarshal")
+			th.ValidateIterator(t, res.Iterator(), true, th.JsonToKV[string, string](exp))
+			js, err = json.Marshal(res)
+			th.AssertErrNil(t, err, "unexpected error on json.Marshal")
+			if exp := `[{"key":"foo","value":"1"},{"key":"bar","value":"2"},{"key":"foo","value":"3"}]`; string(js) != exp {
+				t.Errorf("expected %s, found %s", exp, string(js))
+			}
+		})
+	}
+}
+
+func TestJSONPairsNilAndErrors(t *testing.T) {
+	var p omultimap.JSONPairs[int, int]
+	th.AssertErrNil(t, json.Unmarshal([]byte(`null`), &p), "unexpected error on null")
+	if p.OMultiMap != nil {
+		t.Error("expected nil multimap on null input")
+	}
+	if js, _ := json.Marshal(p); string(js) != "null" {
+		t.Errorf("expected null, found %s", string(js))
+	}
+	th.AssertErrNil(t, json.Unmarshal([]byte(`[[1,10],[1,11],[2,20]]`), &p), "unexpected error on json.Unmarshal")
+	th.ValidateIterator(t, p.GetValuesOf(1), true, th.JsonToKV[int, int](`[[1,10],[1,11]]`))
+	th.AssertErrNotNil(t, json.Unmarshal([]byte(`[["1",10]]`), &p), "expected error with invalid key")
+}
@@ -0,0 +1,32 @@
+//go:build go1.21
+
+package omultimap
+
+import (
+	"log/slog"
+
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+// Return the key/values of the multimap m as slog attributes, in insertion order (repeated keys
+// included), see omap.IteratorAttrs.
+func AttrsOf[K comparable, V any](m OMultiMap[K, V]) []slog.Attr {
+	return omap.IteratorAttrs(m.Iterator())
+}
+
+// Implement slog.LogValuer interface, as a group with the key/values in insertion order.
+func (m *OMultiMapLinked[K, V]) LogValue() slog.Value {
+	return slog.GroupValue(AttrsOf[K, V](m)...)
+}
+
+// Implement slog.LogValuer interface, as a group with the key/values in insertion order.
+func (m *OMultiMapSync[K, V]) LogValue() slog.Value {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return slog.GroupValue(AttrsOf(m.omm)...)
+}
+
+// Implement slog.LogValuer interface, as a group with the key/values in insertion order.
+func (m *OMultiMapFolded[V]) LogValue() slog.Value {
+	return slog.GroupValue(AttrsOf[string, V](m)...)
+}
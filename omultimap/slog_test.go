@@ -0,0 +1,30 @@
+//go:build go1.21
+
+package omultimap_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+)
+
+func TestLogValue(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			m := impl.initializerStrStr()
+			m.Put("z", "1", "2")
+			m.Put("a", "3")
+			var buf bytes.Buffer
+			slog.New(slog.NewTextHandler(&buf, nil)).Info("msg", "map", m)
+			if !strings.HasSuffix(buf.String(), " map.z=1 map.z=2 map.a=3\n") {
+				t.Errorf("unexpected log output: %s", buf.String())
+			}
+			if attrs := omultimap.AttrsOf(m); len(attrs) != 3 || attrs[1].Key != "z" {
+				t.Errorf("unexpected attributes %v", attrs)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package omultimap
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+// JSONColumn wraps an OMultiMap so it can be stored into and loaded from a database column through
+// database/sql, encoded as a JSON object with the keys in order, duplicated keys included (see
+// omap.MarshalJSON). The value is sent as text, so it works with JSON/JSONB and text columns.
+//
+// SQL NULL is mapped to a nil OMultiMap, in both directions.
+type JSONColumn[K comparable, V any] struct {
+	OMultiMap[K, V]
+}
+
+var (
+	_ driver.Valuer = JSONColumn[string, any]{}
+	_ sql.Scanner   = (*JSONColumn[string, any])(nil)
+)
+
+// Implement driver.Valuer interface.
+func (c JSONColumn[K, V]) Value() (driver.Value, error) {
+	if c.OMultiMap == nil {
+		return nil, nil
+	}
+	b, err := omap.MarshalJSON(c.Iterator())
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Implement sql.Scanner interface. If OMultiMap is nil, a new multimap is created using New,
+// otherwise the given multimap is emptied and reused. A NULL value sets OMultiMap to nil.
+func (c *JSONColumn[K, V]) Scan(src any) error {
+	var b []byte
+	switch s := src.(type) {
+	case nil:
+		c.OMultiMap = nil
+		return nil
+	case []byte:
+		b = s
+	case string:
+		b = []byte(s)
+	default:
+		return fmt.Errorf("%w: cannot scan value of type %T into a JSONColumn", omap.ErrOMap, src)
+	}
+	if c.OMultiMap == nil {
+		c.OMultiMap = New[K, V]()
+	} else {
+		for _, key := range omap.IteratorKeysToSlice(c.Iterator()) {
+			c.DeleteAll(key)
+		}
+	}
+	return omap.UnmarshalJSON(func(key K, value V) { c.Put(key, value) }, b)
+}
+
+// Scan the current row of rows (rows.Next must have been called) into a new OMultiMap, with keys
+// in the order of the columns of the SELECT. Different from omap.ScanRow, repeated column names
+// (e.g. from a JOIN) are all kept. See omap.ScanRowFunc for the usage of opts.
+func ScanRow(rows *sql.Rows, opts ...omap.ScanOptions) (OMultiMap[string, any], error) {
+	m := New[string, any]()
+	if err := omap.ScanRowFunc(func(key string, value any) { m.Put(key, value) }, rows, opts...); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Scan all remaining rows into a slice of OMultiMap, one per row, as ScanRow does. As it iterates
+// until rows.Next returns false, rows is closed when this function returns with success.
+func ScanAll(rows *sql.Rows, opts ...omap.ScanOptions) ([]OMultiMap[string, any], error) {
+	ret := make([]OMultiMap[string, any], 0)
+	for rows.Next() {
+		m, err := ScanRow(rows, opts...)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,80 @@
+package omultimap_test
+
+import (
+	"reflect"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+)
+
+func TestJSONColumn(t *testing.T) {
+	db, table := th.NewFakeDB("data")
+	defer db.Close()
+	mm := omultimap.New[string, int]()
+	mm.Put("x", 1)
+	mm.Put("y", 2)
+	mm.Put("x", 3)
+	_, err := db.Exec("INSERT INTO t VALUES (?)", omultimap.JSONColumn[string, int]{mm})
+	th.AssertErrNil(t, err, "unexpected error on insert")
+	_, err = db.Exec("INSERT INTO t VALUES (?)", omultimap.JSONColumn[string, int]{})
+	th.AssertErrNil(t, err, "unexpected error on insert of NULL")
+	if rows := table.Rows(); rows[0][0] != `{"x":1,"y":2,"x":3}` || rows[1][0] != nil {
+		t.Errorf("unexpected stored values: %v", rows)
+	}
+	rows, err := db.Query("SELECT data FROM t")
+	th.AssertErrNil(t, err, "unexpected error on select")
+	defer rows.Close()
+	var col omultimap.JSONColumn[string, int]
+	th.AssertErrNil(t, col.Scan([]byte(`{"old":0}`)), "unexpected error on scan")
+	rows.Next()
+	th.AssertErrNil(t, rows.Scan(&col), "unexpected error on scan")
+	th.ValidateIterator(t, col.Iterator(), true, th.JsonToKV[string, int](`[["x",1],["y",2],["x",3]]`))
+	rows.Next()
+	th.AssertErrNil(t, rows.Scan(&col), "unexpected error on scan of NULL")
+	if col.OMultiMap != nil {
+		t.Error("expected nil multimap for NULL")
+	}
+	th.AssertErrNil(t, rows.Err(), "unexpected error on rows")
+}
+
+func TestJSONColumnErrors(t *testing.T) {
+	var col omultimap.JSONColumn[string, int]
+	th.AssertErrIs(t, col.Scan(10), omap.ErrOMap, "expected error with unsupported type")
+	th.AssertErrNotNil(t, col.Scan(`{"a": "b"}`), "expected error with invalid value type")
+	invalid := omultimap.New[string, any]()
+	invalid.Put("a", make(chan int))
+	_, err := omultimap.JSONColumn[string, any]{invalid}.Value()
+	th.AssertErrNotNil(t, err, "expected error with invalid value")
+}
+
+func TestScanRow(t *testing.T) {
+	db, table := th.NewFakeDB("id", "name", "id", "name")
+	defer db.Close()
+	_, err := db.Exec("INSERT INTO t VALUES (?, ?, ?, ?)", 1, "a", 2, nil)
+	th.AssertErrNil(t, err, "unexpected error on insert")
+	rows, err := db.Query("SELECT * FROM t JOIN t2")
+	th.AssertErrNil(t, err, "unexpected error on select")
+	res, err := omultimap.ScanAll(rows)
+	th.AssertErrNil(t, err, "unexpected error on ScanAll")
+	if len(res) != 1 {
+		t.Fatalf("expected 1 row, found %d", len(res))
+	}
+	if keys := omap.IteratorKeysToSlice(res[0].Iterator()); !reflect.DeepEqual(keys, []string{"id", "name", "id", "name"}) {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+	if values := omap.IteratorValuesToSlice(res[0].Iterator()); !reflect.DeepEqual(values, []any{int64(1), "a", int64(2), nil}) {
+		t.Errorf("unexpected values: %#v", values)
+	}
+	// errors
+	rows, err = db.Query("SELECT * FROM t JOIN t2")
+	th.AssertErrNil(t, err, "unexpected error on select")
+	_, err = omultimap.ScanAll(rows, omap.ScanOptions{Null: func(string) (any, error) { return nil, th.ErrFakeTable }})
+	th.AssertErrIs(t, err, th.ErrFakeTable, "expected error from hook")
+	table.RowsErr = th.ErrFakeTable
+	rows, err = db.Query("SELECT * FROM t JOIN t2")
+	th.AssertErrNil(t, err, "unexpected error on select")
+	_, err = omultimap.ScanAll(rows)
+	th.AssertErrIs(t, err, th.ErrFakeTable, "expected error from driver")
+}
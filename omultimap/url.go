@@ -0,0 +1,164 @@
+package omultimap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+// Maximum size of a form body read by ParseRequestForm and ParsePostForm, same as net/http.
+const MaxFormSize = int64(10 << 20)
+
+// Returned by ParseRequestForm and ParsePostForm if the body is larger than MaxFormSize.
+var ErrFormTooLarge = fmt.Errorf("%w: form body larger than MaxFormSize", omap.ErrOMap)
+
+// Parse the given URL-encoded query string (as url.ParseQuery), but keeping the parameters in the
+// order found in the query, including repeated ones.
+//
+// As url.ParseQuery, it returns the first decoding error found, if any, along with all the
+// parameters that could be decoded.
+func ParseQuery(query string) (OMultiMap[string, string], error) {
+	m := New[string, string]()
+	err := parseQuery(m, query)
+	return m, err
+}
+
+func parseQuery(m OMultiMap[string, string], query string) (err error) {
+	for query != "" {
+		var key string
+		key, query, _ = strings.Cut(query, "&")
+		if strings.Contains(key, ";") {
+			if err == nil {
+				err = errors.New("invalid semicolon separator in query")
+			}
+			continue
+		}
+		if key == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(key, "=")
+		key, err1 := url.QueryUnescape(key)
+		if err1 != nil {
+			if err == nil {
+				err = err1
+			}
+			continue
+		}
+		value, err1 = url.QueryUnescape(value)
+		if err1 != nil {
+			if err == nil {
+				err = err1
+			}
+			continue
+		}
+		m.Put(key, value)
+	}
+	return err
+}
+
+// Options used by EncodeQueryWith.
+type QueryOptions struct {
+	// If true, spaces are escaped as "%20", so keys and values are percent-encoded as RFC 3986
+	// requires for the query component of URLs, otherwise they are escaped as "+", as done by
+	// url.QueryEscape and HTML forms. ParseQuery decodes both.
+	EscapeSpaceAsPercent bool
+}
+
+// Iterate over the given iterator it, from the given position, and encode the key/values in
+// "URL encoded" form ("bar=baz&foo=quux"), escaping them with url.QueryEscape. Different from
+// url.Values.Encode, keys are not sorted and are kept in the iteration order. See EncodeQueryWith
+// for escaping spaces as "%20" and how the result compares to the query given to ParseQuery.
+// Note: the iterator will be at EOF after this function returns.
+func EncodeQuery(it omap.OMapIterator[string, string]) string {
+	return EncodeQueryWith(it, QueryOptions{})
+}
+
+// Same as EncodeQuery, but escaping keys and values as set by opts.
+//
+// Encoding the result of ParseQuery gives the same parameters, in the same order, but not
+// necessarily the same query string: escaping is normalized (e.g. "%7e" becomes "~"), spaces are
+// always escaped as set by opts, empty parameters ("a&&b") are dropped and a key without "=" is
+// encoded with it ("k" becomes "k=").
+func EncodeQueryWith(it omap.OMapIterator[string, string], opts QueryOptions) string {
+	escape := url.QueryEscape
+	if opts.EscapeSpaceAsPercent {
+		// QueryEscape escapes "+" itself, so every "+" it returns is a space
+		escape = func(s string) string { return strings.ReplaceAll(url.QueryEscape(s), "+", "%20") }
+	}
+	var buf strings.Builder
+	for it.Next() {
+		if buf.Len() > 0 {
+			buf.WriteByte('&')
+		}
+		buf.WriteString(escape(it.Key()))
+		buf.WriteByte('=')
+		buf.WriteString(escape(it.Value()))
+	}
+	return buf.String()
+}
+
+// Parse the query string of the URL of the given request r, see ParseQuery.
+func ParseRequestQuery(r *http.Request) (OMultiMap[string, string], error) {
+	return ParseQuery(r.URL.RawQuery)
+}
+
+// Parse the body of the given request r, if its method is POST, PUT or PATCH and it is of type
+// "application/x-www-form-urlencoded", keeping the order of the parameters (see ParseQuery).
+// For any other request, an empty multimap is returned.
+//
+// Different from http.Request.ParseForm, r.Body is replaced by a new reader with the same content,
+// so it can still be read (or parsed by ParseForm) after this function returns, even if an error
+// is returned (e.g. ErrFormTooLarge). Closing the new reader closes the original body.
+func ParsePostForm(r *http.Request) (OMultiMap[string, string], error) {
+	m := New[string, string]()
+	err := parsePostForm(m, r)
+	return m, err
+}
+
+func parsePostForm(m OMultiMap[string, string], r *http.Request) error {
+	if r.Body == nil || (r.Method != http.MethodPost && r.Method != http.MethodPut && r.Method != http.MethodPatch) {
+		return nil
+	}
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		ct = "application/octet-stream"
+	}
+	ct, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return err
+	}
+	if ct != "application/x-www-form-urlencoded" {
+		return nil
+	}
+	b, err := io.ReadAll(io.LimitReader(r.Body, MaxFormSize+1))
+	// give back what was read, followed by the rest of the body, if any
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(b), r.Body), r.Body}
+	if err != nil {
+		return err
+	}
+	if int64(len(b)) > MaxFormSize {
+		return ErrFormTooLarge
+	}
+	return parseQuery(m, string(b))
+}
+
+// Parse both the body (see ParsePostForm) and the URL query string (see ParseRequestQuery) of the
+// given request r, in this order, which is the same precedence used by http.Request.Form.
+func ParseRequestForm(r *http.Request) (OMultiMap[string, string], error) {
+	m := New[string, string]()
+	err := parsePostForm(m, r)
+	if err1 := parseQuery(m, r.URL.RawQuery); err == nil {
+		err = err1
+	}
+	return m, err
+}
@@ -0,0 +1,133 @@
+package omultimap_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+)
+
+func TestParseQuery(t *testing.T) {
+	m, err := omultimap.ParseQuery("z=1&a=hello+world&z=%2F2&&empty=&novalue&a%26b=c%3Dd")
+	th.AssertErrNil(t, err, "unexpected error on ParseQuery")
+	exp := `[["z","1"],["a","hello world"],["z","/2"],["empty",""],["novalue",""],["a&b","c=d"]]`
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, string](exp))
+	if res := omultimap.EncodeQuery(m.Iterator()); res != "z=1&a=hello+world&z=%2F2&empty=&novalue=&a%26b=c%3Dd" {
+		t.Errorf("unexpected encoded query: %s", res)
+	}
+	// round-trip
+	m2, err := omultimap.ParseQuery(omultimap.EncodeQuery(m.Iterator()))
+	th.AssertErrNil(t, err, "unexpected error on ParseQuery")
+	th.ValidateIterator(t, m2.Iterator(), true, th.JsonToKV[string, string](exp))
+	if res := omultimap.EncodeQuery(omultimap.New[string, string]().Iterator()); res != "" {
+		t.Errorf("expected empty query, found %q", res)
+	}
+	// RFC 3986 escaping of spaces, "+" and "~" included
+	m, err = omultimap.ParseQuery("a%20b=hello+world%2B1&t=%7e")
+	th.AssertErrNil(t, err, "unexpected error on ParseQuery")
+	if res := omultimap.EncodeQueryWith(m.Iterator(), omultimap.QueryOptions{EscapeSpaceAsPercent: true}); res != "a%20b=hello%20world%2B1&t=~" {
+		t.Errorf("unexpected encoded query with EscapeSpaceAsPercent: %s", res)
+	}
+	if res := omultimap.EncodeQueryWith(m.Iterator(), omultimap.QueryOptions{}); res != "a+b=hello+world%2B1&t=~" {
+		t.Errorf("unexpected encoded query with default options: %s", res)
+	}
+	m2, err = omultimap.ParseQuery(omultimap.EncodeQueryWith(m.Iterator(), omultimap.QueryOptions{EscapeSpaceAsPercent: true}))
+	th.AssertErrNil(t, err, "unexpected error on ParseQuery")
+	th.ValidateIterator(t, m2.Iterator(), true, th.JsonToKV[string, string](`[["a b","hello world+1"],["t","~"]]`))
+	// errors keep valid parameters, and return the first error
+	m, err = omultimap.ParseQuery("a=1;b=2&c=%zz&d=4&%zz=5&e=%")
+	th.AssertErrNotNil(t, err, "expected error with invalid query")
+	if !strings.Contains(err.Error(), "semicolon") {
+		t.Errorf("expected semicolon error first, found %v", err)
+	}
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, string](`[["d","4"]]`))
+	_, err = omultimap.ParseQuery("%zz=5&c=%zz")
+	th.AssertErrNotNil(t, err, "expected error with invalid key")
+	_, err = omultimap.ParseQuery("c=%zz&%zz=5")
+	th.AssertErrNotNil(t, err, "expected error with invalid value")
+}
+
+func TestParseRequestForm(t *testing.T) {
+	body := "b=2&a=1&b=3"
+	r := httptest.NewRequest(http.MethodPost, "/path?q=x&a=0", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	m, err := omultimap.ParseRequestForm(r)
+	th.AssertErrNil(t, err, "unexpected error on ParseRequestForm")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, string](`[["b","2"],["a","1"],["b","3"],["q","x"],["a","0"]]`))
+	// body is still available
+	m, err = omultimap.ParsePostForm(r)
+	th.AssertErrNil(t, err, "unexpected error on ParsePostForm")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, string](`[["b","2"],["a","1"],["b","3"]]`))
+	th.AssertErrNil(t, r.ParseForm(), "unexpected error on ParseForm")
+	if r.PostForm.Get("b") != "2" {
+		t.Errorf("expected body to be readable again, found %v", r.PostForm)
+	}
+	m, err = omultimap.ParseRequestQuery(r)
+	th.AssertErrNil(t, err, "unexpected error on ParseRequestQuery")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, string](`[["q","x"],["a","0"]]`))
+	// body ignored for other methods and content types
+	for _, r := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/?q=x", strings.NewReader(body)),
+		httptest.NewRequest(http.MethodPost, "/?q=x", strings.NewReader(body)),
+		httptest.NewRequest(http.MethodPut, "/?q=x", nil),
+	} {
+		m, err := omultimap.ParseRequestForm(r)
+		th.AssertErrNil(t, err, "unexpected error on ParseRequestForm")
+		th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, string](`[["q","x"]]`))
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, th.ErrFakeTable
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestParseRequestFormErrors(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader("a=1"))
+	r.Header.Set("Content-Type", "invalid/")
+	_, err := omultimap.ParsePostForm(r)
+	th.AssertErrNotNil(t, err, "expected error with invalid content type")
+	r = httptest.NewRequest(http.MethodPost, "/", io.MultiReader(strings.NewReader("a=1"), failingReader{}))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	_, err = omultimap.ParsePostForm(r)
+	th.AssertErrIs(t, err, th.ErrFakeTable, "expected error from body")
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("a", int(omultimap.MaxFormSize)+1)))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	_, err = omultimap.ParsePostForm(r)
+	th.AssertErrIs(t, err, omultimap.ErrFormTooLarge, "expected error with large body")
+	th.AssertErrIs(t, err, omap.ErrOMap, "expected ErrFormTooLarge to wrap omap.ErrOMap")
+	// whole body must still be readable
+	if b, err := io.ReadAll(r.Body); err != nil || int64(len(b)) != omultimap.MaxFormSize+1 {
+		t.Errorf("expected body of %d bytes after ErrFormTooLarge, found %d bytes, error: %v", omultimap.MaxFormSize+1, len(b), err)
+	}
+	body := &closeRecorder{Reader: strings.NewReader("a=1")}
+	r = httptest.NewRequest(http.MethodPost, "/", body)
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	_, err = omultimap.ParsePostForm(r)
+	th.AssertErrNil(t, err, "unexpected error on ParsePostForm")
+	th.AssertErrNil(t, r.Body.Close(), "unexpected error on Close")
+	if !body.closed {
+		t.Error("expected original body to be closed")
+	}
+	r = httptest.NewRequest(http.MethodPost, "/?a=%zz", strings.NewReader("b=1"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	m, err := omultimap.ParseRequestForm(r)
+	th.AssertErrNotNil(t, err, "expected error with invalid query")
+	th.ValidateIterator(t, m.Iterator(), true, th.JsonToKV[string, string](`[["b","1"]]`))
+}
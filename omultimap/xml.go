@@ -0,0 +1,38 @@
+package omultimap
+
+import (
+	"encoding/xml"
+
+	"github.com/matheusoliveira/go-ordered-map/omap"
+)
+
+// Wraps an OMultiMap to be encoded and decoded by encoding/xml with the given Options, since the
+// xml.Marshaler and xml.Unmarshaler methods of the maps always use the default omap.XMLOptions.
+// See omap.XMLMap.
+//
+// When decoding, the values of Map are deleted before the decoded ones are put (or Map is created
+// with New if nil), so Options must be set before calling xml.Unmarshal.
+type XMLMap[K comparable, V any] struct {
+	Map     OMultiMap[K, V]
+	Options omap.XMLOptions
+}
+
+// Implement xml.Marshaler interface, see omap.MarshalXML.
+func (x XMLMap[K, V]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if x.Map == nil {
+		x.Map = New[K, V]()
+	}
+	return omap.MarshalXML(e, start, x.Map.Iterator(), x.Options)
+}
+
+// Implement xml.Unmarshaler interface, see omap.UnmarshalXML.
+func (x *XMLMap[K, V]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if x.Map == nil {
+		x.Map = New[K, V]()
+	} else {
+		for _, key := range omap.IteratorKeysToSlice(x.Map.Iterator()) {
+			x.Map.DeleteAll(key)
+		}
+	}
+	return omap.UnmarshalXML[K, V](func(key K, val V) { x.Map.Put(key, val) }, d, start, x.Options)
+}
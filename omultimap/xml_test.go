@@ -0,0 +1,65 @@
+package omultimap_test
+
+import (
+	"encoding/xml"
+	"testing"
+
+	th "github.com/matheusoliveira/go-ordered-map/internal/testhelper"
+	"github.com/matheusoliveira/go-ordered-map/omap"
+	"github.com/matheusoliveira/go-ordered-map/omultimap"
+)
+
+func TestXML(t *testing.T) {
+	type request struct {
+		XMLName xml.Name                                   `xml:"Request"`
+		Items   *omultimap.OMultiMapLinked[string, string] `xml:"Items"`
+	}
+	input := `<Request><Items><Item>a</Item><Note>n1</Note><Item>b</Item><Item>c</Item><Note>n2</Note></Items></Request>`
+	var req request
+	th.AssertErrNil(t, xml.Unmarshal([]byte(input), &req), "unexpected error on xml.Unmarshal")
+	th.ValidateIterator(t, req.Items.Iterator(), true, th.JsonToKV[string, string](`[["Item","a"],["Note","n1"],["Item","b"],["Item","c"],["Note","n2"]]`))
+	th.ValidateIterator(t, req.Items.GetValuesOf("Item"), true, th.JsonToKV[string, string](`[["Item","a"],["Item","b"],["Item","c"]]`))
+	data, err := xml.Marshal(req)
+	th.AssertErrNil(t, err, "unexpected error on xml.Marshal")
+	if string(data) != input {
+		t.Errorf("expected %s, found %s", input, string(data))
+	}
+	// unmarshal again on same map should reset it
+	th.AssertErrNil(t, xml.Unmarshal([]byte(`<Items><X>1</X></Items>`), req.Items), "unexpected error on xml.Unmarshal")
+	th.ValidateIterator(t, req.Items.Iterator(), true, th.JsonToKV[string, string](`[["X","1"]]`))
+}
+
+func TestXMLMap(t *testing.T) {
+	mm := omultimap.New[string, int]()
+	mm.Put("b", 1, 2)
+	mm.Put("a", 3)
+	data, err := xml.Marshal(omultimap.XMLMap[string, int]{Map: mm})
+	th.AssertErrNil(t, err, "unexpected error on xml.Marshal")
+	if exp := `<XMLMap><b>1</b><b>2</b><a>3</a></XMLMap>`; string(data) != exp {
+		t.Errorf("expected %s, found %s", exp, string(data))
+	}
+	var out omultimap.XMLMap[string, int]
+	th.AssertErrNil(t, xml.Unmarshal(data, &out), "unexpected error on xml.Unmarshal")
+	th.ValidateIterator(t, out.Map.Iterator(), true, th.JsonToKV[string, int](`[["b",1],["b",2],["a",3]]`))
+	// attributes
+	attrs := omultimap.XMLMap[string, int]{Options: omap.XMLOptions{Attributes: true}}
+	th.AssertErrNil(t, xml.Unmarshal([]byte(`<t x="1" y="2"/>`), &attrs), "unexpected error on xml.Unmarshal")
+	th.ValidateIterator(t, attrs.Map.Iterator(), true, th.JsonToKV[string, int](`[["x",1],["y",2]]`))
+	data, err = xml.Marshal(attrs)
+	th.AssertErrNil(t, err, "unexpected error on xml.Marshal")
+	if exp := `<XMLMap x="1" y="2"></XMLMap>`; string(data) != exp {
+		t.Errorf("expected %s, found %s", exp, string(data))
+	}
+	// repeated keys can't be attributes
+	mm.Put("a", 4)
+	_, err = xml.Marshal(omultimap.XMLMap[string, int]{Map: mm, Options: omap.XMLOptions{Attributes: true}})
+	th.AssertErrIs(t, err, omap.ErrDuplicateKey, "expected error with repeated attribute")
+	// reused, the previous values are deleted
+	th.AssertErrNil(t, xml.Unmarshal([]byte(`<t z="9"/>`), &attrs), "unexpected error on xml.Unmarshal")
+	th.ValidateIterator(t, attrs.Map.Iterator(), true, th.JsonToKV[string, int](`[["z",9]]`))
+	data, err = xml.Marshal(omultimap.XMLMap[string, int]{})
+	th.AssertErrNil(t, err, "unexpected error on xml.Marshal")
+	if exp := `<XMLMap></XMLMap>`; string(data) != exp {
+		t.Errorf("expected %s, found %s", exp, string(data))
+	}
+}